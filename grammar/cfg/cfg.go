@@ -0,0 +1,136 @@
+package cfg
+
+import (
+	"fmt"
+	"mini-parser/parser"
+	"strings"
+)
+
+// Block 基本块: 顺序执行的简单语句, 以可选的条件跳转结束
+type Block struct {
+	ID    int
+	Stmts []parser.Statement
+	// Cond不为nil时, Succs[0]为条件成立的后继, Succs[1]为条件不成立的后继
+	Cond  parser.Expression
+	Succs []*Block
+	Preds []*Block
+}
+
+// Graph Mini程序的控制流图
+type Graph struct {
+	Entry  *Block
+	Exit   *Block
+	Blocks []*Block
+}
+
+// Build 由主程序的语句构造控制流图
+func Build(program *parser.Program) *Graph {
+	return build(program.Statements)
+}
+
+// BuildProcedure 由过程体构造控制流图, return 语句跳转到出口块
+func BuildProcedure(proc *parser.ProcedureDeclaration) *Graph {
+	return build(proc.Body.Statements)
+}
+
+func build(stmts []parser.Statement) *Graph {
+	b := &builder{g: &Graph{}}
+	b.g.Entry = b.newBlock()
+	last := b.stmts(stmts, b.g.Entry)
+	b.g.Exit = b.newBlock()
+	link(last, b.g.Exit)
+	for _, block := range b.returns {
+		link(block, b.g.Exit)
+	}
+	return b.g
+}
+
+type builder struct {
+	g       *Graph
+	returns []*Block // 以 return 结束的块
+}
+
+func (b *builder) newBlock() *Block {
+	block := &Block{ID: len(b.g.Blocks)}
+	b.g.Blocks = append(b.g.Blocks, block)
+	return block
+}
+
+func link(from, to *Block) {
+	from.Succs = append(from.Succs, to)
+	to.Preds = append(to.Preds, from)
+}
+
+// stmts 把语句序列接在cur之后, 返回控制流继续执行的块
+func (b *builder) stmts(list []parser.Statement, cur *Block) *Block {
+	for _, s := range list {
+		cur = b.stmt(s, cur)
+	}
+	return cur
+}
+
+func (b *builder) stmt(s parser.Statement, cur *Block) *Block {
+	switch s := s.(type) {
+	case *parser.BlockStatement:
+		return b.stmts(s.Statements, cur)
+	case *parser.IfExpression:
+		cur.Cond = s.Condition
+		then := b.newBlock()
+		link(cur, then)
+		var els *Block
+		if s.Alternative != nil {
+			els = b.newBlock()
+			link(cur, els)
+		}
+		join := b.newBlock()
+		link(b.stmts(s.Consequence.Statements, then), join)
+		if els != nil {
+			link(b.stmts(s.Alternative.Statements, els), join)
+		} else {
+			link(cur, join)
+		}
+		return join
+	case *parser.WhileExpression:
+		head := b.newBlock()
+		link(cur, head)
+		head.Cond = s.Condition
+		body := b.newBlock()
+		link(head, body)
+		after := b.newBlock()
+		link(head, after)
+		link(b.stmts(s.Body.Statements, body), head)
+		return after
+	case *parser.ReturnStatement:
+		cur.Stmts = append(cur.Stmts, s)
+		b.returns = append(b.returns, cur)
+		// return 之后的语句不可达, 放在没有前驱的新块中
+		return b.newBlock()
+	default:
+		cur.Stmts = append(cur.Stmts, s)
+		return cur
+	}
+}
+
+func (g *Graph) String() string {
+	var out strings.Builder
+	for _, block := range g.Blocks {
+		fmt.Fprintf(&out, "B%d:", block.ID)
+		switch block {
+		case g.Entry:
+			out.WriteString(" (entry)")
+		case g.Exit:
+			out.WriteString(" (exit)")
+		}
+		out.WriteString("\n")
+		for _, s := range block.Stmts {
+			fmt.Fprintf(&out, "    %s\n", s.String())
+		}
+		if block.Cond != nil {
+			fmt.Fprintf(&out, "    if %s goto B%d else B%d\n",
+				block.Cond.String(), block.Succs[0].ID, block.Succs[1].ID)
+		} else if len(block.Succs) == 1 {
+			fmt.Fprintf(&out, "    goto B%d\n", block.Succs[0].ID)
+		}
+	}
+	return out.String()
+}
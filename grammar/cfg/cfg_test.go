@@ -0,0 +1,44 @@
+package cfg
+
+import (
+	"mini-parser/parser"
+	"mini-parser/token"
+	"testing"
+)
+
+// return 所在的块跳到出口块, 之后的语句放在不可达的块中
+func TestBuildProcedureReturn(t *testing.T) {
+	p := parser.New(token.New(`program p;
+function f(a);
+begin
+    if (a > 0) then return 1;
+    return 2;
+    a := 3
+end;
+begin end.`))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("语法错误: %v", p.Errors())
+	}
+	g := BuildProcedure(program.Procedures[0])
+
+	// B3、B4 分别是两个 return 之后不可达的块
+	want := `B0: (entry)
+    if (a > 0) goto B1 else B2
+B1:
+    return 1;
+    goto B5
+B2:
+    return 2;
+    goto B5
+B3:
+    goto B2
+B4:
+    a := 3;
+    goto B5
+B5: (exit)
+`
+	if got := g.String(); got != want {
+		t.Errorf("控制流图\n%s\n期望\n%s", got, want)
+	}
+}
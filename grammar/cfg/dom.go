@@ -0,0 +1,131 @@
+package cfg
+
+// DomTree 支配树及支配边界, 均以块ID为下标
+type DomTree struct {
+	Idom     []*Block // 直接支配者, 入口块和不可达块为nil
+	Children [][]*Block
+	Frontier [][]*Block
+	order    []*Block // 逆后序
+}
+
+// Dominators 用Cooper-Harvey-Kennedy迭代算法计算支配树和支配边界
+func Dominators(g *Graph) *DomTree {
+	n := len(g.Blocks)
+	d := &DomTree{
+		Idom:     make([]*Block, n),
+		Children: make([][]*Block, n),
+		Frontier: make([][]*Block, n),
+	}
+
+	d.order = reversePostorder(g)
+	rpo := make([]int, n)
+	for i := range rpo {
+		rpo[i] = -1
+	}
+	for i, b := range d.order {
+		rpo[b.ID] = i
+	}
+
+	intersect := func(a, b *Block) *Block {
+		for a != b {
+			for rpo[a.ID] > rpo[b.ID] {
+				a = d.Idom[a.ID]
+			}
+			for rpo[b.ID] > rpo[a.ID] {
+				b = d.Idom[b.ID]
+			}
+		}
+		return a
+	}
+
+	d.Idom[g.Entry.ID] = g.Entry
+	for changed := true; changed; {
+		changed = false
+		for _, b := range d.order[1:] {
+			var idom *Block
+			for _, p := range b.Preds {
+				if d.Idom[p.ID] == nil {
+					continue
+				}
+				if idom == nil {
+					idom = p
+				} else {
+					idom = intersect(p, idom)
+				}
+			}
+			if idom != d.Idom[b.ID] {
+				d.Idom[b.ID] = idom
+				changed = true
+			}
+		}
+	}
+	d.Idom[g.Entry.ID] = nil
+
+	for _, b := range d.order[1:] {
+		idom := d.Idom[b.ID]
+		d.Children[idom.ID] = append(d.Children[idom.ID], b)
+	}
+
+	for _, b := range d.order {
+		if len(b.Preds) < 2 {
+			continue
+		}
+		for _, p := range b.Preds {
+			if rpo[p.ID] < 0 {
+				continue
+			}
+			for runner := p; runner != d.Idom[b.ID]; runner = d.Idom[runner.ID] {
+				if !contains(d.Frontier[runner.ID], b) {
+					d.Frontier[runner.ID] = append(d.Frontier[runner.ID], b)
+				}
+			}
+		}
+	}
+
+	return d
+}
+
+// Dominates 判断a是否支配b
+func (d *DomTree) Dominates(a, b *Block) bool {
+	for ; b != nil; b = d.Idom[b.ID] {
+		if a == b {
+			return true
+		}
+	}
+	return false
+}
+
+// Order 返回可达块的逆后序
+func (d *DomTree) Order() []*Block {
+	return d.order
+}
+
+func reversePostorder(g *Graph) []*Block {
+	visited := make([]bool, len(g.Blocks))
+	var post []*Block
+	var visit func(b *Block)
+	visit = func(b *Block) {
+		visited[b.ID] = true
+		for _, s := range b.Succs {
+			if !visited[s.ID] {
+				visit(s)
+			}
+		}
+		post = append(post, b)
+	}
+	visit(g.Entry)
+
+	for i, j := 0, len(post)-1; i < j; i, j = i+1, j-1 {
+		post[i], post[j] = post[j], post[i]
+	}
+	return post
+}
+
+func contains(blocks []*Block, b *Block) bool {
+	for _, x := range blocks {
+		if x == b {
+			return true
+		}
+	}
+	return false
+}
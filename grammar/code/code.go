@@ -0,0 +1,196 @@
+package code
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"mini-parser/msg"
+)
+
+type Instructions []byte
+
+type Opcode byte
+
+const (
+	OpConstant Opcode = iota
+	OpLoad
+	OpStore
+	OpTrue
+	OpFalse
+
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpMod
+	OpMinus
+	OpBang
+	OpReal
+
+	OpEqual
+	OpNotEqual
+	OpLess
+	OpGreater
+	OpLessEqual
+	OpGreaterEqual
+
+	OpJump
+	OpJumpNotTruthy
+)
+
+type Definition struct {
+	Name          string
+	OperandWidths []int
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant: {"OpConstant", []int{2}}, // 常量表下标
+	OpLoad:     {"OpLoad", []int{2}},     // 变量槽位
+	OpStore:    {"OpStore", []int{2}},    // 变量槽位
+	OpTrue:     {"OpTrue", []int{}},
+	OpFalse:    {"OpFalse", []int{}},
+
+	OpAdd:   {"OpAdd", []int{}},
+	OpSub:   {"OpSub", []int{}},
+	OpMul:   {"OpMul", []int{}},
+	OpDiv:   {"OpDiv", []int{}},
+	OpMod:   {"OpMod", []int{}},
+	OpMinus: {"OpMinus", []int{}},
+	OpBang:  {"OpBang", []int{}},
+	OpReal:  {"OpReal", []int{}}, // 栈顶的integer转换为real, 用于赋给声明为real的变量
+
+	OpEqual:        {"OpEqual", []int{}},
+	OpNotEqual:     {"OpNotEqual", []int{}},
+	OpLess:         {"OpLess", []int{}},
+	OpGreater:      {"OpGreater", []int{}},
+	OpLessEqual:    {"OpLessEqual", []int{}},
+	OpGreaterEqual: {"OpGreaterEqual", []int{}},
+
+	OpJump:          {"OpJump", []int{2}},          // 跳转目标偏移
+	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{2}}, // 栈顶为false时跳转
+}
+
+func Lookup(op byte) (*Definition, error) {
+	def, ok := definitions[Opcode(op)]
+	if !ok {
+		return nil, fmt.Errorf("未定义的操作码 %d", op)
+	}
+	return def, nil
+}
+
+// CheckOperands 检查操作数能否按操作码定义的宽度编码, Make 对超出范围的操作数只保留低位
+func CheckOperands(op Opcode, operands ...int) error {
+	def, ok := definitions[op]
+	if !ok {
+		return fmt.Errorf("未定义的操作码 %d", op)
+	}
+	for i, o := range operands {
+		if i >= len(def.OperandWidths) {
+			return fmt.Errorf("%s 只有 %d 个操作数", def.Name, len(def.OperandWidths))
+		}
+		limit := 1<<(8*def.OperandWidths[i]) - 1
+		if o < 0 || o > limit {
+			return errors.New(msg.Get(msg.OperandRange, def.Name, o, limit))
+		}
+	}
+	return nil
+}
+
+// Make 按操作码定义编码一条指令
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+
+	length := 1
+	for _, w := range def.OperandWidths {
+		length += w
+	}
+
+	instruction := make([]byte, length)
+	instruction[0] = byte(op)
+
+	offset := 1
+	for i, o := range operands {
+		width := def.OperandWidths[i]
+		switch width {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(o))
+		}
+		offset += width
+	}
+
+	return instruction
+}
+
+// ReadOperands 解码指令的操作数, 返回操作数和读取的字节数
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+
+	for i, width := range def.OperandWidths {
+		switch width {
+		case 2:
+			operands[i] = int(ReadUint16(ins[offset:]))
+		}
+		offset += width
+	}
+
+	return operands, offset
+}
+
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+// String 反汇编指令序列, 每行一条指令
+func (ins Instructions) String() string {
+	return ins.Disassemble(nil)
+}
+
+// Disassemble 反汇编指令序列, annotate不为nil时其结果作为注释附在每条指令之后
+func (ins Instructions) Disassemble(annotate func(offset int) string) string {
+	var out bytes.Buffer
+
+	i := 0
+	for i < len(ins) {
+		def, err := Lookup(ins[i])
+		if err != nil {
+			fmt.Fprintf(&out, "ERROR: %s\n", err)
+			i++
+			continue
+		}
+
+		operands, read := ReadOperands(def, ins[i+1:])
+		text := ins.fmtInstruction(def, operands)
+		if annotate != nil {
+			if note := annotate(i); note != "" {
+				text = fmt.Sprintf("%-24s ; %s", text, note)
+			}
+		}
+		fmt.Fprintf(&out, "%04d %s\n", i, text)
+
+		i += 1 + read
+	}
+
+	return out.String()
+}
+
+func (ins Instructions) fmtInstruction(def *Definition, operands []int) string {
+	operandCount := len(def.OperandWidths)
+
+	if len(operands) != operandCount {
+		return fmt.Sprintf("ERROR: 操作数个数 %d 与定义 %d 不符\n", len(operands), operandCount)
+	}
+
+	switch operandCount {
+	case 0:
+		return def.Name
+	case 1:
+		return fmt.Sprintf("%s %d", def.Name, operands[0])
+	}
+
+	return fmt.Sprintf("ERROR: 未处理的操作数个数 %s\n", def.Name)
+}
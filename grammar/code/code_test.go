@@ -0,0 +1,90 @@
+package code
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		op       Opcode
+		operands []int
+		want     []byte
+	}{
+		{OpConstant, []int{65534}, []byte{byte(OpConstant), 255, 254}},
+		{OpJump, []int{258}, []byte{byte(OpJump), 1, 2}},
+		{OpAdd, nil, []byte{byte(OpAdd)}},
+	}
+	for _, tt := range tests {
+		got := Make(tt.op, tt.operands...)
+		if string(got) != string(tt.want) {
+			t.Errorf("Make(%d, %v) = %v, 期望 %v", tt.op, tt.operands, got, tt.want)
+		}
+	}
+}
+
+func TestReadOperands(t *testing.T) {
+	for _, op := range []Opcode{OpConstant, OpLoad, OpStore, OpJump, OpJumpNotTruthy} {
+		def, err := Lookup(byte(op))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ins := Make(op, 1000)
+		operands, read := ReadOperands(def, ins[1:])
+		if read != 2 || len(operands) != 1 || operands[0] != 1000 {
+			t.Errorf("%s: 读出 %v, %d 字节", def.Name, operands, read)
+		}
+	}
+}
+
+// 超出两字节的操作数由 CheckOperands 报告, Make 只保留低位
+func TestCheckOperands(t *testing.T) {
+	if err := CheckOperands(OpConstant, 65535); err != nil {
+		t.Errorf("65535 应能编码: %v", err)
+	}
+	for _, operand := range []int{65536, -1} {
+		if err := CheckOperands(OpConstant, operand); err == nil || !strings.Contains(err.Error(), "OpConstant") {
+			t.Errorf("操作数 %d 应报告超出范围, 实际为 %v", operand, err)
+		}
+	}
+	if err := CheckOperands(OpAdd, 1); err == nil {
+		t.Error("OpAdd 没有操作数, 应报告错误")
+	}
+}
+
+func TestDisassemble(t *testing.T) {
+	var ins Instructions
+	for _, i := range [][]byte{
+		Make(OpConstant, 1),
+		Make(OpLoad, 2),
+		Make(OpAdd),
+		Make(OpJumpNotTruthy, 12),
+		Make(OpStore, 65535),
+	} {
+		ins = append(ins, i...)
+	}
+
+	want := `0000 OpConstant 1
+0003 OpLoad 2
+0006 OpAdd
+0007 OpJumpNotTruthy 12
+0010 OpStore 65535
+`
+	if got := ins.String(); got != want {
+		t.Errorf("反汇编结果\n%s\n期望\n%s", got, want)
+	}
+
+	annotated := ins.Disassemble(func(offset int) string {
+		if offset == 6 {
+			return "加法"
+		}
+		return ""
+	})
+	if line := strings.Split(annotated, "\n")[2]; line != "0006 OpAdd                    ; 加法" {
+		t.Errorf("带注释的指令为 %q", line)
+	}
+
+	if got := (Instructions{255}).String(); !strings.HasPrefix(got, "ERROR: ") {
+		t.Errorf("未定义的操作码反汇编为 %q", got)
+	}
+}
@@ -0,0 +1,58 @@
+package compiler
+
+import (
+	"bytes"
+	"fmt"
+	"mini-parser/code"
+	"mini-parser/object"
+	"sort"
+)
+
+// Position 从某条指令开始对应的Mini源位置
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+type Bytecode struct {
+	Instructions code.Instructions
+	Constants    []object.Object
+	Names        []string // 变量槽位对应的变量名
+	Positions    []Position
+}
+
+// PositionOf 返回偏移处指令对应的源位置
+func (b *Bytecode) PositionOf(offset int) (line, column int) {
+	i := sort.Search(len(b.Positions), func(i int) bool {
+		return b.Positions[i].Offset > offset
+	})
+	if i == 0 {
+		return 0, 0
+	}
+	p := b.Positions[i-1]
+	return p.Line, p.Column
+}
+
+// Disassemble 输出常量表、变量槽位和带源位置注释的指令
+func (b *Bytecode) Disassemble() string {
+	var out bytes.Buffer
+
+	out.WriteString("== 常量 ==\n")
+	for i, c := range b.Constants {
+		fmt.Fprintf(&out, "%d: %s %s\n", i, c.Type(), c.Inspect())
+	}
+
+	out.WriteString("== 变量 ==\n")
+	for i, name := range b.Names {
+		fmt.Fprintf(&out, "%d: %s\n", i, name)
+	}
+
+	out.WriteString("== 指令 ==\n")
+	out.WriteString(b.Instructions.Disassemble(func(offset int) string {
+		line, column := b.PositionOf(offset)
+		return fmt.Sprintf("%d:%d", line, column)
+	}))
+
+	return out.String()
+}
@@ -0,0 +1,298 @@
+package compiler
+
+import (
+	"errors"
+	"mini-parser/code"
+	"mini-parser/msg"
+	"mini-parser/object"
+	"mini-parser/parser"
+	"mini-parser/token"
+	"mini-parser/types"
+)
+
+type Compiler struct {
+	instructions code.Instructions
+	constants    []object.Object
+	slots        map[string]int
+	names        []string
+	positions    []Position
+	pos          token.Token // 当前正在编译的源记号
+	// 声明为 real 的变量, 赋值时把 integer 转换为 real
+	reals map[string]bool
+	// 常量在常量表中的下标, 相同的常量只保存一份
+	constantIndex map[string]int
+	// 第一个无法编码的操作数, 如常量表或程序过大
+	err error
+}
+
+func New() *Compiler {
+	return &Compiler{
+		instructions:  code.Instructions{},
+		constants:     []object.Object{},
+		slots:         map[string]int{},
+		reals:         map[string]bool{},
+		constantIndex: map[string]int{},
+	}
+}
+
+// NewWithState 沿用已有的变量槽位和常量表, 供REPL逐条编译时保持状态
+func NewWithState(names []string, constants []object.Object) *Compiler {
+	c := New()
+	c.constants = constants
+	for i, obj := range constants {
+		c.constantIndex[constantKey(obj)] = i
+	}
+	for _, name := range names {
+		c.slot(name)
+	}
+	return c
+}
+
+func (c *Compiler) Compile(node parser.Node) error {
+	switch node := node.(type) {
+	case *parser.Program:
+		// 声明的变量按声明的顺序分配槽位
+		for _, decl := range node.Vars {
+			isReal := false
+			if decl.Type != nil {
+				t, _ := types.Lookup(decl.Type.Value)
+				isReal = t == types.Real
+			}
+			for _, name := range decl.Names {
+				c.slot(name.Value)
+				c.reals[name.Value] = isReal
+			}
+		}
+		return c.compileStatements(node.Statements)
+
+	case *parser.BlockStatement:
+		return c.compileStatements(node.Statements)
+
+	case *parser.AssignStatement:
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+		c.pos = node.Name.Token
+		if c.reals[node.Name.Value] {
+			c.emit(code.OpReal)
+		}
+		c.emit(code.OpStore, c.slot(node.Name.Value))
+
+	case *parser.IfExpression:
+		if err := c.Compile(node.Condition); err != nil {
+			return err
+		}
+		c.pos = node.Token
+		jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+		if err := c.Compile(node.Consequence); err != nil {
+			return err
+		}
+
+		if node.Alternative == nil {
+			c.changeOperand(jumpNotTruthyPos, len(c.instructions))
+			return c.err
+		}
+
+		c.pos = node.Token
+		jumpPos := c.emit(code.OpJump, 9999)
+		c.changeOperand(jumpNotTruthyPos, len(c.instructions))
+
+		if err := c.Compile(node.Alternative); err != nil {
+			return err
+		}
+		c.changeOperand(jumpPos, len(c.instructions))
+
+	case *parser.WhileExpression:
+		loopStart := len(c.instructions)
+		if err := c.Compile(node.Condition); err != nil {
+			return err
+		}
+		c.pos = node.Token
+		jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+		if err := c.Compile(node.Body); err != nil {
+			return err
+		}
+
+		c.pos = node.Token
+		c.emit(code.OpJump, loopStart)
+		c.changeOperand(jumpNotTruthyPos, len(c.instructions))
+
+	case *parser.InfixExpression:
+		if node.Operator == "&&" || node.Operator == "||" {
+			return c.compileLogical(node)
+		}
+
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+
+		op, ok := infixOps[node.Operator]
+		if !ok {
+			return errors.New(msg.Get(msg.UnknownOperator, node.Operator))
+		}
+		c.pos = node.Token
+		c.emit(op)
+
+	case *parser.PrefixExpression:
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+
+		c.pos = node.Token
+		switch node.Operator {
+		case "!":
+			c.emit(code.OpBang)
+		case "-":
+			c.emit(code.OpMinus)
+		default:
+			return errors.New(msg.Get(msg.UnknownOperator, node.Operator))
+		}
+
+	case *parser.Identifier:
+		c.pos = node.Token
+		c.emit(code.OpLoad, c.slot(node.Value))
+
+	case *parser.IntegerLiteral:
+		c.pos = node.Token
+		c.emit(code.OpConstant, c.addConstant(&object.Integer{Value: node.Value}))
+
+	case *parser.RealLiteral:
+		c.pos = node.Token
+		c.emit(code.OpConstant, c.addConstant(&object.Real{Value: node.Value}))
+
+	case *parser.Boolean:
+		c.pos = node.Token
+		if node.Value {
+			c.emit(code.OpTrue)
+		} else {
+			c.emit(code.OpFalse)
+		}
+
+	default:
+		return errors.New(msg.Get(msg.CannotCompile, node))
+	}
+
+	return c.err
+}
+
+var infixOps = map[string]code.Opcode{
+	"+":  code.OpAdd,
+	"-":  code.OpSub,
+	"*":  code.OpMul,
+	"/":  code.OpDiv,
+	"%":  code.OpMod,
+	"=":  code.OpEqual,
+	"!=": code.OpNotEqual,
+	"<":  code.OpLess,
+	">":  code.OpGreater,
+	"<=": code.OpLessEqual,
+	">=": code.OpGreaterEqual,
+}
+
+func (c *Compiler) compileStatements(statements []parser.Statement) error {
+	for _, s := range statements {
+		if err := c.Compile(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compileLogical 按短路语义编译 && 和 ||
+func (c *Compiler) compileLogical(node *parser.InfixExpression) error {
+	if err := c.Compile(node.Left); err != nil {
+		return err
+	}
+
+	c.pos = node.Token
+	jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+	if node.Operator == "&&" {
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+		c.pos = node.Token
+		jumpPos := c.emit(code.OpJump, 9999)
+		c.changeOperand(jumpNotTruthyPos, len(c.instructions))
+		c.emit(code.OpFalse)
+		c.changeOperand(jumpPos, len(c.instructions))
+		return c.err
+	}
+
+	c.emit(code.OpTrue)
+	jumpPos := c.emit(code.OpJump, 9999)
+	c.changeOperand(jumpNotTruthyPos, len(c.instructions))
+	if err := c.Compile(node.Right); err != nil {
+		return err
+	}
+	c.changeOperand(jumpPos, len(c.instructions))
+	return c.err
+}
+
+// slot 返回变量的槽位, 首次出现的变量分配新槽位
+func (c *Compiler) slot(name string) int {
+	if s, ok := c.slots[name]; ok {
+		return s
+	}
+	s := len(c.names)
+	c.slots[name] = s
+	c.names = append(c.names, name)
+	return s
+}
+
+// addConstant 返回常量在常量表中的下标, 已有相同的常量时不再追加
+func (c *Compiler) addConstant(obj object.Object) int {
+	key := constantKey(obj)
+	if i, ok := c.constantIndex[key]; ok {
+		return i
+	}
+	c.constants = append(c.constants, obj)
+	c.constantIndex[key] = len(c.constants) - 1
+	return len(c.constants) - 1
+}
+
+// constantKey 区分类型, 使 integer 1 与 real 1 是不同的常量
+func constantKey(obj object.Object) string {
+	return string(obj.Type()) + " " + obj.Inspect()
+}
+
+// emit 追加一条指令并记录其源位置, 返回指令的偏移
+func (c *Compiler) emit(op code.Opcode, operands ...int) int {
+	pos := len(c.instructions)
+	c.check(op, operands...)
+	c.instructions = append(c.instructions, code.Make(op, operands...)...)
+
+	if n := len(c.positions); n == 0 ||
+		c.positions[n-1].Line != c.pos.Line || c.positions[n-1].Column != c.pos.Column {
+		c.positions = append(c.positions, Position{Offset: pos, Line: c.pos.Line, Column: c.pos.Column})
+	}
+
+	return pos
+}
+
+// check 记录第一个无法编码的操作数, 由 Compile 返回
+func (c *Compiler) check(op code.Opcode, operands ...int) {
+	if err := code.CheckOperands(op, operands...); err != nil && c.err == nil {
+		c.err = errors.New(msg.Get(msg.Location, c.pos.Line, c.pos.Column, err))
+	}
+}
+
+func (c *Compiler) changeOperand(opPos int, operand int) {
+	op := code.Opcode(c.instructions[opPos])
+	c.check(op, operand)
+	copy(c.instructions[opPos:], code.Make(op, operand))
+}
+
+func (c *Compiler) Bytecode() *Bytecode {
+	return &Bytecode{
+		Instructions: c.instructions,
+		Constants:    c.constants,
+		Names:        c.names,
+		Positions:    c.positions,
+	}
+}
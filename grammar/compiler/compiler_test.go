@@ -0,0 +1,168 @@
+package compiler
+
+import (
+	"mini-parser/code"
+	"mini-parser/parser"
+	"mini-parser/token"
+	"testing"
+)
+
+func compile(t *testing.T, src string) *Bytecode {
+	t.Helper()
+	p := parser.New(token.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("语法错误: %v", p.Errors())
+	}
+	c := New()
+	if err := c.Compile(program); err != nil {
+		t.Fatalf("编译错误: %v", err)
+	}
+	return c.Bytecode()
+}
+
+func concat(instructions ...[]byte) code.Instructions {
+	var out code.Instructions
+	for _, ins := range instructions {
+		out = append(out, ins...)
+	}
+	return out
+}
+
+func TestInstructions(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want code.Instructions
+	}{
+		{"infix", "x := 1 + 2", concat(
+			code.Make(code.OpConstant, 0),
+			code.Make(code.OpConstant, 1),
+			code.Make(code.OpAdd),
+			code.Make(code.OpStore, 0),
+		)},
+		// 相同的常量只保存一份, 变量按首次出现分配槽位
+		{"slots", "x := 1; y := x * 1", concat(
+			code.Make(code.OpConstant, 0),
+			code.Make(code.OpStore, 0),
+			code.Make(code.OpLoad, 0),
+			code.Make(code.OpConstant, 0),
+			code.Make(code.OpMul),
+			code.Make(code.OpStore, 1),
+		)},
+		{"prefix", "x := -1; b := !true", concat(
+			code.Make(code.OpConstant, 0),
+			code.Make(code.OpMinus),
+			code.Make(code.OpStore, 0),
+			code.Make(code.OpTrue),
+			code.Make(code.OpBang),
+			code.Make(code.OpStore, 1),
+		)},
+		// 条件为假时跳过 then 分支
+		{"if", "if (true) then x := 1; y := 2", concat(
+			code.Make(code.OpTrue),
+			code.Make(code.OpJumpNotTruthy, 10),
+			code.Make(code.OpConstant, 0),
+			code.Make(code.OpStore, 0),
+			code.Make(code.OpConstant, 1),
+			code.Make(code.OpStore, 1),
+		)},
+		// then 分支之后跳过 else 分支
+		{"if else", "if (true) then x := 1 else x := 2", concat(
+			code.Make(code.OpTrue),
+			code.Make(code.OpJumpNotTruthy, 13),
+			code.Make(code.OpConstant, 0),
+			code.Make(code.OpStore, 0),
+			code.Make(code.OpJump, 19),
+			code.Make(code.OpConstant, 1),
+			code.Make(code.OpStore, 0),
+		)},
+		// 循环体之后跳回条件, 条件为假时跳到循环之后
+		{"while", "while (false) do x := 1", concat(
+			code.Make(code.OpFalse),
+			code.Make(code.OpJumpNotTruthy, 13),
+			code.Make(code.OpConstant, 0),
+			code.Make(code.OpStore, 0),
+			code.Make(code.OpJump, 0),
+		)},
+		// 短路求值: 左边为假时结果为 false, 不计算右边
+		{"and", "b := true && false", concat(
+			code.Make(code.OpTrue),
+			code.Make(code.OpJumpNotTruthy, 8),
+			code.Make(code.OpFalse),
+			code.Make(code.OpJump, 9),
+			code.Make(code.OpFalse),
+			code.Make(code.OpStore, 0),
+		)},
+		// 左边为真时结果为 true, 否则结果为右边的值
+		{"or", "b := false || true", concat(
+			code.Make(code.OpFalse),
+			code.Make(code.OpJumpNotTruthy, 8),
+			code.Make(code.OpTrue),
+			code.Make(code.OpJump, 9),
+			code.Make(code.OpTrue),
+			code.Make(code.OpStore, 0),
+		)},
+		// 声明为 real 的变量赋值前把 integer 转换为 real
+		{"real var", "program p; var r: real; begin r := 1 end.", concat(
+			code.Make(code.OpConstant, 0),
+			code.Make(code.OpReal),
+			code.Make(code.OpStore, 0),
+		)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compile(t, tt.src).Instructions; got.String() != tt.want.String() {
+				t.Errorf("指令\n%s\n期望\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// integer 1 与 real 1.0 是不同的常量
+func TestConstants(t *testing.T) {
+	b := compile(t, "x := 1; y := 1.0; z := 1")
+	if len(b.Constants) != 2 || b.Constants[0].Inspect() != "1" || b.Constants[1].Type() != "REAL" {
+		t.Errorf("常量表 %v", b.Constants)
+	}
+}
+
+func TestPositionOf(t *testing.T) {
+	b := compile(t, "x := 1;\ny := x / 2")
+	tests := []struct {
+		offset       int
+		line, column int
+	}{
+		{0, 1, 6},  // 1
+		{3, 1, 1},  // x :=
+		{6, 2, 6},  // x
+		{9, 2, 10}, // 2
+		{12, 2, 8}, // /
+		{13, 2, 1}, // y :=
+	}
+	for _, tt := range tests {
+		if line, column := b.PositionOf(tt.offset); line != tt.line || column != tt.column {
+			t.Errorf("偏移 %d 的位置为 %d:%d, 期望 %d:%d", tt.offset, line, column, tt.line, tt.column)
+		}
+	}
+}
+
+func TestDisassemble(t *testing.T) {
+	want := `== 常量 ==
+0: REAL 1.5
+1: INTEGER 2
+== 变量 ==
+0: x
+1: y
+== 指令 ==
+0000 OpConstant 0             ; 1:6
+0003 OpStore 0                ; 1:1
+0006 OpLoad 0                 ; 2:6
+0009 OpConstant 1             ; 2:10
+0012 OpMul                    ; 2:8
+0013 OpStore 1                ; 2:1
+`
+	if got := compile(t, "x := 1.5;\ny := x * 2").Disassemble(); got != want {
+		t.Errorf("反汇编结果\n%s\n期望\n%s", got, want)
+	}
+}
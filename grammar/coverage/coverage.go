@@ -0,0 +1,248 @@
+// Package coverage 统计一组源程序用到了Mini文法的哪些产生式, 以及触发了 parser 中的哪些报错分支
+package coverage
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	goparser "go/parser"
+	"go/printer"
+	"go/token"
+	"html/template"
+	"io/fs"
+	"mini-parser/parser"
+	"mini-parser/parsetree"
+	minitoken "mini-parser/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ProductionHit 产生式及它在具体语法树中出现的次数
+type ProductionHit struct {
+	Production parsetree.Production
+	Count      int
+}
+
+// ErrorSite parser 中的一处报错: 调用 addError 或 expectPeek 的位置
+type ErrorSite struct {
+	parser.Site
+	Func  string // 所在的函数
+	Call  string // 调用的源代码, 如 p.addError(msg.MissingSemicolon)
+	Count int
+}
+
+// Report 一组源程序的覆盖情况
+type Report struct {
+	Files       int
+	Parsed      int // 能按Mini文法得到具体语法树的文件数, 产生式只统计这些文件
+	Productions []ProductionHit
+	Errors      []ErrorSite
+}
+
+// ErrorSites 扫描 parser 包的源文件, 按位置列出全部报错分支。
+// fsys 的根目录为 parser 包的源代码目录, 通常是 parser.Sources()
+func ErrorSites(fsys fs.FS) ([]ErrorSite, error) {
+	fset := token.NewFileSet()
+	files, err := fs.Glob(fsys, "*.go")
+	if err != nil {
+		return nil, err
+	}
+
+	var sites []ErrorSite
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		text, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		f, err := goparser.ParseFile(fset, file, text, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				sel, ok := call.Fun.(*ast.SelectorExpr)
+				if !ok || sel.Sel.Name != "addError" && sel.Sel.Name != "expectPeek" {
+					return true
+				}
+				var text bytes.Buffer
+				printer.Fprint(&text, fset, call)
+				sites = append(sites, ErrorSite{
+					Site: parser.Site{File: filepath.Base(file), Line: fset.Position(call.Pos()).Line},
+					Func: fn.Name.Name,
+					Call: text.String(),
+				})
+				return true
+			})
+		}
+	}
+	if len(sites) == 0 {
+		return nil, fmt.Errorf("没有找到 addError 或 expectPeek 的调用")
+	}
+	return sites, nil
+}
+
+// Run 分析每个源程序: 用 parsetree 统计产生式, 用 parser.ParseProgram 统计报错分支。
+// fsys 为 parser 包的源代码, 见 ErrorSites。
+func Run(sources []string, fsys fs.FS) (*Report, error) {
+	sites, err := ErrorSites(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	hits := map[parser.Site]int{}
+	r := &Report{Files: len(sources)}
+	for _, src := range sources {
+		if tree, err := parsetree.Parse(src); err == nil {
+			r.Parsed++
+			for _, p := range tree.Steps {
+				counts[p.String()]++
+			}
+		}
+		p := parser.New(minitoken.New(src))
+		p.RecordErrors(hits)
+		p.ParseProgram()
+	}
+
+	for _, p := range parsetree.Grammar() {
+		r.Productions = append(r.Productions, ProductionHit{p, counts[p.String()]})
+	}
+	for _, s := range sites {
+		s.Count = hits[s.Site]
+		r.Errors = append(r.Errors, s)
+	}
+	sort.SliceStable(r.Errors, func(i, j int) bool {
+		a, b := r.Errors[i], r.Errors[j]
+		return a.File < b.File || a.File == b.File && a.Line < b.Line
+	})
+	return r, nil
+}
+
+// Load 读取文件, 目录中的 .mini 文件都会读入
+func Load(paths []string) ([]string, error) {
+	var sources []string
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || file != path && filepath.Ext(file) != ".mini" {
+				return nil
+			}
+			src, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			sources = append(sources, string(src))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sources, nil
+}
+
+func covered[T any](items []T, count func(T) int) int {
+	n := 0
+	for _, x := range items {
+		if count(x) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(n) * 100 / float64(total)
+}
+
+// Summary 覆盖率的统计数字
+func (r *Report) Summary() string {
+	prods := covered(r.Productions, func(p ProductionHit) int { return p.Count })
+	errs := covered(r.Errors, func(e ErrorSite) int { return e.Count })
+	return fmt.Sprintf("文件 %d 个, 能得到具体语法树的 %d 个\n产生式覆盖 %d/%d (%.1f%%)\n报错分支覆盖 %d/%d (%.1f%%)\n",
+		r.Files, r.Parsed,
+		prods, len(r.Productions), percent(prods, len(r.Productions)),
+		errs, len(r.Errors), percent(errs, len(r.Errors)))
+}
+
+// Text 统计数字以及没有覆盖的产生式和报错分支
+func (r *Report) Text() string {
+	var out strings.Builder
+	out.WriteString(r.Summary())
+	var prods, errs []string
+	for _, p := range r.Productions {
+		if p.Count == 0 {
+			prods = append(prods, "  "+p.Production.String())
+		}
+	}
+	for _, e := range r.Errors {
+		if e.Count == 0 {
+			errs = append(errs, fmt.Sprintf("  %s:%d %s: %s", e.File, e.Line, e.Func, e.Call))
+		}
+	}
+	if len(prods) > 0 {
+		out.WriteString("未覆盖的产生式:\n" + strings.Join(prods, "\n") + "\n")
+	}
+	if len(errs) > 0 {
+		out.WriteString("未覆盖的报错分支:\n" + strings.Join(errs, "\n") + "\n")
+	}
+	return out.String()
+}
+
+var page = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>Mini 语法分析覆盖率</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+td, th { border: 1px solid #ccc; padding: 2px 8px; text-align: left; }
+td.count { text-align: right; }
+tr.miss { background: #fdd; }
+code { font-family: monospace; }
+</style>
+</head>
+<body>
+<h1>Mini 语法分析覆盖率</h1>
+<pre>{{.Summary}}</pre>
+<h2>产生式</h2>
+<table>
+<tr><th>产生式</th><th>次数</th></tr>
+{{range .Productions}}<tr{{if eq .Count 0}} class="miss"{{end}}><td><code>{{.Production}}</code></td><td class="count">{{.Count}}</td></tr>
+{{end}}</table>
+<h2>报错分支</h2>
+<table>
+<tr><th>位置</th><th>函数</th><th>调用</th><th>次数</th></tr>
+{{range .Errors}}<tr{{if eq .Count 0}} class="miss"{{end}}><td>{{.File}}:{{.Line}}</td><td>{{.Func}}</td><td><code>{{.Call}}</code></td><td class="count">{{.Count}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// HTML 列出全部产生式和报错分支及其次数, 没有覆盖的行标为红色
+func (r *Report) HTML() (string, error) {
+	var out strings.Builder
+	if err := page.Execute(&out, r); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
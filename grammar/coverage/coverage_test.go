@@ -0,0 +1,99 @@
+package coverage
+
+import (
+	"mini-parser/parser"
+	"mini-parser/token"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// 编译进程序的源代码与源代码目录中的一致
+func TestErrorSites(t *testing.T) {
+	embedded, err := ErrorSites(parser.Sources())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ErrorSites(os.DirFS(filepath.Join("..", "parser")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(embedded) == 0 || len(embedded) != len(dir) {
+		t.Fatalf("编译进程序的报错分支 %d 处, 源代码目录中 %d 处", len(embedded), len(dir))
+	}
+	for i := range embedded {
+		if embedded[i] != dir[i] {
+			t.Errorf("第 %d 处报错分支不同: %+v 与 %+v", i, embedded[i], dir[i])
+		}
+	}
+}
+
+var programs = []string{
+	"x := ;",
+	"program p begin end.",
+	"if (x > 1 then y := 1",
+	"while (x) y := 1",
+	"begin x := 1",
+	"x := 1 y := 2",
+}
+
+// 记录的调用位置都是扫描出的报错分支
+func TestRecordErrors(t *testing.T) {
+	sites, err := ErrorSites(parser.Sources())
+	if err != nil {
+		t.Fatal(err)
+	}
+	known := map[parser.Site]bool{}
+	for _, s := range sites {
+		known[s.Site] = true
+	}
+
+	for _, src := range programs {
+		hits := map[parser.Site]int{}
+		p := parser.New(token.New(src))
+		p.RecordErrors(hits)
+		p.ParseProgram()
+		if len(hits) == 0 {
+			t.Errorf("%q: 没有记录到报错", src)
+		}
+		for site := range hits {
+			if !known[site] {
+				t.Errorf("%q: 记录的 %s:%d 不是 addError 或 expectPeek 的调用", src, site.File, site.Line)
+			}
+		}
+	}
+}
+
+// 每次 Run 单独计数, 同时运行的多次统计互不影响
+func TestRunIndependent(t *testing.T) {
+	first, err := Run(programs, parser.Sources())
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := 0
+	for _, e := range first.Errors {
+		total += e.Count
+	}
+	if total == 0 {
+		t.Fatal("没有统计到报错")
+	}
+
+	var wg sync.WaitGroup
+	reports := make([]*Report, 4)
+	for i := range reports {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reports[i], _ = Run(programs, parser.Sources())
+		}()
+	}
+	wg.Wait()
+	for _, r := range reports {
+		for i, e := range r.Errors {
+			if e.Count != first.Errors[i].Count {
+				t.Errorf("%s:%d 第一次统计 %d 次, 之后 %d 次", e.File, e.Line, first.Errors[i].Count, e.Count)
+			}
+		}
+	}
+}
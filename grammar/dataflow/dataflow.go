@@ -0,0 +1,105 @@
+package dataflow
+
+import "mini-parser/cfg"
+
+type Direction int
+
+const (
+	Forward Direction = iota
+	Backward
+)
+
+// Problem 描述一个在控制流图上求解的数据流问题, T为格中的值
+type Problem[T any] struct {
+	Direction Direction
+	// 前向问题中入口块的In, 后向问题中出口块的Out
+	Boundary T
+	// 其余块的初值
+	Top T
+	// Meet 合并两个值, 不得修改参数
+	Meet func(a, b T) T
+	// Transfer 前向问题中由In求Out, 后向问题中由Out求In, 不得修改参数
+	Transfer func(block *cfg.Block, value T) T
+	Equal    func(a, b T) bool
+}
+
+// Result 各基本块入口和出口处的数据流值, 以块ID为下标
+type Result[T any] struct {
+	In  []T
+	Out []T
+}
+
+// Solve 用工作表迭代算法求解数据流问题直到不动点
+func Solve[T any](g *cfg.Graph, p Problem[T]) *Result[T] {
+	n := len(g.Blocks)
+	res := &Result[T]{In: make([]T, n), Out: make([]T, n)}
+	for i := range g.Blocks {
+		res.In[i] = p.Top
+		res.Out[i] = p.Top
+	}
+
+	// 前向问题按块顺序, 后向问题按逆序初始化工作表, 以减少迭代次数
+	worklist := make([]*cfg.Block, 0, n)
+	queued := make([]bool, n)
+	for i := range g.Blocks {
+		block := g.Blocks[i]
+		if p.Direction == Backward {
+			block = g.Blocks[n-1-i]
+		}
+		worklist = append(worklist, block)
+		queued[block.ID] = true
+	}
+
+	for len(worklist) > 0 {
+		block := worklist[0]
+		worklist = worklist[1:]
+		queued[block.ID] = false
+
+		var changed bool
+		var next []*cfg.Block
+		if p.Direction == Forward {
+			in := p.Boundary
+			if block != g.Entry {
+				in = meetAll(p, block.Preds, res.Out)
+			}
+			res.In[block.ID] = in
+			out := p.Transfer(block, in)
+			changed = !p.Equal(out, res.Out[block.ID])
+			res.Out[block.ID] = out
+			next = block.Succs
+		} else {
+			out := p.Boundary
+			if block != g.Exit {
+				out = meetAll(p, block.Succs, res.In)
+			}
+			res.Out[block.ID] = out
+			in := p.Transfer(block, out)
+			changed = !p.Equal(in, res.In[block.ID])
+			res.In[block.ID] = in
+			next = block.Preds
+		}
+
+		if !changed {
+			continue
+		}
+		for _, b := range next {
+			if !queued[b.ID] {
+				worklist = append(worklist, b)
+				queued[b.ID] = true
+			}
+		}
+	}
+
+	return res
+}
+
+func meetAll[T any](p Problem[T], blocks []*cfg.Block, values []T) T {
+	if len(blocks) == 0 {
+		return p.Top
+	}
+	v := values[blocks[0].ID]
+	for _, b := range blocks[1:] {
+		v = p.Meet(v, values[b.ID])
+	}
+	return v
+}
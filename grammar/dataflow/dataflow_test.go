@@ -0,0 +1,78 @@
+package dataflow
+
+import (
+	"fmt"
+	"mini-parser/cfg"
+	"mini-parser/parser"
+	"mini-parser/token"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, src string) *parser.Program {
+	t.Helper()
+	p := parser.New(token.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("语法错误: %v", p.Errors())
+	}
+	return program
+}
+
+// format 把集合排序后写作 {a, b}
+func format[K comparable](s Set[K], name func(K) string) string {
+	var names []string
+	for k := range s {
+		names = append(names, name(k))
+	}
+	slices.Sort(names)
+	return "{" + strings.Join(names, ", ") + "}"
+}
+
+func blockID(b int) string {
+	return fmt.Sprintf("B%d", b)
+}
+
+// 循环程序的控制流图: B0 -> B1(循环头) -> B2(循环体) -> B1, B1 -> B3 -> B4(出口)
+const loop = "i := 0; while (i < 3) do i := i + 1; j := i"
+
+// 前向问题: 每个块入口处为能到达它的块; 后向问题: 每个块出口处为它能到达的块
+func TestSolve(t *testing.T) {
+	g := cfg.Build(parse(t, loop))
+	add := func(block *cfg.Block, value Set[int]) Set[int] {
+		out := value.Copy()
+		out[block.ID] = struct{}{}
+		return out
+	}
+
+	forward := Solve(g, Problem[Set[int]]{
+		Direction: Forward,
+		Boundary:  Set[int]{},
+		Top:       Set[int]{},
+		Meet:      Union[int],
+		Transfer:  add,
+		Equal:     Equal[int],
+	})
+	wantIn := []string{"{}", "{B0, B1, B2}", "{B0, B1, B2}", "{B0, B1, B2}", "{B0, B1, B2, B3}"}
+	for id, want := range wantIn {
+		if got := format(forward.In[id], blockID); got != want {
+			t.Errorf("前向问题 B%d 入口为 %s, 期望 %s", id, got, want)
+		}
+	}
+
+	backward := Solve(g, Problem[Set[int]]{
+		Direction: Backward,
+		Boundary:  Set[int]{},
+		Top:       Set[int]{},
+		Meet:      Union[int],
+		Transfer:  add,
+		Equal:     Equal[int],
+	})
+	wantOut := []string{"{B1, B2, B3, B4}", "{B1, B2, B3, B4}", "{B1, B2, B3, B4}", "{B4}", "{}"}
+	for id, want := range wantOut {
+		if got := format(backward.Out[id], blockID); got != want {
+			t.Errorf("后向问题 B%d 出口为 %s, 期望 %s", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package dataflow
+
+import (
+	"mini-parser/cfg"
+	"mini-parser/parser"
+)
+
+// Liveness 活跃变量分析: 计算各基本块入口和出口处的活跃变量, 出口处没有活跃变量
+func Liveness(g *cfg.Graph) *Result[Set[string]] {
+	return LivenessAt(g, Set[string]{})
+}
+
+// LivenessAt 与 Liveness 相同, exit 为出口处活跃的变量, 如程序结束时仍要读取的结果
+func LivenessAt(g *cfg.Graph, exit Set[string]) *Result[Set[string]] {
+	return Solve(g, Problem[Set[string]]{
+		Direction: Backward,
+		Boundary:  exit,
+		Top:       Set[string]{},
+		Meet:      Union[string],
+		Transfer: func(block *cfg.Block, out Set[string]) Set[string] {
+			live := out.Copy()
+			if block.Cond != nil {
+				for _, ident := range uses(block.Cond) {
+					live[ident.Value] = struct{}{}
+				}
+			}
+			for i := len(block.Stmts) - 1; i >= 0; i-- {
+				switch s := block.Stmts[i].(type) {
+				case *parser.AssignStatement:
+					delete(live, s.Name.Value)
+					for _, ident := range uses(s.Value) {
+						live[ident.Value] = struct{}{}
+					}
+				case *parser.CallStatement:
+					for _, ident := range uses(s.Call) {
+						live[ident.Value] = struct{}{}
+					}
+				case *parser.ReturnStatement:
+					if s.Value != nil {
+						for _, ident := range uses(s.Value) {
+							live[ident.Value] = struct{}{}
+						}
+					}
+				}
+			}
+			return live
+		},
+		Equal: Equal[string],
+	})
+}
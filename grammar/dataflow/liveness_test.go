@@ -0,0 +1,38 @@
+package dataflow
+
+import (
+	"mini-parser/cfg"
+	"testing"
+)
+
+func TestLiveness(t *testing.T) {
+	g := cfg.Build(parse(t, "a := 1; b := 2; while (a < 10) do a := a + b; c := a"))
+	name := func(s string) string { return s }
+
+	live := Liveness(g)
+	want := []string{"{}", "{a, b}", "{a, b}", "{a}", "{}"}
+	for id, w := range want {
+		if got := format(live.In[id], name); got != w {
+			t.Errorf("B%d 入口活跃 %s, 期望 %s", id, got, w)
+		}
+	}
+
+	// 出口处活跃的变量在整个程序中都不能被认为是死的
+	live = LivenessAt(g, Set[string]{"c": {}, "b": {}})
+	if got := format(live.Out[3], name); got != "{b, c}" {
+		t.Errorf("B3 出口活跃 %s, 期望 {b, c}", got)
+	}
+	if got := format(live.In[0], name); got != "{}" {
+		t.Errorf("入口活跃 %s, 期望 {}", got)
+	}
+}
+
+// 函数中 return 的值是对变量的使用
+func TestLivenessReturn(t *testing.T) {
+	program := parse(t, "program p; function f(a); var t; begin t := a * 2; return t end; begin end.")
+	g := cfg.BuildProcedure(program.Procedures[0])
+	live := Liveness(g)
+	if got := format(live.In[g.Entry.ID], func(s string) string { return s }); got != "{a}" {
+		t.Errorf("函数入口活跃 %s, 期望 {a}", got)
+	}
+}
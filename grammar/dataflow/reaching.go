@@ -0,0 +1,197 @@
+package dataflow
+
+import (
+	"mini-parser/cfg"
+	"mini-parser/parser"
+	"sort"
+)
+
+// Definition 对变量的一次定值: 赋值语句, 或者过程调用对全局变量的赋值。
+// Stmt 和 Call 都为nil时表示入口处的未初始化伪定值
+type Definition struct {
+	Name string
+	Stmt *parser.AssignStatement
+	Call *parser.CallExpression
+}
+
+// Uninitialized 是否为入口处的未初始化伪定值
+func (d *Definition) Uninitialized() bool {
+	return d.Stmt == nil && d.Call == nil
+}
+
+// Effects 调用每个过程时可能被赋值的全局变量, 包括它直接或间接调用的过程所赋值的
+type Effects map[string]Set[string]
+
+// GlobalEffects 收集每个过程赋值的、不是它的形参或局部变量的名字, 并沿调用关系传递
+func GlobalEffects(program *parser.Program) Effects {
+	effects := Effects{}
+	calls := map[string][]string{}
+	for _, proc := range program.Procedures {
+		locals := procedureLocals(proc)
+		writes := Set[string]{}
+		parser.Inspect(proc.Body, func(n parser.Node) bool {
+			switch n := n.(type) {
+			case *parser.AssignStatement:
+				if !locals.Has(n.Name.Value) {
+					writes[n.Name.Value] = struct{}{}
+				}
+			case *parser.CallExpression:
+				calls[proc.Name.Value] = append(calls[proc.Name.Value], n.Function.Value)
+			}
+			return true
+		})
+		effects[proc.Name.Value] = writes
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for caller, callees := range calls {
+			for _, callee := range callees {
+				for name := range effects[callee] {
+					if !effects[caller].Has(name) {
+						effects[caller][name] = struct{}{}
+						changed = true
+					}
+				}
+			}
+		}
+	}
+	return effects
+}
+
+// procedureLocals 过程的形参和局部变量
+func procedureLocals(proc *parser.ProcedureDeclaration) Set[string] {
+	locals := Set[string]{}
+	for _, param := range proc.Parameters {
+		locals[param.Value] = struct{}{}
+	}
+	for _, decl := range proc.Locals {
+		for _, name := range decl.Names {
+			locals[name.Value] = struct{}{}
+		}
+	}
+	return locals
+}
+
+// Env 到达定值分析的环境
+type Env struct {
+	// Uninitialized 入口处未赋值的变量, nil 表示控制流图中出现的全部变量
+	Uninitialized Set[string]
+	// Effects 调用处视为对被调过程所赋值的变量的定值
+	Effects Effects
+}
+
+// ReachingDefinitions 到达定值分析的结果
+type ReachingDefinitions struct {
+	*Result[Set[*Definition]]
+	Defs   []*Definition
+	byName map[string][]*Definition
+	byStmt map[*parser.AssignStatement]*Definition
+	byCall map[*parser.CallExpression][]*Definition
+}
+
+// Reaching 计算到达各基本块的定值集合, 入口处每个未赋值的变量带有一个未初始化伪定值。
+// env 为nil时全部变量在入口处未赋值, 过程调用不定值任何变量
+func Reaching(g *cfg.Graph, env *Env) *ReachingDefinitions {
+	if env == nil {
+		env = &Env{}
+	}
+	rd := &ReachingDefinitions{
+		byName: map[string][]*Definition{},
+		byStmt: map[*parser.AssignStatement]*Definition{},
+		byCall: map[*parser.CallExpression][]*Definition{},
+	}
+
+	entry := Set[*Definition]{}
+	for _, name := range variables(g) {
+		if env.Uninitialized == nil || env.Uninitialized.Has(name) {
+			def := rd.addDef(&Definition{Name: name})
+			entry[def] = struct{}{}
+		}
+	}
+	addCalls := func(node parser.Node) {
+		parser.Inspect(node, func(n parser.Node) bool {
+			if call, ok := n.(*parser.CallExpression); ok {
+				names := make([]string, 0, len(env.Effects[call.Function.Value]))
+				for name := range env.Effects[call.Function.Value] {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					rd.byCall[call] = append(rd.byCall[call], rd.addDef(&Definition{Name: name, Call: call}))
+				}
+			}
+			return true
+		})
+	}
+	for _, block := range g.Blocks {
+		for _, s := range block.Stmts {
+			addCalls(s)
+			if assign, ok := s.(*parser.AssignStatement); ok {
+				rd.byStmt[assign] = rd.addDef(&Definition{Name: assign.Name.Value, Stmt: assign})
+			}
+		}
+		if block.Cond != nil {
+			addCalls(block.Cond)
+		}
+	}
+
+	rd.Result = Solve(g, Problem[Set[*Definition]]{
+		Direction: Forward,
+		Boundary:  entry,
+		Top:       Set[*Definition]{},
+		Meet:      Union[*Definition],
+		Transfer: func(block *cfg.Block, in Set[*Definition]) Set[*Definition] {
+			out := in
+			for _, s := range block.Stmts {
+				out = rd.Step(out, s)
+			}
+			if block.Cond != nil {
+				out = rd.Calls(out, block.Cond)
+			}
+			return out
+		},
+		Equal: Equal[*Definition],
+	})
+	return rd
+}
+
+func (rd *ReachingDefinitions) addDef(def *Definition) *Definition {
+	rd.Defs = append(rd.Defs, def)
+	rd.byName[def.Name] = append(rd.byName[def.Name], def)
+	return def
+}
+
+// Step 返回执行语句s之后到达的定值集合, 语句中的过程调用先于赋值
+func (rd *ReachingDefinitions) Step(in Set[*Definition], s parser.Statement) Set[*Definition] {
+	out := rd.Calls(in, s)
+	assign, ok := s.(*parser.AssignStatement)
+	if !ok {
+		return out
+	}
+	return rd.define(out, rd.byStmt[assign])
+}
+
+// Calls 返回执行节点中的过程调用之后到达的定值集合
+func (rd *ReachingDefinitions) Calls(in Set[*Definition], node parser.Node) Set[*Definition] {
+	out := in
+	parser.Inspect(node, func(n parser.Node) bool {
+		if call, ok := n.(*parser.CallExpression); ok {
+			for _, def := range rd.byCall[call] {
+				out = rd.define(out, def)
+			}
+		}
+		return true
+	})
+	return out
+}
+
+// define 定值def注销同一变量的其他定值
+func (rd *ReachingDefinitions) define(in Set[*Definition], def *Definition) Set[*Definition] {
+	out := in.Copy()
+	for _, other := range rd.byName[def.Name] {
+		delete(out, other)
+	}
+	out[def] = struct{}{}
+	return out
+}
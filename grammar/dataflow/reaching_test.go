@@ -0,0 +1,63 @@
+package dataflow
+
+import (
+	"fmt"
+	"mini-parser/cfg"
+	"testing"
+)
+
+// def 把定值写作 x@行:列(赋值语句)、x@f()(过程调用)或 x?(未初始化)
+func def(d *Definition) string {
+	switch {
+	case d.Stmt != nil:
+		return fmt.Sprintf("%s@%d:%d", d.Name, d.Stmt.Token.Line, d.Stmt.Token.Column)
+	case d.Call != nil:
+		return d.Name + "@" + d.Call.Function.Value + "()"
+	}
+	return d.Name + "?"
+}
+
+func TestReaching(t *testing.T) {
+	// B0: x := 1; if c, B1: x := 2, B2: y := x, B3: 出口
+	g := cfg.Build(parse(t, "x := 1;\nif (c) then x := 2;\ny := x"))
+	rd := Reaching(g, nil)
+	tests := []struct {
+		block int
+		in    string
+	}{
+		{0, "{c?, x?, y?}"},
+		{1, "{c?, x@1:1, y?}"},
+		{2, "{c?, x@1:1, x@2:13, y?}"},
+		{3, "{c?, x@1:1, x@2:13, y@3:1}"},
+	}
+	for _, tt := range tests {
+		if got := format(rd.In[tt.block], def); got != tt.in {
+			t.Errorf("到达 B%d 的定值为 %s, 期望 %s", tt.block, got, tt.in)
+		}
+	}
+}
+
+// 过程调用注销被调过程赋值的变量的其他定值
+func TestReachingCalls(t *testing.T) {
+	program := parse(t, `program p;
+var g, h: integer;
+procedure init; begin g := 5 end;
+procedure outer; begin init() end;
+begin outer(); h := g end.`)
+	effects := GlobalEffects(program)
+	if got := format(effects["outer"], func(s string) string { return s }); got != "{g}" {
+		t.Fatalf("outer 赋值的全局变量为 %s, 期望 {g}", got)
+	}
+
+	g := cfg.Build(program)
+	rd := Reaching(g, &Env{Effects: effects})
+	if got := format(rd.Out[g.Entry.ID], def); got != "{g@outer(), h@5:16}" {
+		t.Errorf("入口块出口处的定值为 %s", got)
+	}
+
+	// 没有 Effects 时调用不定值任何变量
+	rd = Reaching(g, nil)
+	if got := format(rd.Out[g.Entry.ID], def); got != "{g?, h@5:16}" {
+		t.Errorf("入口块出口处的定值为 %s", got)
+	}
+}
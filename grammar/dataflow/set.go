@@ -0,0 +1,37 @@
+package dataflow
+
+// Set 数据流分析使用的集合, 运算结果总是新集合
+type Set[K comparable] map[K]struct{}
+
+func (s Set[K]) Has(k K) bool {
+	_, ok := s[k]
+	return ok
+}
+
+func (s Set[K]) Copy() Set[K] {
+	out := make(Set[K], len(s))
+	for k := range s {
+		out[k] = struct{}{}
+	}
+	return out
+}
+
+func Union[K comparable](a, b Set[K]) Set[K] {
+	out := a.Copy()
+	for k := range b {
+		out[k] = struct{}{}
+	}
+	return out
+}
+
+func Equal[K comparable](a, b Set[K]) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k := range a {
+		if !b.Has(k) {
+			return false
+		}
+	}
+	return true
+}
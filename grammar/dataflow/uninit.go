@@ -0,0 +1,97 @@
+package dataflow
+
+import (
+	"mini-parser/cfg"
+	"mini-parser/msg"
+	"mini-parser/parser"
+	"sort"
+	"unicode/utf8"
+)
+
+type Warning struct {
+	Line    int
+	Column  int
+	Length  int
+	Message string
+}
+
+func (w Warning) String() string {
+	return msg.Get(msg.Location, w.Line, w.Column, w.Message)
+}
+
+// CheckUninitialized 报告主程序和各过程中每一处可能在赋值前被使用的变量引用。
+// 过程调用视为对被调过程所赋值的全局变量的定值; 过程中形参和全局变量视为已赋值, 只检查局部变量
+func CheckUninitialized(program *parser.Program) []Warning {
+	effects := GlobalEffects(program)
+	warnings := checkGraph(cfg.Build(program), &Env{Effects: effects})
+	for _, proc := range program.Procedures {
+		locals := procedureLocals(proc)
+		uninit := Set[string]{}
+		for _, decl := range proc.Locals {
+			for _, name := range decl.Names {
+				uninit[name.Value] = struct{}{}
+			}
+		}
+		// 被调过程赋值的是全局变量, 不是本过程中的同名形参或局部变量
+		visible := Effects{}
+		for callee, names := range effects {
+			visible[callee] = Set[string]{}
+			for name := range names {
+				if !locals.Has(name) {
+					visible[callee][name] = struct{}{}
+				}
+			}
+		}
+		warnings = append(warnings, checkGraph(cfg.BuildProcedure(proc), &Env{Uninitialized: uninit, Effects: visible})...)
+	}
+
+	sort.Slice(warnings, func(i, j int) bool {
+		if warnings[i].Line != warnings[j].Line {
+			return warnings[i].Line < warnings[j].Line
+		}
+		return warnings[i].Column < warnings[j].Column
+	})
+	return warnings
+}
+
+func checkGraph(g *cfg.Graph, env *Env) []Warning {
+	rd := Reaching(g, env)
+
+	var warnings []Warning
+	check := func(reaching Set[*Definition], expr parser.Expression) {
+		for _, ident := range uses(expr) {
+			for def := range reaching {
+				if def.Uninitialized() && def.Name == ident.Value {
+					warnings = append(warnings, Warning{
+						Line:    ident.Token.Line,
+						Column:  ident.Token.Column,
+						Length:  utf8.RuneCountInString(ident.Token.Literal),
+						Message: msg.Get(msg.UsedBeforeAssigned, ident.Value),
+					})
+					break
+				}
+			}
+		}
+	}
+
+	for _, block := range g.Blocks {
+		reaching := rd.In[block.ID]
+		for _, s := range block.Stmts {
+			switch s := s.(type) {
+			case *parser.AssignStatement:
+				check(reaching, s.Value)
+			case *parser.CallStatement:
+				check(reaching, s.Call)
+			case *parser.ReturnStatement:
+				if s.Value != nil {
+					check(reaching, s.Value)
+				}
+			}
+			reaching = rd.Step(reaching, s)
+		}
+		if block.Cond != nil {
+			check(reaching, block.Cond)
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,64 @@
+package dataflow
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCheckUninitialized(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string // 行:列 变量
+	}{
+		{"assigned", "x := 1; y := x", nil},
+		{"one branch", "if (c) then x := 1;\ny := x", []string{"1:5 c", "2:6 x"}},
+		{"both branches", "c := true; if (c) then x := 1 else x := 2; y := x", nil},
+		{"loop", "i := 0; while (i < 3) do begin s := s + i; i := i + 1 end", []string{"1:37 s"}},
+		// 过程调用对全局变量赋值
+		{"call", `program p;
+var g, h: integer;
+procedure init; begin g := 5 end;
+begin init(); h := g end.`, nil},
+		// 过程中只检查局部变量, 形参和全局变量视为已赋值
+		{"procedure", `program p;
+var r: integer;
+function f(a);
+var t, u;
+begin
+    if (a > 0) then return a + r;
+    u := t + a;
+    return u
+end;
+begin r := f(1) end.`, []string{"7:10 t"}},
+		// return 跳到出口, 之后的语句不可达
+		{"return", `program p;
+function f(a);
+var t;
+begin
+    if (a > 0) then t := 1 else return 0;
+    return t
+end;
+begin end.`, nil},
+		// 局部变量遮蔽了被调过程赋值的全局变量
+		{"shadow", `program p;
+var g: integer;
+procedure init; begin g := 5 end;
+procedure q;
+var g;
+begin init(); g := g + 1 end;
+begin end.`, []string{"6:20 g"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, w := range CheckUninitialized(parse(t, tt.src)) {
+				got = append(got, fmt.Sprintf("%d:%d %s", w.Line, w.Column, strings.Fields(w.Message)[1]))
+			}
+			if strings.Join(got, "; ") != strings.Join(tt.want, "; ") {
+				t.Errorf("警告 %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
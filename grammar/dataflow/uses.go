@@ -0,0 +1,52 @@
+package dataflow
+
+import (
+	"mini-parser/cfg"
+	"mini-parser/parser"
+)
+
+// uses 按出现顺序返回表达式中引用的变量
+func uses(expr parser.Expression) []*parser.Identifier {
+	var idents []*parser.Identifier
+	inspectVariables(expr, func(ident *parser.Identifier) {
+		idents = append(idents, ident)
+	})
+	return idents
+}
+
+// inspectVariables 按出现顺序访问节点中作为变量引用的标识符, 被调用的过程名不是变量
+func inspectVariables(node parser.Node, f func(*parser.Identifier)) {
+	parser.Inspect(node, func(n parser.Node) bool {
+		switch n := n.(type) {
+		case *parser.CallExpression:
+			for _, arg := range n.Arguments {
+				inspectVariables(arg, f)
+			}
+			return false
+		case *parser.Identifier:
+			f(n)
+		}
+		return true
+	})
+}
+
+// variables 按出现顺序收集控制流图中的全部变量名
+func variables(g *cfg.Graph) []string {
+	var names []string
+	seen := map[string]bool{}
+	visit := func(ident *parser.Identifier) {
+		if !seen[ident.Value] {
+			seen[ident.Value] = true
+			names = append(names, ident.Value)
+		}
+	}
+	for _, block := range g.Blocks {
+		for _, s := range block.Stmts {
+			inspectVariables(s, visit)
+		}
+		if block.Cond != nil {
+			inspectVariables(block.Cond, visit)
+		}
+	}
+	return names
+}
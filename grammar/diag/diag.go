@@ -0,0 +1,177 @@
+package diag
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+)
+
+func (s Severity) String() string {
+	if s == Warning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Diagnostic 一条带位置的诊断信息, 行列号从1开始, 列号按字符计
+type Diagnostic struct {
+	Severity Severity
+	Line     int
+	Column   int
+	Length   int // 下划线覆盖的字符数, 不足1时按1处理
+	Message  string
+	Notes    []string
+}
+
+// Renderer 以源程序片段加下划线的形式输出诊断
+type Renderer struct {
+	Filename string
+	Color    bool
+	lines    []string
+}
+
+func NewRenderer(filename, source string, color bool) *Renderer {
+	return &Renderer{
+		Filename: filename,
+		Color:    color,
+		lines:    strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n"),
+	}
+}
+
+// IsTerminal 判断文件是否为终端, 用于决定是否输出颜色
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+const (
+	bold   = "\033[1m"
+	red    = "\033[31m"
+	yellow = "\033[33m"
+	blue   = "\033[34m"
+	reset  = "\033[0m"
+)
+
+func (r *Renderer) paint(color, text string) string {
+	if !r.Color {
+		return text
+	}
+	return bold + color + text + reset
+}
+
+// Render 输出形如
+//
+//	error: if语句缺少then关键字
+//	  --> test_error.mini:8:14
+//	   |
+//	 8 |     if (x > y)
+//	   |              ^
+//	   = note: if语句的形式为 if (条件) then 语句
+func (r *Renderer) Render(d Diagnostic) string {
+	var out strings.Builder
+
+	color := red
+	if d.Severity == Warning {
+		color = yellow
+	}
+	out.WriteString(r.paint(color, d.Severity.String()))
+	if r.Color {
+		out.WriteString(bold + ": " + d.Message + reset + "\n")
+	} else {
+		out.WriteString(": " + d.Message + "\n")
+	}
+
+	gutter := strings.Repeat(" ", len(fmt.Sprint(d.Line)))
+	fmt.Fprintf(&out, "%s%s %s:%d:%d\n", gutter, r.paint(blue, "-->"), r.Filename, d.Line, d.Column)
+
+	if d.Line >= 1 && d.Line <= len(r.lines) {
+		line := strings.TrimRight(r.lines[d.Line-1], " \t\r")
+		bar := r.paint(blue, "|")
+		fmt.Fprintf(&out, "%s %s\n", gutter, bar)
+		fmt.Fprintf(&out, "%s %s %s\n", r.paint(blue, fmt.Sprint(d.Line)), bar, line)
+		fmt.Fprintf(&out, "%s %s %s\n", gutter, bar, r.paint(color, underline(line, d.Column, d.Length)))
+	}
+
+	for _, note := range d.Notes {
+		fmt.Fprintf(&out, "%s %s note: %s\n", gutter, r.paint(blue, "="), note)
+	}
+
+	return out.String()
+}
+
+// underline 生成对齐到源程序第column列的 ^~~~ 下划线, 制表符原样保留, 宽字符占两列
+func underline(line string, column, length int) string {
+	var pad strings.Builder
+	col := 1
+	for _, ch := range line {
+		if col >= column {
+			break
+		}
+		switch {
+		case ch == '\t':
+			pad.WriteByte('\t')
+		case isWide(ch):
+			pad.WriteString("  ")
+		default:
+			pad.WriteByte(' ')
+		}
+		col++
+	}
+	// 位置在行尾之后(如缺少分号)时继续补空格
+	for ; col < column; col++ {
+		pad.WriteByte(' ')
+	}
+
+	width := 0
+	rest := line
+	for i := 1; i < column && rest != ""; i++ {
+		_, size := utf8.DecodeRuneInString(rest)
+		rest = rest[size:]
+	}
+	for i := 0; i < length && rest != ""; i++ {
+		ch, size := utf8.DecodeRuneInString(rest)
+		rest = rest[size:]
+		if isWide(ch) {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	if width < 1 {
+		width = 1
+	}
+
+	return pad.String() + "^" + strings.Repeat("~", width-1)
+}
+
+// Width 返回字符串在终端中占的列数, 宽字符占两列
+func Width(s string) int {
+	width := 0
+	for _, ch := range s {
+		if isWide(ch) {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+func isWide(ch rune) bool {
+	return unicode.Is(unicode.Han, ch) || unicode.Is(unicode.Hangul, ch) ||
+		unicode.Is(unicode.Hiragana, ch) || unicode.Is(unicode.Katakana, ch) ||
+		ch >= 0xFF00 && ch <= 0xFF60 || ch >= 0x3000 && ch <= 0x303F ||
+		ch >= 0x1F300 && ch <= 0x1F64F || ch >= 0x1F900 && ch <= 0x1F9FF // emoji
+}
@@ -0,0 +1,97 @@
+package diag
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	source := "program p;\nbegin\n    if (x > y)\n    x := 1\nend."
+	r := NewRenderer("test.mini", source, false)
+	got := r.Render(Diagnostic{
+		Line:    3,
+		Column:  15,
+		Message: "if语句缺少then关键字",
+		Notes:   []string{"if语句的形式为 if (条件) then 语句"},
+	})
+	want := `error: if语句缺少then关键字
+ --> test.mini:3:15
+  |
+3 |     if (x > y)
+  |               ^
+  = note: if语句的形式为 if (条件) then 语句
+`
+	if got != want {
+		t.Errorf("输出\n%s\n期望\n%s", got, want)
+	}
+
+	// 行号有两位时行号栏随之加宽; 超出源程序的行只输出位置
+	got = r.Render(Diagnostic{Severity: Warning, Line: 12, Column: 1, Message: "文件意外结束"})
+	want = `warning: 文件意外结束
+  --> test.mini:12:1
+`
+	if got != want {
+		t.Errorf("输出\n%s\n期望\n%s", got, want)
+	}
+}
+
+func TestRenderColor(t *testing.T) {
+	r := NewRenderer("a.mini", "x := 1", true)
+	got := r.Render(Diagnostic{Severity: Warning, Line: 1, Column: 1, Length: 1, Message: "m"})
+	want := bold + yellow + "warning" + reset + bold + ": m" + reset + "\n" +
+		" " + bold + blue + "-->" + reset + " a.mini:1:1\n" +
+		"  " + bold + blue + "|" + reset + "\n" +
+		bold + blue + "1" + reset + " " + bold + blue + "|" + reset + " x := 1\n" +
+		"  " + bold + blue + "|" + reset + " " + bold + yellow + "^" + reset + "\n"
+	if got != want {
+		t.Errorf("输出 %q\n期望 %q", got, want)
+	}
+}
+
+// 下划线按终端中的显示宽度对齐: 宽字符占两列, 制表符原样保留
+func TestUnderline(t *testing.T) {
+	tests := []struct {
+		name           string
+		line           string
+		column, length int
+		want           string
+	}{
+		{"ascii", "x := y + 1", 6, 1, "     ^"},
+		{"length", "x := abc + 1", 6, 3, "     ^~~"},
+		{"zero length", "x := 1", 3, 0, "  ^"},
+		{"tab", "\tx := 1", 2, 1, "\t^"},
+		{"han before", "s := '中文' + 1", 11, 1, "            ^"},
+		{"han underlined", "变量 := 1", 1, 2, "^~~~"},
+		{"kana", "かな := 1", 4, 2, "     ^~"},
+		{"fullwidth", "x：= 1", 3, 1, "   ^"},
+		{"emoji", "s := '🙂' + 1", 9, 1, "         ^"},
+		{"emoji underlined", "🙂🙂 x", 1, 2, "^~~~"},
+		// 缺少分号等位置在行尾之后时继续补空格
+		{"past end", "x := 1", 8, 1, "       ^"},
+		{"past end wide", "中 := 1", 8, 1, "        ^"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := underline(tt.line, tt.column, tt.length); got != tt.want {
+				t.Errorf("underline(%q, %d, %d) = %q, 期望 %q", tt.line, tt.column, tt.length, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"栈", 2},
+		{"<算术表达式>", 12},
+		{"한글", 4},
+		{"（）", 4},
+		{"🙂", 2},
+	}
+	for _, tt := range tests {
+		if got := Width(tt.s); got != tt.want {
+			t.Errorf("Width(%q) = %d, 期望 %d", tt.s, got, tt.want)
+		}
+	}
+}
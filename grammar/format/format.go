@@ -0,0 +1,303 @@
+package format
+
+import (
+	"math"
+	"mini-parser/parser"
+	"mini-parser/token"
+	"strings"
+)
+
+// Options 控制输出格式
+type Options struct {
+	Indent string // 每一级缩进使用的字符串
+}
+
+// DefaultOptions 四个空格缩进
+var DefaultOptions = Options{Indent: "    "}
+
+// Source 分析源程序并输出规范格式, 有语法错误时返回 parser.ParserErrors
+func Source(src string, opts Options) (string, error) {
+	tokenizer := token.New(src)
+	p := parser.New(tokenizer)
+	program := p.ParseProgram()
+	if errs := p.ErrorList(); len(errs) > 0 {
+		return "", errs
+	}
+	return Program(program, tokenizer.Comments(), opts), nil
+}
+
+// Program 按规范格式输出程序, comments 按源程序中的位置插回输出
+func Program(program *parser.Program, comments []token.Comment, opts Options) string {
+	p := &printer{opts: opts, comments: comments, fresh: true}
+
+	if program.Name != nil {
+		p.beginLine(program.Token.Line)
+		p.token(program.Token, "program")
+		p.write(" ")
+		p.token(program.Name.Token, program.Name.Value)
+		p.write(";")
+		if len(program.Consts) > 0 {
+			p.section("const", program.Consts[0].Token.Line)
+			for _, c := range program.Consts {
+				p.beginLine(c.Token.Line)
+				p.token(c.Name.Token, c.Name.Value)
+				p.write(" = ")
+				p.expression(c.Value, parser.LOWEST)
+				p.write(";")
+			}
+			p.depth--
+		}
+		p.vars(program.Vars)
+		for _, proc := range program.Procedures {
+			p.procedure(proc)
+		}
+		// begin之前的注释(如程序头之后的说明)留在begin之前
+		p.beginLine(program.Begin.Line)
+		p.token(program.Begin, "begin")
+		p.block(program.Statements, program.End)
+		p.write(".")
+	} else {
+		p.statements(program.Statements)
+	}
+
+	p.flush(math.MaxInt)
+	if p.open {
+		p.buf.WriteByte('\n')
+	}
+	return p.buf.String()
+}
+
+type printer struct {
+	opts     Options
+	buf      strings.Builder
+	comments []token.Comment
+	next     int  // 下一条未输出的注释
+	depth    int  // 当前缩进层数
+	line     int  // 最近输出的记号在源程序中的行号
+	open     bool // 当前输出行还没有换行
+	fresh    bool // 位于块的开头, 不输出空行
+}
+
+func (p *printer) write(s string) {
+	p.buf.WriteString(s)
+}
+
+// token 输出一个来自源程序的记号并记录它的行号
+func (p *printer) token(tok token.Token, text string) {
+	p.write(text)
+	if tok.Line > p.line {
+		p.line = tok.Line
+	}
+}
+
+func (p *printer) newline() {
+	p.buf.WriteByte('\n')
+	p.open = false
+}
+
+// startLine 换行并缩进, 源程序中隔有空行时保留一个空行
+func (p *printer) startLine(line int) {
+	if p.open {
+		p.newline()
+	}
+	if !p.fresh && line > p.line+1 {
+		p.newline()
+	}
+	p.write(strings.Repeat(p.opts.Indent, p.depth))
+	p.open = true
+	p.fresh = false
+}
+
+// beginLine 输出源程序第line行之前的注释, 然后另起一行
+func (p *printer) beginLine(line int) {
+	p.flush(line)
+	p.startLine(line)
+}
+
+// flush 输出行号小于line的注释: 与上一个记号同行的放在行尾, 其余单独成行
+func (p *printer) flush(line int) {
+	for ; p.next < len(p.comments) && p.comments[p.next].Line < line; p.next++ {
+		c := p.comments[p.next]
+		if p.open && c.Line == p.line {
+			p.write(" ")
+		} else {
+			p.startLine(c.Line)
+		}
+		p.write(c.Text)
+		p.line = c.Line
+	}
+}
+
+// procedure 输出过程声明, 没有形参时省略括号
+func (p *printer) procedure(proc *parser.ProcedureDeclaration) {
+	p.beginLine(proc.Token.Line)
+	p.token(proc.Token, proc.Token.Literal)
+	p.write(" ")
+	p.token(proc.Name.Token, proc.Name.Value)
+	if len(proc.Parameters) > 0 {
+		p.write("(")
+		p.identifiers(proc.Parameters)
+		p.write(")")
+	}
+	p.write(";")
+	p.vars(proc.Locals)
+	p.beginLine(proc.Body.Token.Line)
+	p.token(proc.Body.Token, "begin")
+	p.block(proc.Body.Statements, proc.Body.End)
+	p.write(";")
+}
+
+// section 另起一行输出 const 或 var, 其后的定义各占一行并缩进一级, 调用方负责恢复缩进。
+// 第一个定义之前的注释放在关键字之前
+func (p *printer) section(keyword string, line int) {
+	p.flush(line)
+	p.startLine(p.line)
+	p.write(keyword)
+	p.depth++
+	p.fresh = true
+}
+
+func (p *printer) vars(decls []*parser.VarDeclaration) {
+	if len(decls) == 0 {
+		return
+	}
+	p.section("var", decls[0].Token.Line)
+	for _, d := range decls {
+		p.beginLine(d.Token.Line)
+		p.identifiers(d.Names)
+		if d.Type != nil {
+			p.write(": ")
+			p.token(d.Type.Token, d.Type.Value)
+		}
+		p.write(";")
+	}
+	p.depth--
+}
+
+func (p *printer) identifiers(idents []*parser.Identifier) {
+	for i, ident := range idents {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.token(ident.Token, ident.Value)
+	}
+}
+
+// block 输出begin之后的语句序列和对应的end, 调用前已输出begin
+func (p *printer) block(stmts []parser.Statement, end token.Token) {
+	p.depth++
+	p.fresh = true
+	p.statements(stmts)
+	p.flush(end.Line)
+	p.depth--
+	p.fresh = true
+	p.startLine(end.Line)
+	p.token(end, "end")
+}
+
+func (p *printer) statements(stmts []parser.Statement) {
+	for i, stmt := range stmts {
+		p.statement(stmt)
+		if i < len(stmts)-1 {
+			p.write(";")
+		}
+	}
+}
+
+func (p *printer) statement(stmt parser.Statement) {
+	switch s := stmt.(type) {
+	case *parser.AssignStatement:
+		p.beginLine(s.Token.Line)
+		p.token(s.Name.Token, s.Name.Value)
+		p.write(" := ")
+		p.expression(s.Value, parser.LOWEST)
+	case *parser.IfExpression:
+		p.beginLine(s.Token.Line)
+		p.token(s.Token, "if")
+		p.write(" (")
+		p.expression(s.Condition, parser.LOWEST)
+		p.write(") then")
+		p.body(s.Consequence)
+		if s.Alternative != nil {
+			// then部分最后一条语句的行尾注释留在该语句之后
+			p.flush(s.Else.Line)
+			p.startLine(p.line)
+			p.token(s.Else, "else")
+			p.body(s.Alternative)
+		}
+	case *parser.WhileExpression:
+		p.beginLine(s.Token.Line)
+		p.token(s.Token, "while")
+		p.write(" (")
+		p.expression(s.Condition, parser.LOWEST)
+		p.write(") do")
+		p.body(s.Body)
+	case *parser.CallStatement:
+		p.beginLine(s.Token.Line)
+		p.expression(s.Call, parser.LOWEST)
+	case *parser.ReturnStatement:
+		p.beginLine(s.Token.Line)
+		p.token(s.Token, "return")
+		if s.Value != nil {
+			p.write(" ")
+			p.expression(s.Value, parser.LOWEST)
+		}
+	case *parser.BlockStatement:
+		p.beginLine(s.Token.Line)
+		p.token(s.Token, "begin")
+		p.block(s.Statements, s.End)
+	}
+}
+
+// body 输出then/else/do之后的语句: begin块与关键字对齐, 单条语句缩进一级
+func (p *printer) body(b *parser.BlockStatement) {
+	if b.Token.Type == token.BEGIN {
+		p.statement(b)
+		return
+	}
+	p.depth++
+	p.fresh = true
+	p.statements(b.Statements)
+	p.depth--
+}
+
+// expression 输出表达式, 只在优先级需要时加括号
+func (p *printer) expression(expr parser.Expression, precedence int) {
+	switch e := expr.(type) {
+	case *parser.Identifier:
+		p.token(e.Token, e.Value)
+	case *parser.IntegerLiteral:
+		p.token(e.Token, e.Token.Literal)
+	case *parser.RealLiteral:
+		p.token(e.Token, e.Token.Literal)
+	case *parser.Boolean:
+		p.token(e.Token, e.Token.Literal)
+	case *parser.CallExpression:
+		p.token(e.Token, e.Function.Value)
+		p.write("(")
+		for i, arg := range e.Arguments {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.expression(arg, parser.LOWEST)
+		}
+		p.token(e.Rparen, ")")
+	case *parser.PrefixExpression:
+		p.token(e.Token, e.Operator)
+		p.expression(e.Right, parser.PREFIX)
+	case *parser.InfixExpression:
+		prec := parser.Precedence(e.Token.Type)
+		// 运算符都是左结合的, 右操作数优先级相同时也要加括号
+		if prec < precedence {
+			p.write("(")
+		}
+		p.expression(e.Left, prec)
+		p.write(" ")
+		p.token(e.Token, e.Operator)
+		p.write(" ")
+		p.expression(e.Right, prec+1)
+		if prec < precedence {
+			p.write(")")
+		}
+	}
+}
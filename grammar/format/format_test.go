@@ -0,0 +1,121 @@
+package format
+
+import (
+	"mini-parser/parser"
+	"mini-parser/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// 除了上一级目录中的示例程序, 再覆盖声明、过程和各种位置上的注释
+var programs = map[string]string{
+	"declarations": `program d; // 行尾注释
+// 程序说明
+const n = 10; m = n * 2;
+var x, y: integer; r: real;
+    f: boolean;
+function max(a, b);
+var t;
+begin
+    // 比较
+    if (a > b) then t := a else t := b;
+    return t
+end;
+procedure show;
+begin
+end;
+
+begin
+    x := max(n, m);
+    r := (x + 1) * 2 / (1 - -y);
+    f := !(x > y) && (r <= 1.5 || true);
+    while (x > 0) do
+    begin
+        x := x - 1 // 递减
+    end;
+    show()
+end.`,
+	"elsecomment": "if (x > 1) then // after then\n y := 1 // after y\n else\n y := 2",
+	"noheader": `x := 1; y := x - (2 - 3);
+
+// 条件
+if (x != y) then begin y := 1 end else x := 2`,
+}
+
+// spans 语法树文本中每行末尾的源程序范围, 格式化前后不同
+var spans = regexp.MustCompile(`(?m) \[[-0-9:]*\]$`)
+
+// sources 返回全部用于测试的程序
+func sources(t *testing.T) map[string]string {
+	t.Helper()
+	all := map[string]string{}
+	for name, src := range programs {
+		all[name] = src
+	}
+	for _, name := range []string{"test_correct", "test_complex"} {
+		src, err := os.ReadFile(filepath.Join("..", name+".mini"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		all[name] = string(src)
+	}
+	return all
+}
+
+func tree(t *testing.T, src string) string {
+	t.Helper()
+	p := parser.New(token.New(src))
+	program := p.ParseProgram()
+	if errs := p.ErrorList(); len(errs) > 0 {
+		t.Fatalf("语法错误: %v\n%s", errs, src)
+	}
+	return spans.ReplaceAllString(parser.Tree(program), "")
+}
+
+// 格式化不改变程序的语法树
+func TestRoundTrip(t *testing.T) {
+	for name, src := range sources(t) {
+		t.Run(name, func(t *testing.T) {
+			out, err := Source(src, DefaultOptions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := tree(t, out), tree(t, src); got != want {
+				t.Errorf("格式化后的语法树\n%s\n原来的语法树\n%s\n格式化结果\n%s", got, want, out)
+			}
+		})
+	}
+}
+
+// 格式化已经格式化过的程序不再有变化
+func TestIdempotent(t *testing.T) {
+	for name, src := range sources(t) {
+		t.Run(name, func(t *testing.T) {
+			once, err := Source(src, DefaultOptions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			twice, err := Source(once, DefaultOptions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if twice != once {
+				t.Errorf("第二次格式化的结果\n%s\n第一次的结果\n%s", twice, once)
+			}
+		})
+	}
+}
+
+// 程序头与begin之间的注释留在begin之前
+func TestCommentBeforeBegin(t *testing.T) {
+	src := "program p;\n// header\nbegin\n    x := 1\nend.\n"
+	out, err := Source(src, DefaultOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != src {
+		t.Errorf("得到\n%s\n期望\n%s", out, src)
+	}
+}
@@ -0,0 +1,193 @@
+// Package gen 按文法随机生成合法的Mini程序, 并向程序中注入单个记号的错误
+package gen
+
+import (
+	"fmt"
+	"math/rand"
+	"mini-parser/grammar"
+	"strings"
+)
+
+// Options 控制生成的程序的规模
+type Options struct {
+	MaxDepth int                // 推导树超过该深度后只选最快结束的产生式
+	Weights  map[string]float64 // 产生式(按 Production.String())被选中的权重, 没有列出的为1, nil 时用默认权重
+	Seed     int64
+}
+
+// DefaultOptions 深度不超过12; 按 mini.bnf 降低空语句表、空块和 return 的权重, 其余产生式权重为1
+var DefaultOptions = Options{
+	MaxDepth: 12,
+	Seed:     1,
+	Weights: map[string]float64{
+		"<语句表> → ε":     0.1,
+		"<语句表尾> → ε":    0.4,
+		"<语句> → <复合语句>": 0.5,
+		"<语句> → <返回语句>": 0.2,
+	},
+}
+
+// Generator 随机程序生成器
+type Generator struct {
+	g      *grammar.Grammar
+	opts   Options
+	rng    *rand.Rand
+	height map[string]int // 非终结符推导出终结符串所需的最小推导树高度
+}
+
+// identifiers 生成标识符时使用的名字
+var identifiers = []string{"a", "b", "c", "i", "j", "n", "x", "y", "sum", "count", "flag"}
+
+// New 创建生成器, 文法中每个非终结符都必须能推导出终结符串
+func New(g *grammar.Grammar, opts Options) (*Generator, error) {
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = DefaultOptions.MaxDepth
+	}
+	if opts.Weights == nil {
+		opts.Weights = DefaultOptions.Weights
+	}
+	gen := &Generator{g: g, opts: opts, rng: rand.New(rand.NewSource(opts.Seed)), height: map[string]int{}}
+	for changed := true; changed; {
+		changed = false
+		for _, p := range g.Productions {
+			h, ok := gen.productionHeight(p)
+			if old, seen := gen.height[p.Head]; ok && (!seen || h < old) {
+				gen.height[p.Head] = h
+				changed = true
+			}
+		}
+	}
+	for _, n := range g.Nonterminals {
+		if _, ok := gen.height[n]; !ok {
+			return nil, fmt.Errorf("非终结符 %s 不能推导出终结符串, 无法生成程序", n)
+		}
+	}
+	return gen, nil
+}
+
+// productionHeight 用产生式开始推导时的最小高度, 右部有尚未算出高度的非终结符时返回false
+func (gen *Generator) productionHeight(p grammar.Production) (int, bool) {
+	h := 1
+	for _, s := range p.Body {
+		if s.Terminal {
+			continue
+		}
+		sh, ok := gen.height[s.Name]
+		if !ok {
+			return 0, false
+		}
+		h = max(h, sh+1)
+	}
+	return h, true
+}
+
+// Words 从开始符号随机推导出一个句子, 返回各终结符的文本
+func (gen *Generator) Words() []string {
+	var words []string
+	gen.expand(gen.g.Start, 0, &words)
+	return words
+}
+
+// Program 生成一个程序并排版为源代码
+func (gen *Generator) Program() string {
+	return Layout(gen.Words())
+}
+
+func (gen *Generator) expand(head string, depth int, words *[]string) {
+	for _, s := range gen.choose(head, depth).Body {
+		if s.Terminal {
+			*words = append(*words, gen.terminal(s.Name))
+		} else {
+			gen.expand(s.Name, depth+1, words)
+		}
+	}
+}
+
+// choose 按权重随机选择产生式; 剩余深度不够时只在能最快结束的产生式中选择
+func (gen *Generator) choose(head string, depth int) grammar.Production {
+	alts := gen.g.Alternatives(head)
+	// 推导树的高度不超过剩余的深度, 超过 MaxDepth 后只剩最快结束的产生式
+	limit := max(gen.height[head], gen.opts.MaxDepth-depth)
+
+	var candidates []grammar.Production
+	var weights []float64
+	total := 0.0
+	for _, p := range alts {
+		h, ok := gen.productionHeight(p)
+		if !ok || h > limit {
+			continue
+		}
+		w := 1.0
+		if x, ok := gen.opts.Weights[p.String()]; ok {
+			w = x
+		}
+		if w <= 0 {
+			continue
+		}
+		candidates = append(candidates, p)
+		weights = append(weights, w)
+		total += w
+	}
+	if len(candidates) == 0 {
+		// 权重都为0时退回到最快结束的产生式
+		for _, p := range alts {
+			if h, _ := gen.productionHeight(p); h == gen.height[head] {
+				return p
+			}
+		}
+	}
+
+	x := gen.rng.Float64() * total
+	for i, w := range weights {
+		if x < w {
+			return candidates[i]
+		}
+		x -= w
+	}
+	return candidates[len(candidates)-1]
+}
+
+// terminal 单词类终结符取随机的值, 其余终结符就是它本身的文本
+func (gen *Generator) terminal(name string) string {
+	switch name {
+	case "标识符":
+		return identifiers[gen.rng.Intn(len(identifiers))]
+	case "整数":
+		return fmt.Sprint(gen.rng.Intn(100))
+	case "实数":
+		return fmt.Sprintf("%d.%d", gen.rng.Intn(100), gen.rng.Intn(100))
+	}
+	return name
+}
+
+// Layout 把终结符排成源代码: 分号、begin 之后换行, begin 和 end 之间缩进
+func Layout(words []string) string {
+	var out strings.Builder
+	indent := 0
+	lineStart := true
+	for i, w := range words {
+		if w == "end" {
+			indent = max(0, indent-1)
+			if !lineStart {
+				out.WriteString("\n")
+				lineStart = true
+			}
+		}
+		if lineStart {
+			out.WriteString(strings.Repeat("    ", indent))
+		} else if w != ";" && w != "." && w != "," {
+			out.WriteString(" ")
+		}
+		out.WriteString(w)
+		lineStart = false
+
+		if w == "begin" {
+			indent++
+		}
+		if w == ";" || w == "begin" || i == len(words)-1 {
+			out.WriteString("\n")
+			lineStart = true
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,132 @@
+package gen
+
+import (
+	"fmt"
+	"math/rand"
+	"mini-parser/parser"
+	"mini-parser/token"
+	"strings"
+	"unicode/utf8"
+)
+
+// Kind 注入的错误种类
+type Kind int
+
+const (
+	DeleteSemicolon Kind = iota // 删除语句之间的 ;
+	DropKeyword                 // 删除 then 或 do
+	SwapOperator                // := 换成 =, 中缀运算符换成 :=
+)
+
+func (k Kind) String() string {
+	return [...]string{"删除分号", "删除关键字", "替换运算符"}[k]
+}
+
+// Mutation 一次注入的错误
+type Mutation struct {
+	Kind        Kind
+	Token       token.Token   // 原程序中被删除或替换的记号
+	Replacement string        // 替换后的文本, 删除时为空
+	Near        []token.Token // 注入后的程序中注入处的记号及其前后相邻的记号
+}
+
+func (m Mutation) String() string {
+	if m.Kind == SwapOperator {
+		return fmt.Sprintf("第%d行第%d列: 把 %s 替换为 %s", m.Token.Line, m.Token.Column, m.Token.Literal, m.Replacement)
+	}
+	return fmt.Sprintf("第%d行第%d列: 删除 %s", m.Token.Line, m.Token.Column, m.Token.Literal)
+}
+
+// Covers 报告的错误位置是否在注入处或与之相邻的记号上
+func (m Mutation) Covers(line, column int) bool {
+	for _, tok := range m.Near {
+		if tok.Line == line && tok.Column == column {
+			return true
+		}
+	}
+	return false
+}
+
+// Mutate 向程序中注入一个单记号错误。删除的记号替换为同样宽度的空格, 其余记号的位置不变。
+// 程序中没有可以注入的位置时返回false。
+func Mutate(src string, rng *rand.Rand) (string, Mutation, bool) {
+	tokens := tokenize(src)
+	sites := map[Kind][]int{}
+	for i, tok := range tokens {
+		switch {
+		case tok.Type == token.SEMICOLON:
+			// 最后一条语句后的分号可以省略, 删除它不是错误;
+			// return 之后的分号删除后下一条调用语句会成为返回值, 也不是错误
+			switch {
+			case tokens[i+1].Type == token.END, tokens[i+1].Type == token.EOF, tokens[i+1].Type == token.DOT:
+			case i > 0 && tokens[i-1].Type == token.RETURN && tokens[i+1].Type == token.IDENT && tokens[i+2].Type == token.LPAREN:
+			default:
+				sites[DeleteSemicolon] = append(sites[DeleteSemicolon], i)
+			}
+		case tok.Type == token.THEN || tok.Type == token.DO:
+			sites[DropKeyword] = append(sites[DropKeyword], i)
+		case tok.Type == token.ASSIGN || parser.IsBinary(tok.Type):
+			sites[SwapOperator] = append(sites[SwapOperator], i)
+		}
+	}
+
+	var kinds []Kind
+	for _, k := range []Kind{DeleteSemicolon, DropKeyword, SwapOperator} {
+		if len(sites[k]) > 0 {
+			kinds = append(kinds, k)
+		}
+	}
+	if len(kinds) == 0 {
+		return src, Mutation{}, false
+	}
+	kind := kinds[rng.Intn(len(kinds))]
+	i := sites[kind][rng.Intn(len(sites[kind]))]
+	m := Mutation{Kind: kind, Token: tokens[i]}
+
+	start := offset(src, tokens[i].Line, tokens[i].Column)
+	end := start + len(tokens[i].Literal)
+	replacement := strings.Repeat(" ", utf8.RuneCountInString(tokens[i].Literal))
+	if kind == SwapOperator {
+		m.Replacement = ":="
+		if tokens[i].Type == token.ASSIGN {
+			m.Replacement = "="
+		}
+		replacement = m.Replacement
+	}
+	out := src[:start] + replacement + src[end:]
+
+	// 删除时注入后的第i个记号是原来的下一个记号, 替换时是替换后的记号
+	mutated := tokenize(out)
+	for j := max(0, i-1); j <= min(len(mutated)-1, i+1); j++ {
+		if j == i+1 && kind != SwapOperator {
+			break
+		}
+		m.Near = append(m.Near, mutated[j])
+	}
+	return out, m, true
+}
+
+func tokenize(src string) []token.Token {
+	tokenizer := token.New(src)
+	var tokens []token.Token
+	for {
+		tok := tokenizer.NextToken()
+		tokens = append(tokens, tok)
+		if tok.Type == token.EOF {
+			return tokens
+		}
+	}
+}
+
+// offset 由行号和列号(都从1开始, 列按字符计)求字节偏移
+func offset(src string, line, column int) int {
+	pos := 0
+	for l := 1; l < line; l++ {
+		pos += strings.IndexByte(src[pos:], '\n') + 1
+	}
+	for c := 1; c < column; c++ {
+		_, size := utf8.DecodeRuneInString(src[pos:])
+		pos += size
+	}
+	return pos
+}
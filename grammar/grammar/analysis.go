@@ -0,0 +1,180 @@
+package grammar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Set 终结符集合
+type Set map[string]bool
+
+// Add 加入集合中没有的元素, 返回集合是否改变
+func (s Set) Add(items ...string) bool {
+	changed := false
+	for _, x := range items {
+		if !s[x] {
+			s[x] = true
+			changed = true
+		}
+	}
+	return changed
+}
+
+// Sorted 按字典序返回集合中的元素
+func (s Set) Sorted() []string {
+	out := make([]string, 0, len(s))
+	for x := range s {
+		out = append(out, x)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (s Set) String() string {
+	return "{" + strings.Join(s.Sorted(), ", ") + "}"
+}
+
+// Analysis 文法的 nullable、FIRST 和 FOLLOW 集
+type Analysis struct {
+	Grammar  *Grammar
+	Nullable map[string]bool
+	First    map[string]Set
+	Follow   map[string]Set
+}
+
+// Analyze 用不动点迭代计算 nullable、FIRST 和 FOLLOW
+func Analyze(g *Grammar) *Analysis {
+	a := &Analysis{
+		Grammar:  g,
+		Nullable: map[string]bool{},
+		First:    map[string]Set{},
+		Follow:   map[string]Set{},
+	}
+	for _, n := range g.Nonterminals {
+		a.First[n] = Set{}
+		a.Follow[n] = Set{}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, p := range g.Productions {
+			first, nullable := a.FirstOf(p.Body)
+			if a.First[p.Head].Add(first.Sorted()...) {
+				changed = true
+			}
+			if nullable && !a.Nullable[p.Head] {
+				a.Nullable[p.Head] = true
+				changed = true
+			}
+		}
+	}
+
+	a.Follow[g.Start].Add(End)
+	for changed := true; changed; {
+		changed = false
+		for _, p := range g.Productions {
+			for i, s := range p.Body {
+				if s.Terminal {
+					continue
+				}
+				// FOLLOW(B) 包含 FIRST(β), β 可空时还包含 FOLLOW(A)
+				first, nullable := a.FirstOf(p.Body[i+1:])
+				if a.Follow[s.Name].Add(first.Sorted()...) {
+					changed = true
+				}
+				if nullable && a.Follow[s.Name].Add(a.Follow[p.Head].Sorted()...) {
+					changed = true
+				}
+			}
+		}
+	}
+	return a
+}
+
+// FirstOf 返回符号串的 FIRST 集以及它能否推导出空串
+func (a *Analysis) FirstOf(seq []Symbol) (Set, bool) {
+	first := Set{}
+	for _, s := range seq {
+		if s.Terminal {
+			first.Add(s.Name)
+			return first, false
+		}
+		first.Add(a.First[s.Name].Sorted()...)
+		if !a.Nullable[s.Name] {
+			return first, false
+		}
+	}
+	return first, true
+}
+
+// Predict 产生式的预测集: FIRST(α), α 可空时加上 FOLLOW(A)
+func (a *Analysis) Predict(p Production) Set {
+	first, nullable := a.FirstOf(p.Body)
+	if nullable {
+		first.Add(a.Follow[p.Head].Sorted()...)
+	}
+	return first
+}
+
+// Conflict 同一非终结符的两个产生式的预测集相交
+type Conflict struct {
+	Head      string
+	Terminals []string
+	First     Production
+	Second    Production
+	Kind      string // FIRST/FIRST 或 FIRST/FOLLOW
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s 冲突: 遇到 %s 时可以选择 %s (第%d行) 或 %s (第%d行)",
+		c.Kind, strings.Join(c.Terminals, ", "), c.First, c.First.Line, c.Second, c.Second.Line)
+}
+
+// LL1Conflicts 列出所有使文法不是 LL(1) 的产生式对
+func (a *Analysis) LL1Conflicts() []Conflict {
+	var conflicts []Conflict
+	for _, head := range a.Grammar.Nonterminals {
+		alts := a.Grammar.Alternatives(head)
+		for i := range alts {
+			for j := i + 1; j < len(alts); j++ {
+				pi, pj := a.Predict(alts[i]), a.Predict(alts[j])
+				var common []string
+				for _, x := range pi.Sorted() {
+					if pj[x] {
+						common = append(common, x)
+					}
+				}
+				if len(common) == 0 {
+					continue
+				}
+
+				kind := "FIRST/FIRST"
+				fi, ni := a.FirstOf(alts[i].Body)
+				fj, nj := a.FirstOf(alts[j].Body)
+				for _, x := range common {
+					if ni && !fi[x] || nj && !fj[x] {
+						kind = "FIRST/FOLLOW"
+					}
+				}
+				conflicts = append(conflicts, Conflict{head, common, alts[i], alts[j], kind})
+			}
+		}
+	}
+	return conflicts
+}
+
+// String 按非终结符的顺序列出 nullable、FIRST 和 FOLLOW
+func (a *Analysis) String() string {
+	var out strings.Builder
+	for _, n := range a.Grammar.Nonterminals {
+		nullable := ""
+		if a.Nullable[n] {
+			nullable = " (可空)"
+		}
+		fmt.Fprintf(&out, "%s%s\n", n, nullable)
+		fmt.Fprintf(&out, "    FIRST  = %s\n", a.First[n])
+		fmt.Fprintf(&out, "    FOLLOW = %s\n", a.Follow[n])
+	}
+	return out.String()
+}
@@ -0,0 +1,376 @@
+package grammar
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// End 输入结束符, 出现在开始符号的FOLLOW集中
+const End = "#"
+
+// Symbol 文法符号, 非终结符写作 <名称>
+type Symbol struct {
+	Name     string
+	Terminal bool
+}
+
+func (s Symbol) String() string {
+	return s.Name
+}
+
+// Production 产生式, Body 为空表示 ε, Line 为它在文法文件中的行号
+type Production struct {
+	Head string
+	Body []Symbol
+	Line int
+}
+
+func (p Production) String() string {
+	return p.Head + " → " + BodyString(p.Body)
+}
+
+// BodyString 产生式右部的文本, 空串显示为 ε
+func BodyString(body []Symbol) string {
+	if len(body) == 0 {
+		return "ε"
+	}
+	names := make([]string, len(body))
+	for i, s := range body {
+		names[i] = s.Name
+	}
+	return strings.Join(names, " ")
+}
+
+// Grammar 上下文无关文法, 第一条规则的左部为开始符号
+type Grammar struct {
+	Start        string
+	Productions  []Production
+	Nonterminals []string // 按第一次作为左部出现的顺序
+	Terminals    []string // 按第一次出现的顺序
+}
+
+// Alternatives 返回左部为head的全部产生式
+func (g *Grammar) Alternatives(head string) []Production {
+	var out []Production
+	for _, p := range g.Productions {
+		if p.Head == head {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// String 以BNF输出文法, 关键字和符号加引号, 结果可以再由 Parse 读入
+func (g *Grammar) String() string {
+	var out strings.Builder
+	for _, head := range g.Nonterminals {
+		for i, p := range g.Alternatives(head) {
+			if i == 0 {
+				fmt.Fprintf(&out, "%s ::= %s\n", head, bnfBody(p.Body))
+			} else {
+				fmt.Fprintf(&out, "%s   | %s\n", strings.Repeat(" ", utf8.RuneCountInString(head)), bnfBody(p.Body))
+			}
+		}
+	}
+	return out.String()
+}
+
+func bnfBody(body []Symbol) string {
+	if len(body) == 0 {
+		return "ε"
+	}
+	names := make([]string, len(body))
+	for i, s := range body {
+		names[i] = s.Name
+		if s.Terminal {
+			names[i] = quote(s.Name)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+// quote 单词类终结符(标识符、整数、实数)不加引号, 其余终结符加引号
+func quote(name string) string {
+	for _, class := range classes {
+		if name == class && name != End {
+			return name
+		}
+	}
+	if strings.Contains(name, `"`) {
+		return "'" + name + "'"
+	}
+	return `"` + name + `"`
+}
+
+// Load 读取并分析文法文件
+func Load(filename string) (*Grammar, error) {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	g, err := Parse(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return g, nil
+}
+
+// Parse 分析BNF/EBNF文法:
+//
+//	<非终结符> ::= 右部 | 右部 ...    (也可以写作 -> 或 →)
+//
+// 右部由 <非终结符>、带引号的终结符 "if"、不带引号的单词类终结符 标识符 以及 ε 组成,
+// 可以用 ( ) 分组, [ ] 表示可选, { } 表示重复零次或多次。# 之后到行尾是注释。
+// EBNF 结构会被改写为新的非终结符, 如 <表达式_1>。
+func Parse(src string) (*Grammar, error) {
+	items, err := scan(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &bnfParser{items: items, g: &Grammar{}, fresh: map[string]int{}}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	if err := p.g.check(); err != nil {
+		return nil, err
+	}
+	return p.g, nil
+}
+
+type itemKind int
+
+const (
+	itemNonterminal itemKind = iota
+	itemTerminal
+	itemEpsilon
+	itemDefine
+	itemPunct // | ( ) [ ] { }
+	itemEOF
+)
+
+type item struct {
+	kind itemKind
+	text string
+	line int
+}
+
+func scan(src string) ([]item, error) {
+	var items []item
+	line := 1
+	for i := 0; i < len(src); {
+		ch, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case ch == '\n':
+			line++
+			i += size
+		case unicode.IsSpace(ch):
+			i += size
+		case ch == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case ch == '<':
+			end := strings.IndexAny(src[i+1:], ">\n")
+			if end < 0 || src[i+1+end] != '>' {
+				return nil, fmt.Errorf("第%d行: 非终结符缺少 >", line)
+			}
+			items = append(items, item{itemNonterminal, src[i : i+end+2], line})
+			i += end + 2
+		case ch == '"' || ch == '\'':
+			end := strings.IndexAny(src[i+1:], string(ch)+"\n")
+			if end < 0 || src[i+1+end] != byte(ch) {
+				return nil, fmt.Errorf("第%d行: 终结符缺少结束的引号", line)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("第%d行: 空终结符, 空串应写作 ε", line)
+			}
+			items = append(items, item{itemTerminal, src[i+1 : i+1+end], line})
+			i += end + 2
+		case strings.HasPrefix(src[i:], "::="):
+			items = append(items, item{itemDefine, "::=", line})
+			i += 3
+		case strings.HasPrefix(src[i:], "->"):
+			items = append(items, item{itemDefine, "->", line})
+			i += 2
+		case ch == '→':
+			items = append(items, item{itemDefine, "→", line})
+			i += size
+		case ch == 'ε':
+			items = append(items, item{itemEpsilon, "ε", line})
+			i += size
+		case strings.ContainsRune("|()[]{}", ch):
+			items = append(items, item{itemPunct, string(ch), line})
+			i += size
+		case unicode.IsLetter(ch) || ch == '_':
+			start := i
+			for i < len(src) {
+				ch, size := utf8.DecodeRuneInString(src[i:])
+				if !unicode.IsLetter(ch) && !unicode.IsDigit(ch) && ch != '_' {
+					break
+				}
+				i += size
+			}
+			items = append(items, item{itemTerminal, src[start:i], line})
+		default:
+			return nil, fmt.Errorf("第%d行: 无法识别的字符 %q", line, ch)
+		}
+	}
+	items = append(items, item{itemEOF, "", line})
+	return items, nil
+}
+
+type bnfParser struct {
+	items []item
+	pos   int
+	g     *Grammar
+	fresh map[string]int // 每个非终结符已经生成的辅助非终结符个数
+}
+
+func (p *bnfParser) cur() item {
+	return p.items[p.pos]
+}
+
+// atRuleStart 当前位置是否为新规则的开始: <非终结符> ::=
+func (p *bnfParser) atRuleStart() bool {
+	return p.cur().kind == itemNonterminal && p.items[p.pos+1].kind == itemDefine
+}
+
+func (p *bnfParser) parse() error {
+	for p.cur().kind != itemEOF {
+		if !p.atRuleStart() {
+			return fmt.Errorf("第%d行: 规则应以 <非终结符> ::= 开始, 实际为 %q", p.cur().line, p.cur().text)
+		}
+		head := p.cur().text
+		p.pos += 2
+		if p.g.Start == "" {
+			p.g.Start = head
+		}
+		p.declare(head)
+		if err := p.alternatives(head); err != nil {
+			return err
+		}
+		if p.cur().kind == itemPunct {
+			return fmt.Errorf("第%d行: 多余的 %s", p.cur().line, p.cur().text)
+		}
+	}
+	if p.g.Start == "" {
+		return fmt.Errorf("文法中没有规则")
+	}
+	return nil
+}
+
+// alternatives 分析以 | 分隔的候选式, 每个候选式成为一条以head为左部的产生式
+func (p *bnfParser) alternatives(head string) error {
+	for {
+		line := p.cur().line
+		body, err := p.sequence(head)
+		if err != nil {
+			return err
+		}
+		p.add(Production{Head: head, Body: body, Line: line})
+		if p.cur().kind != itemPunct || p.cur().text != "|" {
+			return nil
+		}
+		p.pos++
+	}
+}
+
+func (p *bnfParser) sequence(head string) ([]Symbol, error) {
+	var body []Symbol
+	for {
+		it := p.cur()
+		switch {
+		case it.kind == itemEOF || p.atRuleStart():
+			return body, nil
+		case it.kind == itemNonterminal:
+			body = append(body, Symbol{Name: it.text})
+			p.pos++
+		case it.kind == itemTerminal:
+			body = append(body, Symbol{Name: it.text, Terminal: true})
+			p.pos++
+		case it.kind == itemEpsilon:
+			p.pos++
+		case it.kind == itemPunct && strings.Contains("([{", it.text):
+			p.pos++
+			sym, err := p.group(head, it)
+			if err != nil {
+				return nil, err
+			}
+			body = append(body, sym)
+		case it.kind == itemDefine:
+			return nil, fmt.Errorf("第%d行: %s 左边应为非终结符", it.line, it.text)
+		default: // | ) ] }
+			return body, nil
+		}
+	}
+}
+
+// group 把 ( ) [ ] { } 改写为新的非终结符
+func (p *bnfParser) group(head string, open item) (Symbol, error) {
+	p.fresh[head]++
+	name := fmt.Sprintf("%s_%d>", strings.TrimSuffix(head, ">"), p.fresh[head])
+	p.declare(name)
+	if err := p.alternatives(name); err != nil {
+		return Symbol{}, err
+	}
+
+	closing := map[string]string{"(": ")", "[": "]", "{": "}"}[open.text]
+	if p.cur().text != closing || p.cur().kind != itemPunct {
+		return Symbol{}, fmt.Errorf("第%d行: %s 缺少对应的 %s", open.line, open.text, closing)
+	}
+	p.pos++
+
+	switch open.text {
+	case "[":
+		p.add(Production{Head: name, Line: open.line})
+	case "{":
+		// { α } 改写为 N → α N | ε
+		for i, prod := range p.g.Productions {
+			if prod.Head == name {
+				p.g.Productions[i].Body = append(prod.Body, Symbol{Name: name})
+			}
+		}
+		p.add(Production{Head: name, Line: open.line})
+	}
+	return Symbol{Name: name}, nil
+}
+
+// declare 按出现顺序登记非终结符
+func (p *bnfParser) declare(name string) {
+	if !contains(p.g.Nonterminals, name) {
+		p.g.Nonterminals = append(p.g.Nonterminals, name)
+	}
+}
+
+func (p *bnfParser) add(prod Production) {
+	for _, s := range prod.Body {
+		if s.Terminal && !contains(p.g.Terminals, s.Name) {
+			p.g.Terminals = append(p.g.Terminals, s.Name)
+		}
+	}
+	p.g.Productions = append(p.g.Productions, prod)
+}
+
+// check 检查每个出现在右部的非终结符都有产生式
+func (g *Grammar) check() error {
+	for _, prod := range g.Productions {
+		for _, s := range prod.Body {
+			if !s.Terminal && !contains(g.Nonterminals, s.Name) {
+				return fmt.Errorf("第%d行: 非终结符 %s 没有产生式", prod.Line, s.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
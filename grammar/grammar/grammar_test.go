@@ -0,0 +1,30 @@
+package grammar_test
+
+import (
+	"mini-parser/grammar"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// load 读入上一级目录中的 mini.bnf
+func load(t *testing.T) *grammar.Grammar {
+	t.Helper()
+	g, err := grammar.Load(filepath.Join("..", "mini.bnf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// mini.bnf 只有悬挂 else 一处 LL(1) 冲突, 修改文法引入新的冲突时这里会失败
+func TestMiniConflicts(t *testing.T) {
+	conflicts := grammar.Analyze(load(t)).LL1Conflicts()
+	if len(conflicts) != 1 {
+		t.Fatalf("期望一个 LL(1) 冲突, 实际有 %d 个: %v", len(conflicts), conflicts)
+	}
+	c := conflicts[0]
+	if c.Head != "<否则部分>" || c.Kind != "FIRST/FOLLOW" || !slices.Equal(c.Terminals, []string{"else"}) {
+		t.Errorf("期望 <否则部分> 在 else 上的 FIRST/FOLLOW 冲突, 实际为 %v", c)
+	}
+}
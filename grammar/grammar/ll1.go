@@ -0,0 +1,184 @@
+package grammar
+
+import (
+	"fmt"
+	"mini-parser/diag"
+	"mini-parser/msg"
+	"mini-parser/parser"
+	"mini-parser/token"
+	"strings"
+	"unicode/utf8"
+)
+
+// LL1Table 预测分析表, Table[A][a] 为栈顶是A、当前输入是a时使用的产生式
+type LL1Table struct {
+	Analysis  *Analysis
+	Table     map[string]map[string]Production
+	Conflicts []Conflict
+}
+
+// BuildLL1 由 FIRST/FOLLOW 集构造预测分析表。
+// 有冲突时保留文法中先出现的产生式, 对 mini.bnf 来说就是让 else 与最近的 if 结合。
+func BuildLL1(a *Analysis) *LL1Table {
+	t := &LL1Table{
+		Analysis:  a,
+		Table:     map[string]map[string]Production{},
+		Conflicts: a.LL1Conflicts(),
+	}
+	for _, head := range a.Grammar.Nonterminals {
+		t.Table[head] = map[string]Production{}
+	}
+	for _, p := range a.Grammar.Productions {
+		for _, x := range a.Predict(p).Sorted() {
+			if _, ok := t.Table[p.Head][x]; !ok {
+				t.Table[p.Head][x] = p
+			}
+		}
+	}
+	return t
+}
+
+// String 按非终结符逐行列出分析表中的非空项
+func (t *LL1Table) String() string {
+	var out strings.Builder
+	for _, head := range t.Analysis.Grammar.Nonterminals {
+		out.WriteString(head + "\n")
+		row := t.Table[head]
+		keys := make(Set)
+		for x := range row {
+			keys.Add(x)
+		}
+		for _, x := range keys.Sorted() {
+			fmt.Fprintf(&out, "    %s%s %s\n", x, strings.Repeat(" ", max(0, 8-diag.Width(x))), row[x])
+		}
+	}
+	return out.String()
+}
+
+// classes mini.bnf 中单词类终结符对应的记号类型
+var classes = map[token.TokenType]string{
+	token.IDENT:  "标识符",
+	token.NUMBER: "整数",
+	token.REAL:   "实数",
+	token.EOF:    End,
+}
+
+// TerminalOf 返回记号在 mini.bnf 中对应的终结符, 关键字和符号就是记号类型本身
+func TerminalOf(tok token.Token) string {
+	if name, ok := classes[tok.Type]; ok {
+		return name
+	}
+	return string(tok.Type)
+}
+
+// Tokenize 读出源程序的全部记号, 最后一个是EOF
+func Tokenize(src string) []token.Token {
+	tokenizer := token.New(src)
+	var tokens []token.Token
+	for {
+		tok := tokenizer.NextToken()
+		tokens = append(tokens, tok)
+		if tok.Type == token.EOF {
+			return tokens
+		}
+	}
+}
+
+// Step 分析过程中的一步: 动作执行前的栈(栈底在左)和剩余输入
+type Step struct {
+	Stack  string
+	Input  string
+	Action string
+}
+
+// Parse 用分析表对记号序列做预测分析, 返回每一步的记录。
+// 出错时返回 parser.ParserErrors, 此前的步骤仍然返回。
+func (t *LL1Table) Parse(tokens []token.Token) ([]Step, error) {
+	stack := []Symbol{{Name: End, Terminal: true}, {Name: t.Analysis.Grammar.Start}}
+	var steps []Step
+	pos := 0
+
+	for {
+		tok := tokens[pos]
+		lookahead := TerminalOf(tok)
+		top := stack[len(stack)-1]
+		step := Step{Stack: stackString(stack), Input: inputString(tokens[pos:])}
+
+		switch {
+		case top.Terminal && top.Name == End && lookahead == End:
+			step.Action = "接受"
+			return append(steps, step), nil
+		case top.Terminal:
+			if top.Name != lookahead {
+				return steps, syntaxError(tok, msg.Get(msg.ExpectedToken, top.Name, literal(tok)))
+			}
+			step.Action = "匹配 " + top.Name
+			stack = stack[:len(stack)-1]
+			pos++
+		default:
+			prod, ok := t.Table[top.Name][lookahead]
+			if !ok {
+				return steps, syntaxError(tok, msg.Get(msg.NoProduction, top.Name, literal(tok)))
+			}
+			step.Action = prod.String()
+			stack = stack[:len(stack)-1]
+			for i := len(prod.Body) - 1; i >= 0; i-- {
+				stack = append(stack, prod.Body[i])
+			}
+		}
+		steps = append(steps, step)
+	}
+}
+
+func stackString(stack []Symbol) string {
+	names := make([]string, len(stack))
+	for i, s := range stack {
+		names[i] = s.Name
+	}
+	return strings.Join(names, " ")
+}
+
+func inputString(tokens []token.Token) string {
+	texts := make([]string, len(tokens))
+	for i, tok := range tokens {
+		texts[i] = literal(tok)
+	}
+	return strings.Join(texts, " ")
+}
+
+// literal 记号的原文, EOF显示为结束符
+func literal(tok token.Token) string {
+	if tok.Type == token.EOF {
+		return End
+	}
+	return tok.Literal
+}
+
+func syntaxError(tok token.Token, message string) error {
+	return parser.ParserErrors{{
+		Line:    tok.Line,
+		Column:  tok.Column,
+		Length:  utf8.RuneCountInString(tok.Literal),
+		Message: message,
+	}}
+}
+
+// FormatSteps 把分析过程排成三列: 栈、剩余输入(右对齐)、动作
+func FormatSteps(steps []Step) string {
+	stackWidth, inputWidth := diag.Width("栈"), diag.Width("输入")
+	for _, s := range steps {
+		stackWidth = max(stackWidth, diag.Width(s.Stack))
+		inputWidth = max(inputWidth, diag.Width(s.Input))
+	}
+
+	var out strings.Builder
+	row := func(stack, input, action string) {
+		out.WriteString(stack + strings.Repeat(" ", stackWidth-diag.Width(stack)+2))
+		out.WriteString(strings.Repeat(" ", inputWidth-diag.Width(input)) + input + "  " + action + "\n")
+	}
+	row("栈", "输入", "动作")
+	for _, s := range steps {
+		row(s.Stack, s.Input, s.Action)
+	}
+	return out.String()
+}
@@ -0,0 +1,60 @@
+package grammar_test
+
+import (
+	"math/rand"
+	"mini-parser/gen"
+	"mini-parser/grammar"
+	"mini-parser/parser"
+	"mini-parser/token"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 按 mini.bnf 构造的预测分析器与手写的递归下降分析器应当接受同样的程序
+func TestLL1MatchesParser(t *testing.T) {
+	g := load(t)
+	table := grammar.BuildLL1(grammar.Analyze(g))
+	rejected := 0
+	check := func(name, src string) {
+		t.Helper()
+		_, err := table.Parse(grammar.Tokenize(src))
+		p := parser.New(token.New(src))
+		p.ParseProgram()
+		errs := p.ErrorList()
+		if err != nil {
+			rejected++
+		}
+		switch {
+		case err != nil && len(errs) == 0:
+			t.Errorf("%s: 预测分析器报告 %v, 递归下降分析器接受\n%s", name, err, src)
+		case err == nil && len(errs) > 0:
+			t.Errorf("%s: 递归下降分析器报告 %v, 预测分析器接受\n%s", name, errs[0], src)
+		}
+	}
+
+	for _, name := range []string{"test_correct", "test_complex", "test_error"} {
+		src, err := os.ReadFile(filepath.Join("..", name+".mini"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(name, string(src))
+	}
+
+	generator, err := gen.New(g, gen.DefaultOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		src := generator.Program()
+		check("生成的程序", src)
+		if out, m, ok := gen.Mutate(src, rng); ok {
+			check(m.String(), out)
+		}
+	}
+	// 注入的错误并不总能使程序不合法(如交换运算符), 但大部分应当被两者拒绝
+	if rejected < 100 {
+		t.Errorf("只有 %d 个程序被拒绝, 变异没有起作用", rejected)
+	}
+}
@@ -0,0 +1,505 @@
+package grammar
+
+import (
+	"fmt"
+	"mini-parser/diag"
+	"mini-parser/msg"
+	"mini-parser/token"
+	"sort"
+	"strings"
+)
+
+// LRKind LR分析表的种类
+type LRKind int
+
+const (
+	LR0 LRKind = iota
+	LR1
+	LALR1
+)
+
+func (k LRKind) String() string {
+	return [...]string{"LR(0)", "LR(1)", "LALR(1)"}[k]
+}
+
+// ParseLRKind 解析命令行中的 lr0, lr1, lalr1
+func ParseLRKind(s string) (LRKind, bool) {
+	switch strings.ToLower(s) {
+	case "lr0":
+		return LR0, true
+	case "lr1":
+		return LR1, true
+	case "lalr1", "lalr":
+		return LALR1, true
+	}
+	return LR0, false
+}
+
+// core 不带向前看符号的项目: 第Prod条产生式, 圆点在右部第Dot个符号之前
+type core struct {
+	Prod int
+	Dot  int
+}
+
+// LRState 项目集, 每个项目带有向前看符号集合(LR(0)时为空)
+type LRState struct {
+	ID    int
+	Items map[core]Set
+	Trans map[string]int // 经过文法符号到达的状态
+}
+
+// ActionKind ACTION表项的种类
+type ActionKind int
+
+const (
+	Shift ActionKind = iota
+	Reduce
+	Accept
+)
+
+// Action ACTION表项, Shift时Target为状态, Reduce时为产生式编号
+type Action struct {
+	Kind   ActionKind
+	Target int
+}
+
+func (a Action) String() string {
+	switch a.Kind {
+	case Shift:
+		return fmt.Sprintf("s%d", a.Target)
+	case Reduce:
+		return fmt.Sprintf("r%d", a.Target)
+	}
+	return "acc"
+}
+
+// LRConflict 某状态遇到某终结符时有多个动作, Actions[0] 为采用的动作
+type LRConflict struct {
+	State    int
+	Terminal string
+	Kind     string // 移进/归约 或 归约/归约
+	Actions  []Action
+}
+
+// LRTable LR分析表, Productions[0] 为拓广文法的 S' → S
+type LRTable struct {
+	Kind        LRKind
+	Grammar     *Grammar
+	Productions []Production
+	States      []*LRState
+	Action      []map[string]Action
+	Goto        []map[string]int
+	Conflicts   []LRConflict
+}
+
+// BuildLR 构造项目集规范族和分析表。LALR(1) 由 LR(1) 项目集合并同心状态得到。
+// 冲突时优先移进, 归约/归约冲突时选编号小的产生式, 冲突都记录在 Conflicts 中。
+func BuildLR(a *Analysis, kind LRKind) *LRTable {
+	g := a.Grammar
+	t := &LRTable{Kind: kind, Grammar: g}
+	start := Production{Head: g.Start + "'", Body: []Symbol{{Name: g.Start}}}
+	t.Productions = append([]Production{start}, g.Productions...)
+
+	b := &lrBuilder{t: t, a: a, index: map[string]int{}}
+	initial := map[core]Set{{0, 0}: {}}
+	if kind != LR0 {
+		initial[core{0, 0}].Add(End)
+	}
+	b.state(initial)
+	for i := 0; i < len(t.States); i++ {
+		s := t.States[i]
+		for _, x := range b.nextSymbols(s) {
+			s.Trans[x] = b.state(b.move(s, x))
+		}
+	}
+
+	if kind == LALR1 {
+		b.merge()
+	}
+	b.fill()
+	return t
+}
+
+type lrBuilder struct {
+	t     *LRTable
+	a     *Analysis
+	index map[string]int // 项目集的键 → 状态编号
+}
+
+func (b *lrBuilder) next(c core) (Symbol, bool) {
+	body := b.t.Productions[c.Prod].Body
+	if c.Dot >= len(body) {
+		return Symbol{}, false
+	}
+	return body[c.Dot], true
+}
+
+// closure 求项目集的闭包, 向前看符号随之传播
+func (b *lrBuilder) closure(items map[core]Set) map[core]Set {
+	for changed := true; changed; {
+		changed = false
+		for _, c := range sortedCores(items) {
+			sym, ok := b.next(c)
+			if !ok || sym.Terminal {
+				continue
+			}
+			rest := b.t.Productions[c.Prod].Body[c.Dot+1:]
+			look, nullable := b.a.FirstOf(rest)
+			if b.t.Kind == LR0 {
+				look = Set{}
+			} else if nullable {
+				look.Add(items[c].Sorted()...)
+			}
+			for i, p := range b.t.Productions {
+				if p.Head != sym.Name {
+					continue
+				}
+				nc := core{i, 0}
+				if items[nc] == nil {
+					items[nc] = Set{}
+					changed = true
+				}
+				if items[nc].Add(look.Sorted()...) {
+					changed = true
+				}
+			}
+		}
+	}
+	return items
+}
+
+// move 圆点越过符号x后的项目集(求闭包之前)
+func (b *lrBuilder) move(s *LRState, x string) map[core]Set {
+	out := map[core]Set{}
+	for c, look := range s.Items {
+		if sym, ok := b.next(c); ok && sym.Name == x {
+			nc := core{c.Prod, c.Dot + 1}
+			out[nc] = Set{}
+			out[nc].Add(look.Sorted()...)
+		}
+	}
+	return out
+}
+
+// nextSymbols 项目集中圆点之后的符号, 先终结符后非终结符, 保证状态编号稳定
+func (b *lrBuilder) nextSymbols(s *LRState) []string {
+	terminals, nonterminals := Set{}, Set{}
+	for c := range s.Items {
+		if sym, ok := b.next(c); ok {
+			if sym.Terminal {
+				terminals.Add(sym.Name)
+			} else {
+				nonterminals.Add(sym.Name)
+			}
+		}
+	}
+	return append(terminals.Sorted(), nonterminals.Sorted()...)
+}
+
+// state 返回项目集对应的状态编号, 新的项目集加入规范族
+func (b *lrBuilder) state(kernel map[core]Set) int {
+	items := b.closure(kernel)
+	key := itemsKey(items, true)
+	if id, ok := b.index[key]; ok {
+		return id
+	}
+	id := len(b.t.States)
+	b.t.States = append(b.t.States, &LRState{ID: id, Items: items, Trans: map[string]int{}})
+	b.index[key] = id
+	return id
+}
+
+// merge 合并心相同的LR(1)状态, 向前看符号取并集
+func (b *lrBuilder) merge() {
+	var merged []*LRState
+	byCore := map[string]int{}
+	rename := make([]int, len(b.t.States))
+	for _, s := range b.t.States {
+		key := itemsKey(s.Items, false)
+		id, ok := byCore[key]
+		if !ok {
+			id = len(merged)
+			byCore[key] = id
+			merged = append(merged, &LRState{ID: id, Items: map[core]Set{}, Trans: map[string]int{}})
+		}
+		for c, look := range s.Items {
+			if merged[id].Items[c] == nil {
+				merged[id].Items[c] = Set{}
+			}
+			merged[id].Items[c].Add(look.Sorted()...)
+		}
+		rename[s.ID] = id
+	}
+	for _, s := range b.t.States {
+		for x, target := range s.Trans {
+			merged[rename[s.ID]].Trans[x] = rename[target]
+		}
+	}
+	b.t.States = merged
+}
+
+// fill 根据项目集填写ACTION和GOTO表
+func (b *lrBuilder) fill() {
+	t := b.t
+	terminals := append(append([]string{}, t.Grammar.Terminals...), End)
+	t.Action = make([]map[string]Action, len(t.States))
+	t.Goto = make([]map[string]int, len(t.States))
+
+	for _, s := range t.States {
+		t.Action[s.ID] = map[string]Action{}
+		t.Goto[s.ID] = map[string]int{}
+		for x, target := range s.Trans {
+			if contains(t.Grammar.Nonterminals, x) {
+				t.Goto[s.ID][x] = target
+			} else {
+				b.set(s.ID, x, Action{Shift, target})
+			}
+		}
+		for _, c := range sortedCores(s.Items) {
+			if _, ok := b.next(c); ok {
+				continue
+			}
+			if c.Prod == 0 {
+				b.set(s.ID, End, Action{Kind: Accept})
+				continue
+			}
+			look := s.Items[c].Sorted()
+			if t.Kind == LR0 {
+				look = terminals
+			}
+			for _, x := range look {
+				b.set(s.ID, x, Action{Reduce, c.Prod})
+			}
+		}
+	}
+}
+
+// set 填写ACTION表项, 已有不同的动作时记录冲突
+func (b *lrBuilder) set(state int, x string, act Action) {
+	t := b.t
+	old, ok := t.Action[state][x]
+	if !ok {
+		t.Action[state][x] = act
+		return
+	}
+	if old == act {
+		return
+	}
+
+	keep, other := old, act
+	if act.Kind == Shift || act.Kind == Reduce && old.Kind == Reduce && act.Target < old.Target {
+		keep, other = act, old
+	}
+	t.Action[state][x] = keep
+
+	kind := "归约/归约"
+	if keep.Kind == Shift || other.Kind == Shift {
+		kind = "移进/归约"
+	}
+	for i, c := range t.Conflicts {
+		if c.State == state && c.Terminal == x {
+			t.Conflicts[i].Actions = []Action{keep}
+			for _, a := range append(c.Actions, other) {
+				if a != keep {
+					t.Conflicts[i].Actions = append(t.Conflicts[i].Actions, a)
+				}
+			}
+			return
+		}
+	}
+	t.Conflicts = append(t.Conflicts, LRConflict{state, x, kind, []Action{keep, other}})
+}
+
+func sortedCores(items map[core]Set) []core {
+	cores := make([]core, 0, len(items))
+	for c := range items {
+		cores = append(cores, c)
+	}
+	sort.Slice(cores, func(i, j int) bool {
+		if cores[i].Prod != cores[j].Prod {
+			return cores[i].Prod < cores[j].Prod
+		}
+		return cores[i].Dot < cores[j].Dot
+	})
+	return cores
+}
+
+// itemsKey 项目集的键, withLook为false时只看心
+func itemsKey(items map[core]Set, withLook bool) string {
+	var out strings.Builder
+	for _, c := range sortedCores(items) {
+		fmt.Fprintf(&out, "%d.%d", c.Prod, c.Dot)
+		if withLook {
+			out.WriteString(items[c].String())
+		}
+		out.WriteByte(' ')
+	}
+	return out.String()
+}
+
+// ItemString 以 [A → α · β, a/b] 的形式显示项目
+func (t *LRTable) ItemString(s *LRState, c core) string {
+	p := t.Productions[c.Prod]
+	names := []string{}
+	for i, sym := range p.Body {
+		if i == c.Dot {
+			names = append(names, "·")
+		}
+		names = append(names, sym.Name)
+	}
+	if c.Dot == len(p.Body) {
+		names = append(names, "·")
+	}
+	text := p.Head + " → " + strings.Join(names, " ")
+	if look := s.Items[c]; len(look) > 0 {
+		text += ", " + strings.Join(look.Sorted(), "/")
+	}
+	return "[" + text + "]"
+}
+
+// StateString 列出状态中的全部项目和转移
+func (t *LRTable) StateString(s *LRState) string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "I%d:\n", s.ID)
+	for _, c := range sortedCores(s.Items) {
+		out.WriteString("    " + t.ItemString(s, c) + "\n")
+	}
+	symbols := make(Set)
+	for x := range s.Trans {
+		symbols.Add(x)
+	}
+	for _, x := range symbols.Sorted() {
+		fmt.Fprintf(&out, "    %s ⇒ I%d\n", x, s.Trans[x])
+	}
+	return out.String()
+}
+
+// ConflictString 说明冲突的动作, 并列出所在状态的项目集
+func (t *LRTable) ConflictString(c LRConflict) string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "状态 %d 遇到 %s 时%s冲突:", c.State, c.Terminal, c.Kind)
+	for i, a := range c.Actions {
+		desc := a.String()
+		if a.Kind == Reduce {
+			desc += " (" + t.Productions[a.Target].String() + ")"
+		}
+		if i == 0 {
+			desc += " [采用]"
+		}
+		out.WriteString(" " + desc)
+	}
+	out.WriteString("\n")
+	out.WriteString(t.StateString(t.States[c.State]))
+	return out.String()
+}
+
+// TableString 列出编号的产生式以及ACTION/GOTO表
+func (t *LRTable) TableString() string {
+	var out strings.Builder
+	for i, p := range t.Productions {
+		fmt.Fprintf(&out, "(%d) %s\n", i, p)
+	}
+	out.WriteString("\n")
+
+	terminals := append(append([]string{}, t.Grammar.Terminals...), End)
+	columns := append(append([]string{"状态"}, terminals...), t.Grammar.Nonterminals...)
+	rows := [][]string{columns}
+	for _, s := range t.States {
+		row := []string{fmt.Sprint(s.ID)}
+		for _, x := range terminals {
+			cell := ""
+			if a, ok := t.Action[s.ID][x]; ok {
+				cell = a.String()
+			}
+			row = append(row, cell)
+		}
+		for _, x := range t.Grammar.Nonterminals {
+			cell := ""
+			if target, ok := t.Goto[s.ID][x]; ok {
+				cell = fmt.Sprint(target)
+			}
+			row = append(row, cell)
+		}
+		rows = append(rows, row)
+	}
+
+	widths := make([]int, len(columns))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], diag.Width(cell))
+		}
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			out.WriteString(cell + strings.Repeat(" ", widths[i]-diag.Width(cell)))
+			if i < len(row)-1 {
+				out.WriteString(" ")
+			}
+		}
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+// Reducer 归约时的语义动作, values 依次为右部各符号的语义值, 终结符的语义值是 token.Token
+type Reducer func(p Production, values []interface{}) (interface{}, error)
+
+// Parse 用LR分析表分析记号序列, 返回开始符号的语义值和每一步的记录
+func (t *LRTable) Parse(tokens []token.Token, reduce Reducer) (interface{}, []Step, error) {
+	states := []int{0}
+	symbols := []string{End}
+	values := []interface{}{nil}
+	var steps []Step
+	pos := 0
+
+	for {
+		tok := tokens[pos]
+		lookahead := TerminalOf(tok)
+		state := states[len(states)-1]
+		step := Step{Stack: lrStackString(states, symbols), Input: inputString(tokens[pos:])}
+
+		act, ok := t.Action[state][lookahead]
+		if !ok {
+			return nil, steps, syntaxError(tok, msg.Get(msg.UnexpectedToken, literal(tok)))
+		}
+		switch act.Kind {
+		case Accept:
+			step.Action = "接受"
+			return values[len(values)-1], append(steps, step), nil
+		case Shift:
+			step.Action = fmt.Sprintf("移进 %s, 转到状态 %d", lookahead, act.Target)
+			states = append(states, act.Target)
+			symbols = append(symbols, lookahead)
+			values = append(values, tok)
+			pos++
+		case Reduce:
+			p := t.Productions[act.Target]
+			n := len(p.Body)
+			var value interface{}
+			if reduce != nil {
+				var err error
+				if value, err = reduce(p, values[len(values)-n:]); err != nil {
+					return nil, steps, err
+				}
+			}
+			states = states[:len(states)-n]
+			symbols = symbols[:len(symbols)-n]
+			values = values[:len(values)-n]
+			target := t.Goto[states[len(states)-1]][p.Head]
+			step.Action = fmt.Sprintf("用 (%d) %s 归约, 转到状态 %d", act.Target, p, target)
+			states = append(states, target)
+			symbols = append(symbols, p.Head)
+			values = append(values, value)
+		}
+		steps = append(steps, step)
+	}
+}
+
+// lrStackString 状态和符号交替排列的分析栈
+func lrStackString(states []int, symbols []string) string {
+	parts := []string{fmt.Sprint(states[0])}
+	for i := 1; i < len(states); i++ {
+		parts = append(parts, symbols[i], fmt.Sprint(states[i]))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,75 @@
+package grammar_test
+
+import (
+	"math/rand"
+	"mini-parser/gen"
+	"mini-parser/grammar"
+	"mini-parser/parser"
+	"mini-parser/token"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 按 mini.bnf 构造的LR(1)和LALR(1)分析器与手写的递归下降分析器接受同样的程序,
+// 并且构造出相同的语法树(包括各节点的源程序范围)
+func TestLRMatchesParser(t *testing.T) {
+	g := load(t)
+	a := grammar.Analyze(g)
+	for _, kind := range []grammar.LRKind{grammar.LR1, grammar.LALR1} {
+		t.Run(kind.String(), func(t *testing.T) {
+			table := grammar.BuildLR(a, kind)
+			// 冲突只来自悬挂 else, 按移进解决, 与递归下降分析器一样把 else 配给最近的 if
+			for _, c := range table.Conflicts {
+				if c.Terminal != "else" || c.Actions[0].Kind != grammar.Shift {
+					t.Fatalf("悬挂 else 之外的冲突: %s", table.ConflictString(c))
+				}
+			}
+
+			rejected := 0
+			check := func(name, src string) {
+				t.Helper()
+				program, _, err := table.ParseProgram(src)
+				p := parser.New(token.New(src))
+				want := p.ParseProgram()
+				errs := p.ErrorList()
+				switch {
+				case err != nil && len(errs) == 0:
+					t.Errorf("%s: LR分析器报告 %v, 递归下降分析器接受\n%s", name, err, src)
+				case err == nil && len(errs) > 0:
+					t.Errorf("%s: 递归下降分析器报告 %v, LR分析器接受\n%s", name, errs[0], src)
+				case err != nil:
+					rejected++
+				default:
+					if got, want := parser.Tree(program), parser.Tree(want); got != want {
+						t.Errorf("%s: LR分析器的语法树\n%s\n递归下降分析器的语法树\n%s\n源程序\n%s", name, got, want, src)
+					}
+				}
+			}
+
+			for _, name := range []string{"test_correct", "test_complex", "test_error"} {
+				src, err := os.ReadFile(filepath.Join("..", name+".mini"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				check(name, string(src))
+			}
+
+			generator, err := gen.New(g, gen.DefaultOptions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rng := rand.New(rand.NewSource(1))
+			for i := 0; i < 300; i++ {
+				src := generator.Program()
+				check("生成的程序", src)
+				if out, m, ok := gen.Mutate(src, rng); ok {
+					check(m.String(), out)
+				}
+			}
+			if rejected < 100 {
+				t.Errorf("只有 %d 个程序被拒绝, 变异没有起作用", rejected)
+			}
+		})
+	}
+}
@@ -0,0 +1,332 @@
+package grammar
+
+import (
+	"fmt"
+	"mini-parser/msg"
+	"mini-parser/parser"
+	"mini-parser/parsetree"
+	"mini-parser/token"
+	"strconv"
+)
+
+// operand 表达式尾中的一个 运算符 操作数 对
+type operand struct {
+	op    token.Token
+	right parser.Expression
+}
+
+// arguments 实参部分: 实参表以及右括号
+type arguments struct {
+	list   []parser.Expression
+	rparen token.Token
+}
+
+// elsePart 否则部分: else 记号以及其后的语句, 为空时都是零值
+type elsePart struct {
+	token token.Token
+	body  *parser.BlockStatement
+}
+
+// MiniActions mini.bnf 的语义动作, 构造与 parser.ParseProgram 相同的语法树
+func MiniActions(p Production, v []interface{}) (interface{}, error) {
+	switch p.Head {
+	case parsetree.Program:
+		if len(v) == 1 {
+			return &parser.Program{Statements: v[0].([]parser.Statement)}, nil
+		}
+		name := v[1].(token.Token)
+		body := v[6].(*parser.BlockStatement)
+		return &parser.Program{
+			Token:      v[0].(token.Token),
+			Name:       ident(name),
+			Consts:     v[3].([]*parser.ConstDeclaration),
+			Vars:       v[4].([]*parser.VarDeclaration),
+			Procedures: v[5].([]*parser.ProcedureDeclaration),
+			Begin:      body.Token,
+			Statements: body.Statements,
+			End:        body.End,
+		}, nil
+
+	case parsetree.ProcList:
+		if len(v) == 0 {
+			return []*parser.ProcedureDeclaration(nil), nil
+		}
+		return append([]*parser.ProcedureDeclaration{v[0].(*parser.ProcedureDeclaration)}, v[1].([]*parser.ProcedureDeclaration)...), nil
+
+	case parsetree.Proc:
+		return &parser.ProcedureDeclaration{
+			Token:      v[0].(token.Token),
+			Name:       ident(v[1].(token.Token)),
+			Parameters: v[2].([]*parser.Identifier),
+			Locals:     v[4].([]*parser.VarDeclaration),
+			Body:       v[5].(*parser.BlockStatement),
+		}, nil
+
+	case parsetree.ProcKind:
+		return v[0], nil
+
+	case parsetree.Params:
+		if len(v) == 0 {
+			return []*parser.Identifier(nil), nil
+		}
+		return v[1], nil
+
+	case parsetree.ConstPart:
+		if len(v) == 0 {
+			return []*parser.ConstDeclaration(nil), nil
+		}
+		return append([]*parser.ConstDeclaration{v[1].(*parser.ConstDeclaration)}, v[2].([]*parser.ConstDeclaration)...), nil
+
+	case parsetree.ConstList:
+		if len(v) == 0 {
+			return []*parser.ConstDeclaration(nil), nil
+		}
+		return append([]*parser.ConstDeclaration{v[0].(*parser.ConstDeclaration)}, v[1].([]*parser.ConstDeclaration)...), nil
+
+	case parsetree.ConstDef:
+		name := v[0].(token.Token)
+		return &parser.ConstDeclaration{Token: name, Name: ident(name), Value: v[2].(parser.Expression)}, nil
+
+	case parsetree.Vars:
+		if len(v) == 0 {
+			return []*parser.VarDeclaration(nil), nil
+		}
+		return append([]*parser.VarDeclaration{v[1].(*parser.VarDeclaration)}, v[2].([]*parser.VarDeclaration)...), nil
+
+	case parsetree.VarList:
+		if len(v) == 0 {
+			return []*parser.VarDeclaration(nil), nil
+		}
+		return append([]*parser.VarDeclaration{v[0].(*parser.VarDeclaration)}, v[1].([]*parser.VarDeclaration)...), nil
+
+	case parsetree.VarDecl:
+		names := v[0].([]*parser.Identifier)
+		return &parser.VarDeclaration{Token: names[0].Token, Names: names, Type: v[1].(*parser.Identifier)}, nil
+
+	case parsetree.TypePart:
+		if len(v) == 0 {
+			return (*parser.Identifier)(nil), nil
+		}
+		return ident(v[1].(token.Token)), nil
+
+	case parsetree.ParamList:
+		if len(v) == 0 {
+			return []*parser.Identifier(nil), nil
+		}
+		return v[0], nil
+
+	case parsetree.IdentList:
+		return append([]*parser.Identifier{ident(v[0].(token.Token))}, v[1].([]*parser.Identifier)...), nil
+
+	case parsetree.IdentListTail:
+		if len(v) == 0 {
+			return []*parser.Identifier(nil), nil
+		}
+		return append([]*parser.Identifier{ident(v[1].(token.Token))}, v[2].([]*parser.Identifier)...), nil
+
+	case parsetree.Compound:
+		return &parser.BlockStatement{
+			Token:      v[0].(token.Token),
+			Statements: v[1].([]parser.Statement),
+			End:        v[2].(token.Token),
+		}, nil
+
+	case parsetree.StmtList:
+		if len(v) == 0 {
+			return []parser.Statement(nil), nil
+		}
+		return append([]parser.Statement{v[0].(parser.Statement)}, v[1].([]parser.Statement)...), nil
+
+	case parsetree.StmtListTail:
+		if len(v) == 0 {
+			return []parser.Statement(nil), nil
+		}
+		return v[1], nil
+
+	case parsetree.Stmt:
+		return v[0], nil
+
+	case parsetree.Simple:
+		name := v[0].(token.Token)
+		if args, ok := v[1].(*arguments); ok {
+			return &parser.CallStatement{Token: name, Call: callExpression(name, args)}, nil
+		}
+		return &parser.AssignStatement{
+			Token: name,
+			Name:  ident(name),
+			Value: v[1].(parser.Expression),
+		}, nil
+
+	case parsetree.SimpleTail:
+		if len(v) == 1 {
+			return v[0], nil // <实参部分>
+		}
+		return v[1], nil
+
+	case parsetree.If:
+		alt := v[6].(elsePart)
+		return &parser.IfExpression{
+			Token:       v[0].(token.Token),
+			Condition:   v[2].(parser.Expression),
+			Consequence: body(v[5].(parser.Statement)),
+			Else:        alt.token,
+			Alternative: alt.body,
+		}, nil
+
+	case parsetree.Else:
+		if len(v) == 0 {
+			return elsePart{}, nil
+		}
+		return elsePart{v[0].(token.Token), body(v[1].(parser.Statement))}, nil
+
+	case parsetree.While:
+		return &parser.WhileExpression{
+			Token:     v[0].(token.Token),
+			Condition: v[2].(parser.Expression),
+			Body:      body(v[5].(parser.Statement)),
+		}, nil
+
+	case parsetree.Return:
+		value, _ := v[1].(parser.Expression)
+		return &parser.ReturnStatement{Token: v[0].(token.Token), Value: value}, nil
+
+	case parsetree.ReturnValue:
+		if len(v) == 0 {
+			return nil, nil
+		}
+		return v[0], nil
+
+	case parsetree.Expr, parsetree.Rel, parsetree.Arith, parsetree.Term:
+		// 左结合: 把尾部的运算符和操作数依次与左边已有的表达式组合
+		left := v[0].(parser.Expression)
+		for _, o := range v[1].([]operand) {
+			left = &parser.InfixExpression{Token: o.op, Left: left, Operator: o.op.Literal, Right: o.right}
+		}
+		return left, nil
+
+	case parsetree.ExprTail, parsetree.RelTail, parsetree.ArithTail, parsetree.TermTail:
+		if len(v) == 0 {
+			return []operand(nil), nil
+		}
+		rest := v[2].([]operand)
+		return append([]operand{{v[0].(token.Token), v[1].(parser.Expression)}}, rest...), nil
+
+	case parsetree.LogicOp, parsetree.RelOp, parsetree.AddOp, parsetree.MulOp:
+		return v[0], nil
+
+	case parsetree.Factor:
+		return factor(v)
+
+	case parsetree.Call:
+		if len(v) == 0 {
+			return (*arguments)(nil), nil
+		}
+		return v[0], nil
+
+	case parsetree.Args:
+		return &arguments{list: v[1].([]parser.Expression), rparen: v[2].(token.Token)}, nil
+
+	case parsetree.ArgList:
+		if len(v) == 0 {
+			return []parser.Expression(nil), nil
+		}
+		return append([]parser.Expression{v[0].(parser.Expression)}, v[1].([]parser.Expression)...), nil
+
+	case parsetree.ArgListTail:
+		if len(v) == 0 {
+			return []parser.Expression(nil), nil
+		}
+		return append([]parser.Expression{v[1].(parser.Expression)}, v[2].([]parser.Expression)...), nil
+	}
+	return nil, fmt.Errorf("mini.bnf 中没有产生式 %s 的语义动作", p)
+}
+
+// body 与 parseBodyStatement 一致: begin块直接使用, 单条语句包装为语句块
+func body(stmt parser.Statement) *parser.BlockStatement {
+	if block, ok := stmt.(*parser.BlockStatement); ok {
+		return block
+	}
+	var first token.Token
+	switch s := stmt.(type) {
+	case *parser.AssignStatement:
+		first = s.Token
+	case *parser.IfExpression:
+		first = s.Token
+	case *parser.WhileExpression:
+		first = s.Token
+	case *parser.CallStatement:
+		first = s.Token
+	case *parser.ReturnStatement:
+		first = s.Token
+	}
+	return &parser.BlockStatement{Token: first, Statements: []parser.Statement{stmt}}
+}
+
+func factor(v []interface{}) (interface{}, error) {
+	if len(v) == 3 {
+		return v[1], nil // ( <表达式> )
+	}
+	tok := v[0].(token.Token)
+	if tok.Type == token.IDENT {
+		if args := v[1].(*arguments); args != nil {
+			return callExpression(tok, args), nil
+		}
+		return ident(tok), nil
+	}
+	if len(v) == 2 {
+		return &parser.PrefixExpression{Token: tok, Operator: tok.Literal, Right: v[1].(parser.Expression)}, nil
+	}
+
+	switch tok.Type {
+	case token.NUMBER:
+		value, err := strconv.ParseInt(tok.Literal, 0, 64)
+		if err != nil {
+			return nil, syntaxError(tok, msg.Get(msg.InvalidInteger, tok.Literal))
+		}
+		return &parser.IntegerLiteral{Token: tok, Value: value}, nil
+	case token.REAL:
+		value, err := strconv.ParseFloat(tok.Literal, 64)
+		if err != nil {
+			return nil, syntaxError(tok, msg.Get(msg.InvalidReal, tok.Literal))
+		}
+		return &parser.RealLiteral{Token: tok, Value: value}, nil
+	}
+	return &parser.Boolean{Token: tok, Value: tok.Type == token.TRUE}, nil
+}
+
+func ident(tok token.Token) *parser.Identifier {
+	return &parser.Identifier{Token: tok, Value: tok.Literal}
+}
+
+func callExpression(name token.Token, args *arguments) *parser.CallExpression {
+	return &parser.CallExpression{Token: name, Function: ident(name), Arguments: args.list, Rparen: args.rparen}
+}
+
+// IsMini 文法是否与 parsetree 中的 Mini 文法一致(产生式相同, 顺序不限)
+func IsMini(g *Grammar) bool {
+	want := map[string]bool{}
+	for _, p := range parsetree.Grammar() {
+		want[p.String()] = true
+	}
+	if len(want) != len(g.Productions) {
+		return false
+	}
+	for _, p := range g.Productions {
+		if !want[p.String()] {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseProgram 用LR分析表分析Mini源程序并构造语法树, 分析表须由 mini.bnf 生成
+func (t *LRTable) ParseProgram(src string) (*parser.Program, []Step, error) {
+	if !IsMini(t.Grammar) {
+		return nil, nil, fmt.Errorf("语义动作只适用于 mini.bnf 中的文法")
+	}
+	value, steps, err := t.Parse(Tokenize(src), MiniActions)
+	if err != nil {
+		return nil, steps, err
+	}
+	return value.(*parser.Program), steps, nil
+}
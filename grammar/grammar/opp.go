@@ -0,0 +1,404 @@
+package grammar
+
+import (
+	"fmt"
+	"mini-parser/diag"
+	"mini-parser/msg"
+	"mini-parser/parser"
+	"mini-parser/token"
+	"strings"
+)
+
+// Relation 两个终结符之间的算符优先关系
+type Relation int
+
+const (
+	NoRelation Relation = iota
+	Less                // 左边的优先级低于右边, 右边先归约
+	Equal               // 在同一个句柄中相邻
+	Greater             // 左边的优先级高于右边, 左边先归约
+)
+
+func (r Relation) String() string {
+	switch r {
+	case Less:
+		return "⋖"
+	case Equal:
+		return "≐"
+	case Greater:
+		return "⋗"
+	}
+	return ""
+}
+
+// OPConflict 一对终结符之间有不止一种优先关系
+type OPConflict struct {
+	Left, Right string
+	Relations   []Relation
+}
+
+func (c OPConflict) String() string {
+	names := make([]string, len(c.Relations))
+	for i, r := range c.Relations {
+		names[i] = r.String()
+	}
+	return fmt.Sprintf("%s 与 %s 之间同时有 %s 关系", c.Left, c.Right, strings.Join(names, " "))
+}
+
+// OPTable 算符优先分析表, Relations[a][b] 为终结符a在左、b在右时的关系
+type OPTable struct {
+	Grammar   *Grammar
+	FirstVT   map[string]Set
+	LastVT    map[string]Set
+	Terminals []string // 文法的终结符和结束符
+	Relations map[string]map[string]Relation
+	Conflicts []OPConflict // 有冲突时表中保留先得到的关系
+}
+
+// BuildOP 计算 FIRSTVT/LASTVT 并构造优先关系表, 文法必须是算符文法:
+// 没有 ε 产生式, 右部也没有相邻的两个非终结符
+func BuildOP(g *Grammar) (*OPTable, error) {
+	for _, p := range g.Productions {
+		if len(p.Body) == 0 {
+			return nil, fmt.Errorf("第%d行: %s 是 ε 产生式, 不是算符文法", p.Line, p)
+		}
+		for i := 1; i < len(p.Body); i++ {
+			if !p.Body[i-1].Terminal && !p.Body[i].Terminal {
+				return nil, fmt.Errorf("第%d行: %s 中有相邻的非终结符, 不是算符文法", p.Line, p)
+			}
+		}
+	}
+
+	t := &OPTable{
+		Grammar:   g,
+		FirstVT:   map[string]Set{},
+		LastVT:    map[string]Set{},
+		Terminals: append(append([]string(nil), g.Terminals...), End),
+		Relations: map[string]map[string]Relation{},
+	}
+	for _, n := range g.Nonterminals {
+		t.FirstVT[n] = Set{}
+		t.LastVT[n] = Set{}
+	}
+	for _, x := range t.Terminals {
+		t.Relations[x] = map[string]Relation{}
+	}
+
+	// FIRSTVT(A): A → a… 或 A → B a… 中的a, 以及 A → B… 时 FIRSTVT(B) 中的终结符; LASTVT 与之对称
+	for changed := true; changed; {
+		changed = false
+		for _, p := range g.Productions {
+			first, last := p.Body[0], p.Body[len(p.Body)-1]
+			if t.FirstVT[p.Head].Add(vtOf(first, p.Body[1:], t.FirstVT)...) {
+				changed = true
+			}
+			if t.LastVT[p.Head].Add(vtOf(last, reversed(p.Body[:len(p.Body)-1]), t.LastVT)...) {
+				changed = true
+			}
+		}
+	}
+
+	for _, p := range g.Productions {
+		body := p.Body
+		for i := 0; i+1 < len(body); i++ {
+			x, y := body[i], body[i+1]
+			switch {
+			case x.Terminal && y.Terminal:
+				t.set(x.Name, y.Name, Equal)
+			case x.Terminal:
+				for _, b := range t.FirstVT[y.Name].Sorted() {
+					t.set(x.Name, b, Less)
+				}
+				if i+2 < len(body) && body[i+2].Terminal {
+					t.set(x.Name, body[i+2].Name, Equal)
+				}
+			default:
+				for _, a := range t.LastVT[x.Name].Sorted() {
+					t.set(a, y.Name, Greater)
+				}
+			}
+		}
+	}
+	// 把句子看作 # S #
+	for _, b := range t.FirstVT[g.Start].Sorted() {
+		t.set(End, b, Less)
+	}
+	for _, a := range t.LastVT[g.Start].Sorted() {
+		t.set(a, End, Greater)
+	}
+	return t, nil
+}
+
+// vtOf 右部以sym开头(rest为其后的符号)时贡献给 FIRSTVT 的终结符
+func vtOf(sym Symbol, rest []Symbol, vt map[string]Set) []string {
+	if sym.Terminal {
+		return []string{sym.Name}
+	}
+	out := vt[sym.Name].Sorted()
+	if len(rest) > 0 && rest[0].Terminal {
+		out = append(out, rest[0].Name)
+	}
+	return out
+}
+
+func reversed(body []Symbol) []Symbol {
+	out := make([]Symbol, len(body))
+	for i, s := range body {
+		out[len(body)-1-i] = s
+	}
+	return out
+}
+
+func (t *OPTable) set(a, b string, r Relation) {
+	old, ok := t.Relations[a][b]
+	if !ok {
+		t.Relations[a][b] = r
+		return
+	}
+	if old == r {
+		return
+	}
+	for i, c := range t.Conflicts {
+		if c.Left == a && c.Right == b {
+			for _, x := range c.Relations {
+				if x == r {
+					return
+				}
+			}
+			t.Conflicts[i].Relations = append(c.Relations, r)
+			return
+		}
+	}
+	t.Conflicts = append(t.Conflicts, OPConflict{a, b, []Relation{old, r}})
+}
+
+// VTString 按非终结符的顺序列出 FIRSTVT 和 LASTVT
+func (t *OPTable) VTString() string {
+	var out strings.Builder
+	for _, n := range t.Grammar.Nonterminals {
+		fmt.Fprintf(&out, "%s\n", n)
+		fmt.Fprintf(&out, "    FIRSTVT = %s\n", t.FirstVT[n])
+		fmt.Fprintf(&out, "    LASTVT  = %s\n", t.LastVT[n])
+	}
+	return out.String()
+}
+
+// MatrixString 优先关系矩阵, 行是左边的终结符, 列是右边的终结符
+func (t *OPTable) MatrixString() string {
+	width := 2
+	for _, x := range t.Terminals {
+		width = max(width, diag.Width(x))
+	}
+	cell := func(s string) string {
+		return s + strings.Repeat(" ", width-diag.Width(s)+1)
+	}
+
+	var out strings.Builder
+	row := func(first string, cells func(b string) string) {
+		line := cell(first)
+		for _, b := range t.Terminals {
+			line += cell(cells(b))
+		}
+		out.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	row("", func(b string) string { return b })
+	for _, a := range t.Terminals {
+		row(a, func(b string) string { return t.Relations[a][b].String() })
+	}
+	return out.String()
+}
+
+// CheckPrecedences 把表中双目运算符之间的关系与 parser.Precedence 对照:
+// 左边运算符的优先级不低于右边时应为 ⋗ (同级左结合), 否则为 ⋖。返回不一致之处。
+func (t *OPTable) CheckPrecedences() []string {
+	var ops []string
+	for _, x := range t.Grammar.Terminals {
+		if parser.IsBinary(token.TokenType(x)) {
+			ops = append(ops, x)
+		}
+	}
+
+	var out []string
+	for _, a := range ops {
+		for _, b := range ops {
+			pa, pb := parser.Precedence(token.TokenType(a)), parser.Precedence(token.TokenType(b))
+			want := Less
+			if pa >= pb {
+				want = Greater
+			}
+			if got := t.Relations[a][b]; got != want {
+				out = append(out, fmt.Sprintf("%s %s %s, 但 precedences 中 %s 为 %d, %s 为 %d, 应为 %s",
+					a, or(got.String(), "无关系"), b, a, pa, b, pb, want))
+			}
+		}
+	}
+	return out
+}
+
+func or(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+// opEntry 分析栈中的符号, 非终结符不区分名称
+type opEntry struct {
+	terminal string
+	tok      token.Token
+	value    interface{}
+}
+
+func (e opEntry) String() string {
+	if e.terminal == "" {
+		return "N"
+	}
+	return literal(e.tok)
+}
+
+// Parse 用优先关系表做算符优先分析, 每次归约最左素短语。
+// 归约时按终结符匹配产生式(非终结符只看位置), 用匹配到的产生式调用 reduce。
+func (t *OPTable) Parse(tokens []token.Token, reduce Reducer) (interface{}, []Step, error) {
+	stack := []opEntry{{terminal: End, tok: token.Token{Type: token.EOF}}}
+	var steps []Step
+	pos := 0
+
+	for {
+		tok := tokens[pos]
+		lookahead := TerminalOf(tok)
+		top := topTerminal(stack, len(stack))
+		step := Step{Stack: opStackString(stack), Input: inputString(tokens[pos:])}
+
+		if stack[top].terminal == End && lookahead == End && len(stack) == 2 {
+			step.Action = "接受"
+			return stack[1].value, append(steps, step), nil
+		}
+
+		rel := t.Relations[stack[top].terminal][lookahead]
+		switch rel {
+		case Less, Equal:
+			step.Action = fmt.Sprintf("%s %s %s, 移进", stack[top], rel, literal(tok))
+			stack = append(stack, opEntry{terminal: lookahead, tok: tok})
+			pos++
+
+		case Greater:
+			// 向下找到第一个 ⋖, 它右边到栈顶是最左素短语
+			head := top
+			for {
+				below := topTerminal(stack, head)
+				r := t.Relations[stack[below].terminal][stack[head].terminal]
+				if r == Less {
+					break
+				}
+				if r != Equal {
+					return nil, steps, syntaxError(stack[head].tok, msg.Get(msg.UnexpectedToken, literal(stack[head].tok)))
+				}
+				head = below
+			}
+			start := topTerminal(stack, head) + 1
+			phrase := stack[start:]
+
+			p, ok := t.match(phrase)
+			if !ok {
+				return nil, steps, syntaxError(tok, msg.Get(msg.UnexpectedToken, literal(tok)))
+			}
+			var value interface{}
+			if reduce != nil {
+				values := make([]interface{}, len(phrase))
+				for i, e := range phrase {
+					if e.terminal != "" {
+						values[i] = e.tok
+					} else {
+						values[i] = e.value
+					}
+				}
+				var err error
+				if value, err = reduce(p, values); err != nil {
+					return nil, steps, err
+				}
+			}
+			step.Action = fmt.Sprintf("%s %s %s, 用 %s 归约", stack[top], rel, literal(tok), p)
+			stack = append(stack[:start], opEntry{value: value})
+
+		default:
+			return nil, steps, syntaxError(tok, msg.Get(msg.UnexpectedToken, literal(tok)))
+		}
+		steps = append(steps, step)
+	}
+}
+
+// topTerminal 返回 stack[:n] 中最靠近栈顶的终结符的位置, 栈底的 # 总是终结符
+func topTerminal(stack []opEntry, n int) int {
+	i := n - 1
+	for stack[i].terminal == "" {
+		i--
+	}
+	return i
+}
+
+// match 找到终结符与素短语相同、非终结符位置也相同的产生式
+func (t *OPTable) match(phrase []opEntry) (Production, bool) {
+next:
+	for _, p := range t.Grammar.Productions {
+		if len(p.Body) != len(phrase) {
+			continue
+		}
+		for i, s := range p.Body {
+			if s.Terminal != (phrase[i].terminal != "") || s.Terminal && s.Name != phrase[i].terminal {
+				continue next
+			}
+		}
+		return p, true
+	}
+	return Production{}, false
+}
+
+func opStackString(stack []opEntry) string {
+	names := make([]string, len(stack))
+	for i, e := range stack {
+		names[i] = e.String()
+	}
+	return strings.Join(names, " ")
+}
+
+// ExprActions expr.bnf 的语义动作, 构造与 parser.ParseProgram 相同的赋值语句。
+// 算符优先分析不归约单非终结符产生式, 所以只需要按右部的形状区分。
+func ExprActions(p Production, v []interface{}) (interface{}, error) {
+	switch {
+	case len(v) == 1 && p.Body[0].Terminal:
+		// expr.bnf 中的标识符没有实参部分, 不能交给 Mini 文法的 factor
+		switch tok := v[0].(token.Token); tok.Type {
+		case token.IDENT:
+			return ident(tok), nil
+		case token.NUMBER, token.REAL, token.TRUE, token.FALSE:
+			return factor(v)
+		}
+	case len(v) == 3 && p.Body[0].Name == token.LPAREN && p.Body[2].Name == token.RPAREN:
+		return v[1], nil
+	case len(v) == 3 && p.Body[1].Name == token.ASSIGN:
+		name := v[0].(token.Token)
+		return &parser.AssignStatement{
+			Token: name,
+			Name:  &parser.Identifier{Token: name, Value: name.Literal},
+			Value: v[2].(parser.Expression),
+		}, nil
+	case len(v) == 3 && !p.Body[0].Terminal && !p.Body[2].Terminal:
+		op := v[1].(token.Token)
+		left, right := v[0].(parser.Expression), v[2].(parser.Expression)
+		return &parser.InfixExpression{Token: op, Left: left, Operator: op.Literal, Right: right}, nil
+	}
+	return nil, fmt.Errorf("expr.bnf 中没有产生式 %s 的语义动作", p)
+}
+
+// ParseAssign 用算符优先分析表分析一条赋值语句, 分析表须由 expr.bnf 生成
+func (t *OPTable) ParseAssign(src string) (*parser.AssignStatement, []Step, error) {
+	value, steps, err := t.Parse(Tokenize(src), ExprActions)
+	if err != nil {
+		return nil, steps, err
+	}
+	stmt, ok := value.(*parser.AssignStatement)
+	if !ok {
+		return nil, steps, fmt.Errorf("语义动作只适用于 expr.bnf 中的文法")
+	}
+	return stmt, steps, nil
+}
@@ -0,0 +1,184 @@
+package grammar_test
+
+import (
+	"mini-parser/grammar"
+	"mini-parser/parser"
+	"mini-parser/token"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 教材中的表达式文法
+const textbook = `
+<E> ::= <E> "+" <T> | <T>
+<T> ::= <T> "*" <F> | <F>
+<F> ::= "(" <E> ")" | 标识符
+`
+
+func buildOP(t *testing.T, src string) *grammar.OPTable {
+	t.Helper()
+	g, err := grammar.Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := grammar.BuildOP(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func TestVT(t *testing.T) {
+	table := buildOP(t, textbook)
+	tests := []struct {
+		name            string
+		firstVT, lastVT string
+	}{
+		{"<E>", "( * + 标识符", ") * + 标识符"},
+		{"<T>", "( * 标识符", ") * 标识符"},
+		{"<F>", "( 标识符", ") 标识符"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(table.FirstVT[tt.name].Sorted(), " "); got != tt.firstVT {
+			t.Errorf("FIRSTVT(%s) = {%s}, 期望 {%s}", tt.name, got, tt.firstVT)
+		}
+		if got := strings.Join(table.LastVT[tt.name].Sorted(), " "); got != tt.lastVT {
+			t.Errorf("LASTVT(%s) = {%s}, 期望 {%s}", tt.name, got, tt.lastVT)
+		}
+	}
+}
+
+func TestRelations(t *testing.T) {
+	table := buildOP(t, textbook)
+	if len(table.Conflicts) > 0 {
+		t.Fatalf("冲突: %v", table.Conflicts)
+	}
+	tests := []struct {
+		left, right string
+		want        grammar.Relation
+	}{
+		{"+", "+", grammar.Greater}, // 同级左结合
+		{"+", "*", grammar.Less},
+		{"*", "+", grammar.Greater},
+		{"*", "*", grammar.Greater},
+		{"(", ")", grammar.Equal},
+		{"(", "+", grammar.Less},
+		{")", "*", grammar.Greater},
+		{"标识符", "+", grammar.Greater},
+		{grammar.End, "标识符", grammar.Less},
+		{"+", grammar.End, grammar.Greater},
+		{"标识符", "标识符", grammar.NoRelation},
+		{")", "(", grammar.NoRelation},
+	}
+	for _, tt := range tests {
+		if got := table.Relations[tt.left][tt.right]; got != tt.want {
+			t.Errorf("%s 与 %s 的关系为 %q, 期望 %q", tt.left, tt.right, got, tt.want)
+		}
+	}
+}
+
+func TestOPErrors(t *testing.T) {
+	for _, src := range []string{
+		"<E> ::= <E> \"+\" <E> | ",     // ε 产生式
+		"<E> ::= <E> <T>\n<T> ::= 标识符", // 相邻的非终结符
+	} {
+		g, err := grammar.Parse(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := grammar.BuildOP(g); err == nil || !strings.Contains(err.Error(), "不是算符文法") {
+			t.Errorf("%q: 期望报告不是算符文法, 实际为 %v", src, err)
+		}
+	}
+
+	// 二义的文法中 + 与 + 之间同时有 ⋖ 和 ⋗
+	table := buildOP(t, `<E> ::= <E> "+" <E> | 标识符`)
+	if len(table.Conflicts) != 1 || table.Conflicts[0].Left != "+" || table.Conflicts[0].Right != "+" {
+		t.Errorf("冲突: %v", table.Conflicts)
+	}
+}
+
+func loadExpr(t *testing.T) *grammar.OPTable {
+	t.Helper()
+	g, err := grammar.Load(filepath.Join("..", "expr.bnf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := grammar.BuildOP(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+// expr.bnf 的优先关系与 parser 的优先级表一致, 分析结果与 parser.ParseProgram 相同
+func TestParseAssign(t *testing.T) {
+	table := loadExpr(t)
+	if len(table.Conflicts) > 0 {
+		t.Fatalf("冲突: %v", table.Conflicts)
+	}
+	if diffs := table.CheckPrecedences(); len(diffs) > 0 {
+		t.Errorf("与 parser.Precedence 不一致:\n%s", strings.Join(diffs, "\n"))
+	}
+
+	for _, src := range []string{
+		"i := 1 + 2 * 3",
+		"x := (a + b) * c - d / 2 % 3",
+		"b := a < b = c > d",
+		"b := x && y || z = true",
+		"r := 1.5 - (2 - 3) - 4",
+		"b := a <= b != (c >= 1.0)",
+	} {
+		stmt, _, err := table.ParseAssign(src)
+		if err != nil {
+			t.Errorf("%s: %v", src, err)
+			continue
+		}
+		p := parser.New(token.New(src))
+		program := p.ParseProgram()
+		if len(p.Errors()) > 0 {
+			t.Fatalf("%s: %v", src, p.Errors())
+		}
+		if got, want := parser.Tree(stmt), parser.Tree(program.Statements[0]); got != want {
+			t.Errorf("%s: 算符优先分析的语法树\n%s\n期望\n%s", src, got, want)
+		}
+	}
+}
+
+// 每次归约最左素短语: 1、2、3 先归约, 然后是 *、+, 最后是赋值
+func TestTrace(t *testing.T) {
+	_, steps, err := loadExpr(t).ParseAssign("i:=1+2*3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reduced []string
+	for _, s := range steps {
+		if _, p, ok := strings.Cut(s.Action, "用 "); ok {
+			reduced = append(reduced, strings.TrimSuffix(p, " 归约"))
+		}
+	}
+	want := []string{
+		"<因子> → 整数",
+		"<因子> → 整数",
+		"<因子> → 整数",
+		"<项> → <项> * <因子>",
+		"<算术表达式> → <算术表达式> + <项>",
+		"<赋值语句> → 标识符 := <表达式>",
+	}
+	if strings.Join(reduced, "\n") != strings.Join(want, "\n") {
+		t.Errorf("归约序列\n%s\n期望\n%s", strings.Join(reduced, "\n"), strings.Join(want, "\n"))
+	}
+	if last := steps[len(steps)-1]; last.Action != "接受" {
+		t.Errorf("最后一步为 %q", last.Action)
+	}
+}
+
+func TestOPSyntaxErrors(t *testing.T) {
+	table := loadExpr(t)
+	for _, src := range []string{"x := 1 + * 2", "x := (1 + 2", "x := 1 2", "x := )"} {
+		if _, _, err := table.ParseAssign(src); err == nil {
+			t.Errorf("%s: 期望语法错误", src)
+		}
+	}
+}
@@ -0,0 +1,412 @@
+package grammar
+
+import (
+	"fmt"
+	"strings"
+)
+
+// rules 按非终结符分组的产生式, order 为输出时非终结符的顺序
+type rules struct {
+	start string
+	order []string
+	alts  map[string][]Production
+}
+
+func rulesOf(g *Grammar) *rules {
+	r := &rules{start: g.Start, alts: map[string][]Production{}}
+	for _, head := range g.Nonterminals {
+		r.order = append(r.order, head)
+		r.alts[head] = g.Alternatives(head)
+	}
+	return r
+}
+
+// grammar 按非终结符的顺序重新排列产生式, 终结符按第一次出现的顺序
+func (r *rules) grammar() *Grammar {
+	g := &Grammar{Start: r.start}
+	for _, head := range r.order {
+		g.Nonterminals = append(g.Nonterminals, head)
+		for _, p := range r.alts[head] {
+			for _, s := range p.Body {
+				if s.Terminal && !contains(g.Terminals, s.Name) {
+					g.Terminals = append(g.Terminals, s.Name)
+				}
+			}
+			g.Productions = append(g.Productions, p)
+		}
+	}
+	return g
+}
+
+// fresh 在after之后加入一个新的非终结符, 名称是在after后面加 ' 直到不重名, 如 <A'>
+func (r *rules) fresh(after string) string {
+	name := after
+	for {
+		name = strings.TrimSuffix(name, ">") + "'>"
+		if _, ok := r.alts[name]; !ok {
+			break
+		}
+	}
+	for i, head := range r.order {
+		if head == after {
+			r.order = append(r.order[:i+1], append([]string{name}, r.order[i+1:]...)...)
+			break
+		}
+	}
+	r.alts[name] = nil
+	return name
+}
+
+// LeftRecursive 返回能推导出以自身开头的句型的非终结符, 考虑可空的前缀
+func LeftRecursive(g *Grammar) []string {
+	a := Analyze(g)
+	left := map[string]Set{} // left[A] 为 A 推导出的句型中可能位于最左边的非终结符
+	for _, n := range g.Nonterminals {
+		left[n] = Set{}
+	}
+	for _, p := range g.Productions {
+		for _, s := range p.Body {
+			if s.Terminal {
+				break
+			}
+			left[p.Head].Add(s.Name)
+			if !a.Nullable[s.Name] {
+				break
+			}
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, n := range g.Nonterminals {
+			for _, m := range left[n].Sorted() {
+				if left[n].Add(left[m].Sorted()...) {
+					changed = true
+				}
+			}
+		}
+	}
+
+	var out []string
+	for _, n := range g.Nonterminals {
+		if left[n][n] {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// RemoveLeftRecursion 消除直接和间接左递归。按非终结符的顺序, 把 Ai → Aj γ (j<i 且 Aj 能
+// 以 Ai 开头) 中的 Aj 代入它的候选式, 再把直接左递归 A → A α | β 改写为
+// A → β A', A' → α A' | ε。可空前缀造成的左递归无法这样消除, 此时返回错误。
+func RemoveLeftRecursion(g *Grammar) (*Grammar, error) {
+	r := rulesOf(g)
+	for _, p := range g.Productions {
+		if len(p.Body) == 1 && !p.Body[0].Terminal && r.unitReaches(p.Body[0].Name, p.Head) {
+			return nil, fmt.Errorf("第%d行: %s 使文法有环 (%s ⇒+ %s), 无法消除左递归", p.Line, p, p.Head, p.Head)
+		}
+	}
+
+	original := append([]string(nil), r.order...)
+	for i, ai := range original {
+		for changed := true; changed; {
+			changed = false
+			var alts []Production
+			for _, p := range r.alts[ai] {
+				j := index(original[:i], p.Body)
+				if j < 0 || !r.leftReaches(original[j], ai) {
+					alts = append(alts, p)
+					continue
+				}
+				for _, q := range r.alts[original[j]] {
+					body := append(append([]Symbol(nil), q.Body...), p.Body[1:]...)
+					alts = append(alts, Production{Head: ai, Body: body, Line: p.Line})
+				}
+				changed = true
+			}
+			r.alts[ai] = alts
+		}
+		r.removeDirect(ai)
+	}
+
+	out := r.grammar()
+	if rec := LeftRecursive(out); len(rec) > 0 {
+		return nil, fmt.Errorf("%s 经可空的前缀仍是左递归的, 需要先消除 ε 产生式", strings.Join(rec, ", "))
+	}
+	return out, nil
+}
+
+// index 右部以 heads 中的哪个非终结符开头, 都不是时返回 -1
+func index(heads []string, body []Symbol) int {
+	if len(body) == 0 || body[0].Terminal {
+		return -1
+	}
+	for i, h := range heads {
+		if h == body[0].Name {
+			return i
+		}
+	}
+	return -1
+}
+
+// leftReaches from 是否能推导出以 to 开头的句型(只看右部的第一个符号)
+func (r *rules) leftReaches(from, to string) bool {
+	seen := map[string]bool{}
+	var visit func(n string) bool
+	visit = func(n string) bool {
+		if n == to {
+			return true
+		}
+		if seen[n] {
+			return false
+		}
+		seen[n] = true
+		for _, p := range r.alts[n] {
+			if len(p.Body) > 0 && !p.Body[0].Terminal && visit(p.Body[0].Name) {
+				return true
+			}
+		}
+		return false
+	}
+	return visit(from)
+}
+
+// unitReaches 是否能只用单非终结符产生式 A → B 从from推导出to
+func (r *rules) unitReaches(from, to string) bool {
+	seen := map[string]bool{}
+	var visit func(n string) bool
+	visit = func(n string) bool {
+		if n == to {
+			return true
+		}
+		if seen[n] {
+			return false
+		}
+		seen[n] = true
+		for _, p := range r.alts[n] {
+			if len(p.Body) == 1 && !p.Body[0].Terminal && visit(p.Body[0].Name) {
+				return true
+			}
+		}
+		return false
+	}
+	return visit(from)
+}
+
+func (r *rules) removeDirect(head string) {
+	var recursive, others []Production
+	for _, p := range r.alts[head] {
+		if len(p.Body) > 0 && !p.Body[0].Terminal && p.Body[0].Name == head {
+			recursive = append(recursive, p)
+		} else {
+			others = append(others, p)
+		}
+	}
+	if len(recursive) == 0 {
+		return
+	}
+
+	tail := r.fresh(head)
+	rest := Symbol{Name: tail}
+	var alts []Production
+	for _, p := range others {
+		alts = append(alts, Production{Head: head, Body: append(append([]Symbol(nil), p.Body...), rest), Line: p.Line})
+	}
+	r.alts[head] = alts
+	for _, p := range recursive {
+		body := append(append([]Symbol(nil), p.Body[1:]...), rest)
+		r.alts[tail] = append(r.alts[tail], Production{Head: tail, Body: body, Line: p.Line})
+	}
+	r.alts[tail] = append(r.alts[tail], Production{Head: tail, Line: recursive[0].Line})
+}
+
+// LeftFactor 提取左公因子: 把有相同前缀 α 的候选式 A → α β1 | α β2 改写为
+// A → α A', A' → β1 | β2, 直到同一非终结符的候选式不再以相同的符号开头
+func LeftFactor(g *Grammar) *Grammar {
+	r := rulesOf(g)
+	for i := 0; i < len(r.order); i++ {
+		head := r.order[i]
+		for r.factor(head) {
+		}
+	}
+	return r.grammar()
+}
+
+// factor 提取head的一组左公因子, 返回是否做了改写
+func (r *rules) factor(head string) bool {
+	alts := r.alts[head]
+	for i, p := range alts {
+		if len(p.Body) == 0 {
+			continue
+		}
+		group := []int{i}
+		for j := i + 1; j < len(alts); j++ {
+			if len(alts[j].Body) > 0 && alts[j].Body[0] == p.Body[0] {
+				group = append(group, j)
+			}
+		}
+		if len(group) == 1 {
+			continue
+		}
+
+		n := len(p.Body)
+		for _, j := range group[1:] {
+			n = min(n, commonPrefix(p.Body, alts[j].Body))
+		}
+		prefix := append([]Symbol(nil), p.Body[:n]...)
+		tail := r.fresh(head)
+		for _, j := range group {
+			q := alts[j]
+			r.alts[tail] = append(r.alts[tail], Production{Head: tail, Body: q.Body[n:], Line: q.Line})
+		}
+
+		var out []Production
+		for j, q := range alts {
+			switch {
+			case j == i:
+				out = append(out, Production{Head: head, Body: append(prefix, Symbol{Name: tail}), Line: p.Line})
+			case !containsInt(group, j):
+				out = append(out, q)
+			}
+		}
+		r.alts[head] = out
+		return true
+	}
+	return false
+}
+
+func commonPrefix(a, b []Symbol) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+func containsInt(list []int, x int) bool {
+	for _, y := range list {
+		if y == x {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveUseless 先删除不能推导出终结符串的非终结符及用到它们的产生式,
+// 再删除从开始符号不可达的符号
+func RemoveUseless(g *Grammar) (*Grammar, error) {
+	generating := Set{}
+	for changed := true; changed; {
+		changed = false
+		for _, p := range g.Productions {
+			if !generating[p.Head] && allGenerating(p.Body, generating) {
+				generating.Add(p.Head)
+				changed = true
+			}
+		}
+	}
+	if !generating[g.Start] {
+		return nil, fmt.Errorf("开始符号 %s 不能推导出终结符串", g.Start)
+	}
+
+	r := rulesOf(g)
+	for _, head := range r.order {
+		var alts []Production
+		for _, p := range r.alts[head] {
+			if generating[head] && allGenerating(p.Body, generating) {
+				alts = append(alts, p)
+			}
+		}
+		r.alts[head] = alts
+	}
+
+	reachable := Set{g.Start: true}
+	for work := []string{g.Start}; len(work) > 0; {
+		n := work[len(work)-1]
+		work = work[:len(work)-1]
+		for _, p := range r.alts[n] {
+			for _, s := range p.Body {
+				if !s.Terminal && reachable.Add(s.Name) {
+					work = append(work, s.Name)
+				}
+			}
+		}
+	}
+
+	var order []string
+	for _, head := range r.order {
+		if reachable[head] && generating[head] {
+			order = append(order, head)
+		}
+	}
+	r.order = order
+	return r.grammar(), nil
+}
+
+func allGenerating(body []Symbol, generating Set) bool {
+	for _, s := range body {
+		if !s.Terminal && !generating[s.Name] {
+			return false
+		}
+	}
+	return true
+}
+
+// Transform 按名称依次执行变换: left 消除左递归, factor 提取左公因子, useless 删除无用符号
+func Transform(g *Grammar, steps []string) (*Grammar, error) {
+	for _, step := range steps {
+		var err error
+		switch strings.TrimSpace(step) {
+		case "left":
+			g, err = RemoveLeftRecursion(g)
+		case "factor":
+			g = LeftFactor(g)
+		case "useless":
+			g, err = RemoveUseless(g)
+		default:
+			return nil, fmt.Errorf("未知的文法变换: %s (可用 left, factor, useless)", step)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// Diff 按行比较两个文法的BNF文本, 删除的行以 - 开头, 新增的行以 + 开头
+func Diff(before, after *Grammar) string {
+	a := strings.Split(strings.TrimSuffix(before.String(), "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(after.String(), "\n"), "\n")
+
+	// lcs[i][j] 为 a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			out.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			out.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,163 @@
+package grammar_test
+
+import (
+	"mini-parser/grammar"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func parseGrammar(t *testing.T, src string) *grammar.Grammar {
+	t.Helper()
+	g, err := grammar.Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestRemoveLeftRecursion(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"direct", textbook, `<E> ::= <T> <E'>
+<E'> ::= "+" <T> <E'>
+       | ε
+<T> ::= <F> <T'>
+<T'> ::= "*" <F> <T'>
+       | ε
+<F> ::= "(" <E> ")"
+      | 标识符
+`},
+		// S ⇒ A a ⇒ S d a: 先把 S 的候选式代入 A → S d, 再消除 A 的直接左递归
+		{"indirect", `<S> ::= <A> "a" | "b"
+<A> ::= <A> "c" | <S> "d" | "e"`, `<S> ::= <A> "a"
+      | "b"
+<A> ::= "b" "d" <A'>
+      | "e" <A'>
+<A'> ::= "c" <A'>
+       | "a" "d" <A'>
+       | ε
+`},
+		// 没有左递归的文法不变
+		{"none", `<S> ::= "a" <S> | "b"`, `<S> ::= "a" <S>
+      | "b"
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := grammar.RemoveLeftRecursion(parseGrammar(t, tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("变换结果\n%s\n期望\n%s", got, tt.want)
+			}
+			if rec := grammar.LeftRecursive(out); len(rec) > 0 {
+				t.Errorf("变换后仍是左递归的: %v", rec)
+			}
+		})
+	}
+}
+
+func TestRemoveLeftRecursionErrors(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		// A ⇒ B ⇒ A 构成环
+		{"cycle", `<A> ::= <B> | "a"
+<B> ::= <A> | "b"`, "使文法有环"},
+		// A ⇒ B A x ⇒ A x, B 可空, 代入后仍是左递归的
+		{"nullable prefix", `<A> ::= <B> <A> "x" | "y"
+<B> ::= "z" | ε`, "经可空的前缀仍是左递归的"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := grammar.RemoveLeftRecursion(parseGrammar(t, tt.src))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("期望报告 %q, 实际为 %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLeftFactor(t *testing.T) {
+	g := parseGrammar(t, `<S> ::= "if" <E> "then" <S> | "if" <E> "then" <S> "else" <S> | "a"
+<E> ::= "b"`)
+	want := `<S> ::= "if" <E> "then" <S> <S'>
+      | "a"
+<S'> ::= ε
+       | "else" <S>
+<E> ::= "b"
+`
+	if got := grammar.LeftFactor(g).String(); got != want {
+		t.Errorf("变换结果\n%s\n期望\n%s", got, want)
+	}
+}
+
+func TestRemoveUseless(t *testing.T) {
+	// B 不能推导出终结符串, 删除 S → A B 之后 A 不可达; C 从开始符号不可达
+	g := parseGrammar(t, `<S> ::= <A> <B> | "a"
+<A> ::= "a"
+<B> ::= <B> "b"
+<C> ::= "c"`)
+	out, err := grammar.RemoveUseless(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "<S> ::= \"a\"\n"; got != want {
+		t.Errorf("变换结果\n%s\n期望\n%s", got, want)
+	}
+
+	_, err = grammar.RemoveUseless(parseGrammar(t, `<S> ::= <S> "a"`))
+	if err == nil || !strings.Contains(err.Error(), "不能推导出终结符串") {
+		t.Errorf("开始符号无用时应报告错误, 实际为 %v", err)
+	}
+}
+
+// 消除左递归后的 expr.bnf 是 LL(1) 文法, 可以直接交给预测分析表
+func TestTransformExpr(t *testing.T) {
+	g, err := grammar.Load(filepath.Join("..", "expr.bnf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := grammar.Transform(g, []string{"useless", "left", "factor"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conflicts := grammar.Analyze(out).LL1Conflicts(); len(conflicts) > 0 {
+		t.Errorf("变换后的文法有 LL(1) 冲突: %v\n%s", conflicts, out)
+	}
+	// 输出的BNF可以再读入
+	again, err := grammar.Parse(out.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != out.String() {
+		t.Errorf("重新读入的文法\n%s\n变换结果\n%s", again, out)
+	}
+
+	if _, err := grammar.Transform(g, []string{"right"}); err == nil {
+		t.Error("未知的变换应报告错误")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	before := parseGrammar(t, `<S> ::= <S> "a" | "b"`)
+	after, err := grammar.RemoveLeftRecursion(before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `- <S> ::= <S> "a"
+-       | "b"
++ <S> ::= "b" <S'>
++ <S'> ::= "a" <S'>
++        | ε
+`
+	if got := grammar.Diff(before, after); got != want {
+		t.Errorf("差异\n%s\n期望\n%s", got, want)
+	}
+	if got := grammar.Diff(before, before); strings.Contains(got, "+") || strings.Contains(got, "- ") {
+		t.Errorf("相同文法的差异\n%s", got)
+	}
+}
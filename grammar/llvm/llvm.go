@@ -0,0 +1,297 @@
+package llvm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mini-parser/msg"
+	"mini-parser/parser"
+	"mini-parser/types"
+	"slices"
+)
+
+// Generate 把Mini程序翻译为LLVM IR文本: 每个变量一个alloca, 通过load/store访问,
+// 条件和循环用icmp/br表达, 不使用phi。main函数以变量result的值作为返回值
+func Generate(program *parser.Program, result string) (string, error) {
+	if err := types.CheckIntegral(program, "LLVM"); err != nil {
+		return "", err
+	}
+	g := &generator{vars: parser.Variables(program)}
+	if result != "" && !slices.Contains(g.vars, result) {
+		return "", errors.New(msg.Get(msg.NoSuchVariable, result))
+	}
+
+	for _, s := range program.Statements {
+		if err := g.statement(s); err != nil {
+			return "", err
+		}
+	}
+	value := "0"
+	if result != "" {
+		value = g.load(result)
+	}
+	ret := g.temp()
+	g.emit("%s = trunc i64 %s to i32", ret, value)
+	g.emit("ret i32 %s", ret)
+
+	var out bytes.Buffer
+	name := "mini"
+	if program.Name != nil {
+		name = program.Name.Value
+	}
+	fmt.Fprintf(&out, "; ModuleID = '%s'\n", name)
+	fmt.Fprintf(&out, "source_filename = \"%s.mini\"\n\n", name)
+	out.WriteString("define i32 @main() {\n")
+	out.WriteString("entry:\n")
+	// alloca集中放在入口块, 便于mem2reg提升为寄存器
+	for _, v := range g.vars {
+		fmt.Fprintf(&out, "  %s = alloca i64\n", address(v))
+	}
+	for _, t := range g.temps {
+		fmt.Fprintf(&out, "  %s = alloca i64\n", t)
+	}
+	for _, v := range g.vars {
+		fmt.Fprintf(&out, "  store i64 0, ptr %s\n", address(v))
+	}
+	out.Write(g.body.Bytes())
+	out.WriteString("}\n")
+
+	return out.String(), nil
+}
+
+type generator struct {
+	body   bytes.Buffer
+	vars   []string
+	temps  []string // 短路求值结果使用的临时alloca
+	values int
+	labels int
+}
+
+func address(name string) string {
+	return "%" + name + ".addr"
+}
+
+func (g *generator) emit(format string, args ...interface{}) {
+	g.body.WriteString("  ")
+	fmt.Fprintf(&g.body, format, args...)
+	g.body.WriteString("\n")
+}
+
+func (g *generator) label(name string) {
+	g.body.WriteString(name + ":\n")
+}
+
+func (g *generator) temp() string {
+	g.values++
+	return fmt.Sprintf("%%t%d", g.values)
+}
+
+func (g *generator) newLabels(names ...string) []string {
+	g.labels++
+	out := make([]string, len(names))
+	for i, n := range names {
+		out[i] = fmt.Sprintf("%s%d", n, g.labels)
+	}
+	return out
+}
+
+func (g *generator) load(name string) string {
+	t := g.temp()
+	g.emit("%s = load i64, ptr %s", t, address(name))
+	return t
+}
+
+func (g *generator) statement(stmt parser.Statement) error {
+	switch s := stmt.(type) {
+	case *parser.AssignStatement:
+		value, err := g.expression(s.Value)
+		if err != nil {
+			return err
+		}
+		g.emit("store i64 %s, ptr %s", value, address(s.Name.Value))
+
+	case *parser.BlockStatement:
+		for _, inner := range s.Statements {
+			if err := g.statement(inner); err != nil {
+				return err
+			}
+		}
+
+	case *parser.IfExpression:
+		l := g.newLabels("if.then", "if.else", "if.end")
+		cond, err := g.condition(s.Condition)
+		if err != nil {
+			return err
+		}
+		elseLabel := l[1]
+		if s.Alternative == nil {
+			elseLabel = l[2]
+		}
+		g.emit("br i1 %s, label %%%s, label %%%s", cond, l[0], elseLabel)
+
+		g.label(l[0])
+		if err := g.statement(s.Consequence); err != nil {
+			return err
+		}
+		g.emit("br label %%%s", l[2])
+
+		if s.Alternative != nil {
+			g.label(l[1])
+			if err := g.statement(s.Alternative); err != nil {
+				return err
+			}
+			g.emit("br label %%%s", l[2])
+		}
+		g.label(l[2])
+
+	case *parser.WhileExpression:
+		l := g.newLabels("while.cond", "while.body", "while.end")
+		g.emit("br label %%%s", l[0])
+
+		g.label(l[0])
+		cond, err := g.condition(s.Condition)
+		if err != nil {
+			return err
+		}
+		g.emit("br i1 %s, label %%%s, label %%%s", cond, l[1], l[2])
+
+		g.label(l[1])
+		if err := g.statement(s.Body); err != nil {
+			return err
+		}
+		g.emit("br label %%%s", l[0])
+		g.label(l[2])
+
+	default:
+		return errors.New(msg.Get(msg.UnsupportedStatement, "LLVM", stmt))
+	}
+	return nil
+}
+
+// condition 计算条件并转换为br需要的i1
+func (g *generator) condition(expr parser.Expression) (string, error) {
+	value, err := g.expression(expr)
+	if err != nil {
+		return "", err
+	}
+	t := g.temp()
+	g.emit("%s = icmp ne i64 %s, 0", t, value)
+	return t, nil
+}
+
+var arithmetic = map[string]string{
+	"+": "add",
+	"-": "sub",
+	"*": "mul",
+	"/": "sdiv",
+	"%": "srem",
+}
+
+var predicates = map[string]string{
+	"=":  "eq",
+	"!=": "ne",
+	"<":  "slt",
+	">":  "sgt",
+	"<=": "sle",
+	">=": "sge",
+}
+
+// expression 生成计算表达式的指令, 返回保存结果的i64值, 布尔值用0和1表示
+func (g *generator) expression(expr parser.Expression) (string, error) {
+	switch e := expr.(type) {
+	case *parser.IntegerLiteral:
+		return fmt.Sprintf("%d", e.Value), nil
+
+	case *parser.Boolean:
+		if e.Value {
+			return "1", nil
+		}
+		return "0", nil
+
+	case *parser.Identifier:
+		return g.load(e.Value), nil
+
+	case *parser.PrefixExpression:
+		right, err := g.expression(e.Right)
+		if err != nil {
+			return "", err
+		}
+		t := g.temp()
+		if e.Operator == "-" {
+			g.emit("%s = sub i64 0, %s", t, right)
+		} else {
+			g.emit("%s = xor i64 %s, 1", t, right)
+		}
+		return t, nil
+
+	case *parser.InfixExpression:
+		if e.Operator == "&&" || e.Operator == "||" {
+			return g.logical(e)
+		}
+
+		left, err := g.expression(e.Left)
+		if err != nil {
+			return "", err
+		}
+		right, err := g.expression(e.Right)
+		if err != nil {
+			return "", err
+		}
+
+		if op, ok := arithmetic[e.Operator]; ok {
+			t := g.temp()
+			g.emit("%s = %s i64 %s, %s", t, op, left, right)
+			return t, nil
+		}
+		pred, ok := predicates[e.Operator]
+		if !ok {
+			return "", errors.New(msg.Get(msg.UnsupportedOperator, "LLVM", e.Operator))
+		}
+		cmp := g.temp()
+		g.emit("%s = icmp %s i64 %s, %s", cmp, pred, left, right)
+		t := g.temp()
+		g.emit("%s = zext i1 %s to i64", t, cmp)
+		return t, nil
+
+	case *parser.RealLiteral:
+		return "", errors.New(msg.Get(msg.Location, e.Token.Line, e.Token.Column, msg.Get(msg.RealUnsupported, "LLVM")))
+	}
+	return "", errors.New(msg.Get(msg.UnsupportedExpression, "LLVM", expr))
+}
+
+// logical 用分支和临时alloca实现 && 和 || 的短路求值
+func (g *generator) logical(e *parser.InfixExpression) (string, error) {
+	l := g.newLabels("logic.rhs", "logic.end")
+	slot := fmt.Sprintf("%%logic%d", g.labels)
+	g.temps = append(g.temps, slot)
+
+	// && 左侧为假时结果为0, || 左侧为真时结果为1
+	short := "0"
+	if e.Operator == "||" {
+		short = "1"
+	}
+	g.emit("store i64 %s, ptr %s", short, slot)
+
+	cond, err := g.condition(e.Left)
+	if err != nil {
+		return "", err
+	}
+	if e.Operator == "&&" {
+		g.emit("br i1 %s, label %%%s, label %%%s", cond, l[0], l[1])
+	} else {
+		g.emit("br i1 %s, label %%%s, label %%%s", cond, l[1], l[0])
+	}
+
+	g.label(l[0])
+	right, err := g.expression(e.Right)
+	if err != nil {
+		return "", err
+	}
+	g.emit("store i64 %s, ptr %s", right, slot)
+	g.emit("br label %%%s", l[1])
+
+	g.label(l[1])
+	t := g.temp()
+	g.emit("%s = load i64, ptr %s", t, slot)
+	return t, nil
+}
@@ -0,0 +1,70 @@
+package llvm
+
+import (
+	"flag"
+	"mini-parser/parser"
+	"mini-parser/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "用当前的输出更新 testdata 中的期望文件")
+
+// 示例程序在上一级目录, 期望的 IR 在 testdata/<名称>.ll
+var samples = []string{"test_correct", "test_complex"}
+
+func TestGolden(t *testing.T) {
+	for _, name := range samples {
+		t.Run(name, func(t *testing.T) {
+			src, err := os.ReadFile(filepath.Join("..", name+".mini"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			p := parser.New(token.New(string(src)))
+			program := p.ParseProgram()
+			if len(p.Errors()) > 0 {
+				t.Fatalf("语法错误: %v", p.Errors())
+			}
+			got, err := Generate(program, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", name+".ll")
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("Generate 的输出与 %s 不同, 确认无误后用 go test -update 更新\n%s", golden, got)
+			}
+		})
+	}
+}
+
+// TestVerify 用 opt 检查期望文件是合法的 IR, 没有安装 LLVM 时跳过
+func TestVerify(t *testing.T) {
+	opt, err := exec.LookPath("opt")
+	if err != nil {
+		t.Skip("没有找到 opt 命令")
+	}
+	for _, name := range samples {
+		golden := filepath.Join("testdata", name+".ll")
+		out, err := exec.Command(opt, "-passes=verify", "-disable-output", golden).CombinedOutput()
+		if err != nil {
+			// LLVM 15 之前需要显式打开不透明指针
+			out, err = exec.Command(opt, "-opaque-pointers", "-passes=verify", "-disable-output", golden).CombinedOutput()
+		}
+		if err != nil {
+			t.Errorf("%s 没有通过 opt 检查: %v\n%s", golden, err, out)
+		}
+	}
+}
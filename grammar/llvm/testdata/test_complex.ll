@@ -0,0 +1,105 @@
+; ModuleID = 'complex'
+source_filename = "complex.mini"
+
+define i32 @main() {
+entry:
+  %mode.addr = alloca i64
+  %counter.addr = alloca i64
+  %total.addr = alloca i64
+  %result.addr = alloca i64
+  %a.addr = alloca i64
+  %b.addr = alloca i64
+  %c.addr = alloca i64
+  %d.addr = alloca i64
+  %e.addr = alloca i64
+  %f.addr = alloca i64
+  %init.addr = alloca i64
+  %step.addr = alloca i64
+  %limit.addr = alloca i64
+  store i64 0, ptr %mode.addr
+  store i64 0, ptr %counter.addr
+  store i64 0, ptr %total.addr
+  store i64 0, ptr %result.addr
+  store i64 0, ptr %a.addr
+  store i64 0, ptr %b.addr
+  store i64 0, ptr %c.addr
+  store i64 0, ptr %d.addr
+  store i64 0, ptr %e.addr
+  store i64 0, ptr %f.addr
+  store i64 0, ptr %init.addr
+  store i64 0, ptr %step.addr
+  store i64 0, ptr %limit.addr
+  %t1 = load i64, ptr %mode.addr
+  %t2 = icmp eq i64 %t1, 1
+  %t3 = zext i1 %t2 to i64
+  %t4 = icmp ne i64 %t3, 0
+  br i1 %t4, label %if.then1, label %if.end1
+if.then1:
+  store i64 0, ptr %counter.addr
+  br label %while.cond2
+while.cond2:
+  %t5 = load i64, ptr %counter.addr
+  %t6 = icmp slt i64 %t5, 10
+  %t7 = zext i1 %t6 to i64
+  %t8 = icmp ne i64 %t7, 0
+  br i1 %t8, label %while.body2, label %while.end2
+while.body2:
+  %t9 = load i64, ptr %counter.addr
+  %t10 = srem i64 %t9, 2
+  %t11 = icmp eq i64 %t10, 0
+  %t12 = zext i1 %t11 to i64
+  %t13 = icmp ne i64 %t12, 0
+  br i1 %t13, label %if.then3, label %if.else3
+if.then3:
+  %t14 = load i64, ptr %total.addr
+  %t15 = load i64, ptr %counter.addr
+  %t16 = add i64 %t14, %t15
+  store i64 %t16, ptr %total.addr
+  br label %if.end3
+if.else3:
+  %t17 = load i64, ptr %total.addr
+  %t18 = load i64, ptr %counter.addr
+  %t19 = sub i64 %t17, %t18
+  store i64 %t19, ptr %total.addr
+  br label %if.end3
+if.end3:
+  %t20 = load i64, ptr %counter.addr
+  %t21 = add i64 %t20, 1
+  store i64 %t21, ptr %counter.addr
+  br label %while.cond2
+while.end2:
+  br label %if.end1
+if.end1:
+  %t22 = load i64, ptr %a.addr
+  %t23 = load i64, ptr %b.addr
+  %t24 = add i64 %t22, %t23
+  %t25 = load i64, ptr %c.addr
+  %t26 = load i64, ptr %d.addr
+  %t27 = sub i64 %t25, %t26
+  %t28 = mul i64 %t24, %t27
+  %t29 = load i64, ptr %e.addr
+  %t30 = load i64, ptr %f.addr
+  %t31 = srem i64 %t29, %t30
+  %t32 = sdiv i64 %t28, %t31
+  store i64 %t32, ptr %result.addr
+  store i64 0, ptr %init.addr
+  store i64 2, ptr %step.addr
+  store i64 100, ptr %limit.addr
+  br label %while.cond4
+while.cond4:
+  %t33 = load i64, ptr %init.addr
+  %t34 = load i64, ptr %limit.addr
+  %t35 = icmp slt i64 %t33, %t34
+  %t36 = zext i1 %t35 to i64
+  %t37 = icmp ne i64 %t36, 0
+  br i1 %t37, label %while.body4, label %while.end4
+while.body4:
+  %t38 = load i64, ptr %init.addr
+  %t39 = load i64, ptr %step.addr
+  %t40 = add i64 %t38, %t39
+  store i64 %t40, ptr %init.addr
+  br label %while.cond4
+while.end4:
+  %t41 = trunc i64 0 to i32
+  ret i32 %t41
+}
@@ -0,0 +1,93 @@
+; ModuleID = 'correct'
+source_filename = "correct.mini"
+
+define i32 @main() {
+entry:
+  %x.addr = alloca i64
+  %y.addr = alloca i64
+  %max.addr = alloca i64
+  %i.addr = alloca i64
+  %sum.addr = alloca i64
+  %flag.addr = alloca i64
+  %a.addr = alloca i64
+  %b.addr = alloca i64
+  %c.addr = alloca i64
+  %d.addr = alloca i64
+  %temp.addr = alloca i64
+  %result.addr = alloca i64
+  %logic3 = alloca i64
+  store i64 0, ptr %x.addr
+  store i64 0, ptr %y.addr
+  store i64 0, ptr %max.addr
+  store i64 0, ptr %i.addr
+  store i64 0, ptr %sum.addr
+  store i64 0, ptr %flag.addr
+  store i64 0, ptr %a.addr
+  store i64 0, ptr %b.addr
+  store i64 0, ptr %c.addr
+  store i64 0, ptr %d.addr
+  store i64 0, ptr %temp.addr
+  store i64 0, ptr %result.addr
+  store i64 10, ptr %x.addr
+  %t1 = load i64, ptr %x.addr
+  %t2 = mul i64 5, 2
+  %t3 = add i64 %t1, %t2
+  store i64 %t3, ptr %y.addr
+  %t4 = load i64, ptr %x.addr
+  %t5 = icmp sgt i64 %t4, 5
+  %t6 = zext i1 %t5 to i64
+  %t7 = icmp ne i64 %t6, 0
+  br i1 %t7, label %if.then1, label %if.end1
+if.then1:
+  %t8 = load i64, ptr %x.addr
+  store i64 %t8, ptr %max.addr
+  br label %if.end1
+if.end1:
+  store i64 0, ptr %i.addr
+  br label %while.cond2
+while.cond2:
+  %t9 = load i64, ptr %i.addr
+  %t10 = icmp slt i64 %t9, 10
+  %t11 = zext i1 %t10 to i64
+  %t12 = icmp ne i64 %t11, 0
+  br i1 %t12, label %while.body2, label %while.end2
+while.body2:
+  %t13 = load i64, ptr %i.addr
+  %t14 = add i64 %t13, 1
+  store i64 %t14, ptr %i.addr
+  %t15 = load i64, ptr %sum.addr
+  %t16 = load i64, ptr %i.addr
+  %t17 = add i64 %t15, %t16
+  store i64 %t17, ptr %sum.addr
+  br label %while.cond2
+while.end2:
+  store i64 0, ptr %logic3
+  %t18 = load i64, ptr %a.addr
+  %t19 = load i64, ptr %b.addr
+  %t20 = icmp sgt i64 %t18, %t19
+  %t21 = zext i1 %t20 to i64
+  %t22 = icmp ne i64 %t21, 0
+  br i1 %t22, label %logic.rhs3, label %logic.end3
+logic.rhs3:
+  %t23 = load i64, ptr %c.addr
+  %t24 = load i64, ptr %d.addr
+  %t25 = icmp sle i64 %t23, %t24
+  %t26 = zext i1 %t25 to i64
+  store i64 %t26, ptr %logic3
+  br label %logic.end3
+logic.end3:
+  %t27 = load i64, ptr %logic3
+  store i64 %t27, ptr %flag.addr
+  store i64 100, ptr %temp.addr
+  %t28 = load i64, ptr %temp.addr
+  %t29 = icmp eq i64 %t28, 100
+  %t30 = zext i1 %t29 to i64
+  %t31 = icmp ne i64 %t30, 0
+  br i1 %t31, label %if.then4, label %if.end4
+if.then4:
+  store i64 1, ptr %result.addr
+  br label %if.end4
+if.end4:
+  %t32 = trunc i64 0 to i32
+  ret i32 %t32
+}
@@ -0,0 +1,294 @@
+package lsp
+
+import (
+	"mini-parser/dataflow"
+	"mini-parser/parser"
+	"mini-parser/semantic"
+	"mini-parser/token"
+	"mini-parser/types"
+	"strings"
+	"unicode/utf16"
+)
+
+// document 一个已打开文档的分析结果
+type document struct {
+	text        string
+	lines       []string
+	tokens      []token.Token
+	program     *parser.Program
+	info        *types.Info
+	sem         *semantic.Info // 名字解析的结果, 有语法错误时为nil
+	diagnostics []Diagnostic
+	// 每个变量第一次被赋值处的标识符
+	firstAssign map[string]*parser.Identifier
+	vars        []string
+}
+
+func analyze(text string) *document {
+	doc := &document{text: text, firstAssign: map[string]*parser.Identifier{}, diagnostics: []Diagnostic{}}
+	doc.lines = strings.Split(text, "\n")
+
+	t := token.New(text)
+	for tok := t.NextToken(); tok.Type != token.EOF; tok = t.NextToken() {
+		doc.tokens = append(doc.tokens, tok)
+	}
+
+	p := parser.New(token.New(text))
+	doc.program = p.ParseProgram()
+	for _, err := range p.ErrorList() {
+		doc.diagnostics = append(doc.diagnostics, Diagnostic{
+			Range:    doc.rangeAt(err.Line, err.Column),
+			Severity: SeverityError,
+			Source:   "mini",
+			Message:  err.Message,
+		})
+	}
+
+	parser.Inspect(doc.program, func(n parser.Node) bool {
+		if assign, ok := n.(*parser.AssignStatement); ok && assign.Name != nil {
+			if _, seen := doc.firstAssign[assign.Name.Value]; !seen {
+				doc.firstAssign[assign.Name.Value] = assign.Name
+				doc.vars = append(doc.vars, assign.Name.Value)
+			}
+		}
+		return true
+	})
+
+	// 语法树不完整时后续分析可能遇到空节点, 只在没有语法错误时进行
+	if len(p.ErrorList()) == 0 {
+		doc.info = types.Infer(doc.program)
+		doc.sem = semantic.Check(doc.program)
+		for _, err := range doc.sem.Errors {
+			doc.diagnostics = append(doc.diagnostics, Diagnostic{
+				Range:    doc.rangeAt(err.Line, err.Column),
+				Severity: SeverityError,
+				Source:   "mini",
+				Message:  err.Message,
+			})
+		}
+		for _, w := range doc.sem.Warnings {
+			doc.diagnostics = append(doc.diagnostics, Diagnostic{
+				Range:    doc.rangeAt(w.Line, w.Column),
+				Severity: SeverityWarning,
+				Source:   "mini",
+				Message:  w.Message,
+			})
+		}
+		for _, w := range dataflow.CheckUninitialized(doc.program) {
+			doc.diagnostics = append(doc.diagnostics, Diagnostic{
+				Range:    doc.rangeAt(w.Line, w.Column),
+				Severity: SeverityWarning,
+				Source:   "mini",
+				Message:  w.Message,
+			})
+		}
+	}
+
+	return doc
+}
+
+// tokenAt 返回从指定位置(1起始的行列)开始的记号
+func (doc *document) tokenAt(line, column int) (token.Token, bool) {
+	for _, tok := range doc.tokens {
+		if tok.Line == line && tok.Column == column {
+			return tok, true
+		}
+	}
+	return token.Token{}, false
+}
+
+// tokenCovering 返回覆盖LSP位置的记号
+func (doc *document) tokenCovering(pos Position) (token.Token, bool) {
+	for _, tok := range doc.tokens {
+		r := doc.tokenRange(tok)
+		if r.Start.Line == pos.Line && r.Start.Character <= pos.Character && pos.Character <= r.End.Character {
+			return tok, true
+		}
+	}
+	return token.Token{}, false
+}
+
+// rangeAt 把行列位置转换为LSP范围, 位置上有记号时覆盖整个记号
+func (doc *document) rangeAt(line, column int) Range {
+	if tok, ok := doc.tokenAt(line, column); ok {
+		return doc.tokenRange(tok)
+	}
+	start := Position{Line: max(line-1, 0), Character: doc.character(line, column)}
+	return Range{Start: start, End: Position{Line: start.Line, Character: start.Character + 1}}
+}
+
+// character 把1起始的字符列号转换为该行之前的UTF-16代码单元数
+func (doc *document) character(line, column int) int {
+	if line < 1 || line > len(doc.lines) {
+		return max(column-1, 0)
+	}
+	prefix := []rune(doc.lines[line-1])
+	if column-1 < len(prefix) {
+		prefix = prefix[:max(column-1, 0)]
+	}
+	return len(utf16.Encode(prefix))
+}
+
+// tokenRange LSP使用0起始的行号和UTF-16代码单元计数的列号, 记号之前的中文等字符按UTF-16重新计数
+func (doc *document) tokenRange(tok token.Token) Range {
+	start := Position{Line: tok.Line - 1, Character: doc.character(tok.Line, tok.Column)}
+	length := len(utf16.Encode([]rune(tok.Literal)))
+	return Range{Start: start, End: Position{Line: start.Line, Character: start.Character + length}}
+}
+
+func (doc *document) symbols() []DocumentSymbol {
+	var vars []DocumentSymbol
+	for _, name := range doc.vars {
+		ident := doc.firstAssign[name]
+		r := doc.tokenRange(ident.Token)
+		sym := DocumentSymbol{Name: name, Kind: SymbolVariable, Range: r, SelectionRange: r}
+		if doc.info != nil {
+			sym.Detail = doc.info.Vars[name].String()
+		}
+		vars = append(vars, sym)
+	}
+
+	if doc.program.Name == nil {
+		if vars == nil {
+			return []DocumentSymbol{}
+		}
+		return vars
+	}
+
+	r := doc.tokenRange(doc.program.Name.Token)
+	whole := r
+	if n := len(doc.tokens); n > 0 {
+		whole = Range{Start: doc.tokenRange(doc.program.Token).Start, End: doc.tokenRange(doc.tokens[n-1]).End}
+	}
+	return []DocumentSymbol{{
+		Name:           doc.program.Name.Value,
+		Detail:         "program",
+		Kind:           SymbolModule,
+		Range:          whole,
+		SelectionRange: r,
+		Children:       vars,
+	}}
+}
+
+// symbolAt 返回位置上的标识符所链接的名字
+func (doc *document) symbolAt(tok token.Token) (*semantic.Symbol, bool) {
+	if doc.sem == nil {
+		return nil, false
+	}
+	for ident, sym := range doc.sem.Symbols {
+		if ident.Token == tok {
+			return sym, true
+		}
+	}
+	return nil, false
+}
+
+// definition 跳转到名字的声明处, 隐式变量为第一次出现处;
+// 有语法错误时取变量第一次被赋值的位置
+func (doc *document) definition(pos Position) (Range, bool) {
+	tok, ok := doc.tokenCovering(pos)
+	if !ok || tok.Type != token.IDENT {
+		return Range{}, false
+	}
+	if sym, ok := doc.symbolAt(tok); ok {
+		return doc.tokenRange(sym.Decl.Token), true
+	}
+	ident, ok := doc.firstAssign[tok.Literal]
+	if !ok {
+		return Range{}, false
+	}
+	return doc.tokenRange(ident.Token), true
+}
+
+func (doc *document) hover(pos Position) (*Hover, bool) {
+	tok, ok := doc.tokenCovering(pos)
+	if !ok || tok.Type != token.IDENT || doc.info == nil {
+		return nil, false
+	}
+	if doc.program.Name != nil && tok == doc.program.Name.Token {
+		return &Hover{Contents: MarkupContent{Kind: "plaintext", Value: "program " + tok.Literal}}, true
+	}
+
+	r := doc.tokenRange(tok)
+	if sym, ok := doc.symbolAt(tok); ok {
+		return &Hover{Contents: MarkupContent{Kind: "plaintext", Value: describe(sym)}, Range: &r}, true
+	}
+	t, ok := doc.info.Vars[tok.Literal]
+	if !ok {
+		return nil, false
+	}
+	return &Hover{
+		Contents: MarkupContent{Kind: "plaintext", Value: tok.Literal + ": " + t.String()},
+		Range:    &r,
+	}, true
+}
+
+// describe 悬停显示的名字说明, 如 const n: integer 或 function max(a, b)
+func describe(sym *semantic.Symbol) string {
+	switch sym.Kind {
+	case semantic.Procedure, semantic.Function:
+		var params []string
+		for _, p := range sym.Proc.Parameters {
+			params = append(params, p.Value)
+		}
+		return sym.Kind.String() + " " + sym.Name + "(" + strings.Join(params, ", ") + ")"
+	case semantic.Variable:
+		if sym.Implicit {
+			return sym.Name + ": " + sym.Type.String()
+		}
+	}
+	return sym.Kind.String() + " " + sym.Name + ": " + sym.Type.String()
+}
+
+var semanticTokenTypes = []string{"keyword", "variable", "number", "operator", "namespace"}
+
+const (
+	semKeyword = iota
+	semVariable
+	semNumber
+	semOperator
+	semNamespace
+)
+
+// semanticTokens 按LSP要求以相对位置编码每个记号: 行差, 列差, 长度, 类型, 修饰符
+func (doc *document) semanticTokens() SemanticTokens {
+	data := []int{}
+	prevLine, prevChar := 0, 0
+	for _, tok := range doc.tokens {
+		kind, ok := semanticKind(tok)
+		if !ok {
+			continue
+		}
+		if doc.program.Name != nil && tok == doc.program.Name.Token {
+			kind = semNamespace
+		}
+
+		r := doc.tokenRange(tok)
+		deltaLine := r.Start.Line - prevLine
+		deltaChar := r.Start.Character
+		if deltaLine == 0 {
+			deltaChar -= prevChar
+		}
+		data = append(data, deltaLine, deltaChar, r.End.Character-r.Start.Character, kind, 0)
+		prevLine, prevChar = r.Start.Line, r.Start.Character
+	}
+	return SemanticTokens{Data: data}
+}
+
+func semanticKind(tok token.Token) (int, bool) {
+	switch tok.Type {
+	case token.IDENT:
+		return semVariable, true
+	case token.NUMBER, token.REAL:
+		return semNumber, true
+	case token.ILLEGAL, token.SEMICOLON, token.COMMA, token.DOT, token.LPAREN, token.RPAREN:
+		return 0, false
+	}
+	if token.LookupIdent(tok.Literal) != token.IDENT {
+		return semKeyword, true
+	}
+	if tok.Type == "" {
+		return 0, false
+	}
+	return semOperator, true
+}
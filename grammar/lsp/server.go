@@ -0,0 +1,225 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mini-parser/msg"
+	"strconv"
+	"strings"
+)
+
+// Server 基于标准输入输出的Mini语言服务器
+type Server struct {
+	in       *bufio.Reader
+	out      io.Writer
+	docs     map[string]*document
+	shutdown bool
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{in: bufio.NewReader(in), out: out, docs: map[string]*document{}}
+}
+
+// Run 处理客户端消息, 直到收到exit通知或输入结束
+func (s *Server) Run() error {
+	for {
+		body, err := s.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			// 无法解析的消息不知道id, 按 JSON-RPC 以 null 作为id回复错误, 继续处理后面的消息
+			if err := s.replyError(json.RawMessage("null"), codeParseError, msg.Get(msg.InvalidMessage, err)); err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("未收到shutdown请求就退出")
+			}
+			return nil
+		}
+		if err := s.handle(&req); err != nil {
+			return err
+		}
+	}
+}
+
+// read 读取一条以Content-Length头部分帧的消息
+func (s *Server) read() ([]byte, error) {
+	length := -1
+	for {
+		line, err := s.in.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(name, "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("无效的Content-Length: %s", value)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("消息缺少Content-Length")
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.in, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (s *Server) write(msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+func (s *Server) reply(id json.RawMessage, result interface{}) error {
+	return s.write(response{JSONRPC: "2.0", ID: id, Result: result})
+}
+
+func (s *Server) replyError(id json.RawMessage, code int, message string) error {
+	return s.write(errorResponse{JSONRPC: "2.0", ID: id, Error: responseError{Code: code, Message: message}})
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	return s.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *Server) handle(req *request) error {
+	// id 为 null 的消息与没有 id 的一样是通知, 不需要回复
+	isRequest := len(req.ID) > 0 && string(req.ID) != "null"
+
+	switch req.Method {
+	case "initialize":
+		return s.reply(req.ID, map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":       1, // 全量同步
+				"documentSymbolProvider": true,
+				"definitionProvider":     true,
+				"hoverProvider":          true,
+				"semanticTokensProvider": map[string]interface{}{
+					"legend": map[string]interface{}{
+						"tokenTypes":     semanticTokenTypes,
+						"tokenModifiers": []string{},
+					},
+					"full": true,
+				},
+			},
+			"serverInfo": map[string]string{"name": "mini-lsp"},
+		})
+
+	case "initialized", "$/cancelRequest", "$/setTrace":
+		return nil
+
+	case "shutdown":
+		s.shutdown = true
+		return s.reply(req.ID, nil)
+
+	case "textDocument/didOpen":
+		var params DidOpenParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+		return s.update(params.TextDocument.URI, params.TextDocument.Text)
+
+	case "textDocument/didChange":
+		var params DidChangeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || len(params.ContentChanges) == 0 {
+			return nil
+		}
+		// 全量同步时最后一次变更即为完整文本
+		text := params.ContentChanges[len(params.ContentChanges)-1].Text
+		return s.update(params.TextDocument.URI, text)
+
+	case "textDocument/didClose":
+		var params DidCloseParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return s.notify("textDocument/publishDiagnostics",
+			PublishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+
+	case "textDocument/documentSymbol":
+		var params DocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return s.replyError(req.ID, codeInvalidParams, err.Error())
+		}
+		doc := s.docs[params.TextDocument.URI]
+		if doc == nil {
+			return s.reply(req.ID, []DocumentSymbol{})
+		}
+		return s.reply(req.ID, doc.symbols())
+
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return s.replyError(req.ID, codeInvalidParams, err.Error())
+		}
+		doc := s.docs[params.TextDocument.URI]
+		if doc == nil {
+			return s.reply(req.ID, nil)
+		}
+		if r, ok := doc.definition(params.Position); ok {
+			return s.reply(req.ID, Location{URI: params.TextDocument.URI, Range: r})
+		}
+		return s.reply(req.ID, nil)
+
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return s.replyError(req.ID, codeInvalidParams, err.Error())
+		}
+		doc := s.docs[params.TextDocument.URI]
+		if doc == nil {
+			return s.reply(req.ID, nil)
+		}
+		if hover, ok := doc.hover(params.Position); ok {
+			return s.reply(req.ID, hover)
+		}
+		return s.reply(req.ID, nil)
+
+	case "textDocument/semanticTokens/full":
+		var params DocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return s.replyError(req.ID, codeInvalidParams, err.Error())
+		}
+		doc := s.docs[params.TextDocument.URI]
+		if doc == nil {
+			return s.reply(req.ID, SemanticTokens{Data: []int{}})
+		}
+		return s.reply(req.ID, doc.semanticTokens())
+	}
+
+	if isRequest {
+		return s.replyError(req.ID, codeMethodNotFound, msg.Get(msg.UnsupportedMethod, req.Method))
+	}
+	return nil
+}
+
+// update 重新分析文档并发布诊断
+func (s *Server) update(uri, text string) error {
+	doc := analyze(text)
+	s.docs[uri] = doc
+	return s.notify("textDocument/publishDiagnostics",
+		PublishDiagnosticsParams{URI: uri, Diagnostics: doc.diagnostics})
+}
@@ -0,0 +1,229 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// 𝑥 在 UTF-16 中占两个代码单元, 它之后的记号的字符列号与UTF-16列号不同
+const source = `program p;
+var 𝑥, y: integer;
+begin
+  𝑥 := 1; y := 𝑥 + z
+end.
+`
+
+const uri = "file:///test.mini"
+
+// client 通过管道与服务器交换消息
+type client struct {
+	t   *testing.T
+	in  *io.PipeWriter
+	out *bufio.Reader
+	id  int
+}
+
+type message struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *responseError  `json:"error"`
+}
+
+func (c *client) send(id json.RawMessage, method string, params interface{}) {
+	c.t.Helper()
+	body, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if _, err := fmt.Fprintf(c.in, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *client) notify(method string, params interface{}) {
+	c.t.Helper()
+	c.send(nil, method, params)
+}
+
+// call 发送请求并把结果解码到result中
+func (c *client) call(method string, params interface{}, result interface{}) {
+	c.t.Helper()
+	c.id++
+	id := json.RawMessage(strconv.Itoa(c.id))
+	c.send(id, method, params)
+	m := c.read()
+	if string(m.ID) != string(id) {
+		c.t.Fatalf("%s: 期望 id %s, 收到 %s", method, id, m.ID)
+	}
+	if m.Error != nil {
+		c.t.Fatalf("%s: %s", method, m.Error.Message)
+	}
+	if result != nil {
+		if err := json.Unmarshal(m.Result, result); err != nil {
+			c.t.Fatalf("%s: %v: %s", method, err, m.Result)
+		}
+	}
+}
+
+func (c *client) read() message {
+	c.t.Helper()
+	length := -1
+	for {
+		line, err := c.out.ReadString('\n')
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		if value, ok := strings.CutPrefix(line, "Content-Length: "); ok {
+			length, _ = strconv.Atoi(value)
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.out, body); err != nil {
+		c.t.Fatal(err)
+	}
+	var m message
+	if err := json.Unmarshal(body, &m); err != nil {
+		c.t.Fatal(err)
+	}
+	return m
+}
+
+func position(line, character int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: line, Character: character},
+	}
+}
+
+func span(line, start, end int) Range {
+	return Range{Start: Position{Line: line, Character: start}, End: Position{Line: line, Character: end}}
+}
+
+func TestServer(t *testing.T) {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- NewServer(inR, outW).Run()
+		outW.Close()
+	}()
+	c := &client{t: t, in: inW, out: bufio.NewReader(outR)}
+
+	var init struct {
+		Capabilities map[string]interface{} `json:"capabilities"`
+	}
+	c.call("initialize", map[string]interface{}{}, &init)
+	for _, cap := range []string{"definitionProvider", "hoverProvider", "semanticTokensProvider"} {
+		if init.Capabilities[cap] == nil {
+			t.Errorf("initialize 的结果缺少 %s", cap)
+		}
+	}
+	c.notify("initialized", map[string]interface{}{})
+
+	c.notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "languageId": "mini", "version": 1, "text": source},
+	})
+	m := c.read()
+	if m.Method != "textDocument/publishDiagnostics" {
+		t.Fatalf("期望 publishDiagnostics, 收到 %s", m.Method)
+	}
+	var diags PublishDiagnosticsParams
+	if err := json.Unmarshal(m.Params, &diags); err != nil {
+		t.Fatal(err)
+	}
+	// 未声明的 z 报告错误, 数据流分析还警告它在赋值前被使用
+	if diags.URI != uri || len(diags.Diagnostics) != 2 {
+		t.Fatalf("期望两条诊断, 收到 %+v", diags)
+	}
+	for i, severity := range []int{SeverityError, SeverityWarning} {
+		// z 是第4行第20个字符, 之前的两个 𝑥 各多占一个代码单元
+		if d := diags.Diagnostics[i]; d.Range != span(3, 21, 22) || d.Severity != severity || !strings.Contains(d.Message, "z") {
+			t.Errorf("z 的诊断不对: %+v", d)
+		}
+	}
+
+	var loc Location
+	c.call("textDocument/definition", position(3, 17), &loc)
+	if loc.URI != uri || loc.Range != span(1, 4, 6) {
+		t.Errorf("𝑥 的定义位置不对: %+v", loc)
+	}
+
+	var hover Hover
+	c.call("textDocument/hover", position(3, 11), &hover)
+	if hover.Contents.Value != "var y: integer" || hover.Range == nil || *hover.Range != span(3, 11, 12) {
+		t.Errorf("y 的悬停信息不对: %+v", hover)
+	}
+
+	var tokens SemanticTokens
+	c.call("textDocument/semanticTokens/full", DocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}}, &tokens)
+	if len(tokens.Data)%5 != 0 {
+		t.Fatalf("语义记号的数据长度 %d 不是5的倍数", len(tokens.Data))
+	}
+	var got []string
+	line, char := 0, 0
+	for i := 0; i < len(tokens.Data); i += 5 {
+		if tokens.Data[i] > 0 {
+			line, char = line+tokens.Data[i], 0
+		}
+		char += tokens.Data[i+1]
+		if line == 3 {
+			got = append(got, fmt.Sprintf("%d+%d:%s", char, tokens.Data[i+2], semanticTokenTypes[tokens.Data[i+3]]))
+		}
+	}
+	want := "2+2:variable 5+2:operator 8+1:number 11+1:variable 13+2:operator 16+2:variable 19+1:operator 21+1:variable"
+	if strings.Join(got, " ") != want {
+		t.Errorf("第4行的语义记号\n得到 %s\n期望 %s", strings.Join(got, " "), want)
+	}
+
+	c.call("shutdown", nil, nil)
+	c.notify("exit", nil)
+	if err := <-done; err != nil {
+		t.Errorf("服务器退出时出错: %v", err)
+	}
+	inW.Close()
+}
+
+// 无法解析的消息得到 -32700 错误, 服务器继续处理之后的消息; id 为 null 的消息是通知, 不回复
+func TestInvalidMessages(t *testing.T) {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- NewServer(inR, outW).Run()
+		outW.Close()
+	}()
+	c := &client{t: t, in: inW, out: bufio.NewReader(outR)}
+
+	// 在另一个goroutine中写入, 服务器错误地回复时不会因管道阻塞而卡住
+	go func() {
+		for _, body := range []string{
+			`{"jsonrpc": "2.0", "id": null, "method": "mini/unknown"}`,
+			`{"jsonrpc": "2.0", "id": 1, "method": `,
+		} {
+			fmt.Fprintf(inW, "Content-Length: %d\r\n\r\n%s", len(body), body)
+		}
+	}()
+	// 对 id 为 null 的消息有回复的话, 这里先读到的会是它
+	m := c.read()
+	if m.Error == nil || m.Error.Code != codeParseError || string(m.ID) != "null" {
+		t.Fatalf("期望 -32700 错误, 收到 %+v", m)
+	}
+
+	c.call("shutdown", nil, nil)
+	c.notify("exit", nil)
+	if err := <-done; err != nil {
+		t.Errorf("服务器退出时出错: %v", err)
+	}
+	inW.Close()
+}
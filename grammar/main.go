@@ -1,42 +1,745 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"math/rand"
+	"mini-parser/cfg"
+	"mini-parser/compiler"
+	"mini-parser/coverage"
+	"mini-parser/dataflow"
+	"mini-parser/diag"
+	"mini-parser/format"
+	"mini-parser/gen"
+	"mini-parser/grammar"
+	"mini-parser/llvm"
+	"mini-parser/lsp"
+	"mini-parser/msg"
+	"mini-parser/optimizer"
 	"mini-parser/parser" // 修改后
-	"mini-parser/token"  // 修改后
+	"mini-parser/parsetree"
+	"mini-parser/repl"
+	"mini-parser/semantic"
+	"mini-parser/ssa"
+	"mini-parser/token" // 修改后
+	"mini-parser/transpile"
+	"mini-parser/vm"
+	"mini-parser/wat"
+	"mini-parser/x86"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("使用方法: mini_parser <文件路径>")
+	optimize := flag.Bool("O", false, "输出常量折叠和死分支删除后的程序结构")
+	run := flag.Bool("run", false, "编译为字节码并在虚拟机中运行")
+	disasm := flag.Bool("disasm", false, "输出字节码的反汇编")
+	showSSA := flag.Bool("ssa", false, "输出控制流图的SSA形式及退出SSA后的结果")
+	target := flag.String("emit", "", "生成目标代码: x86, c, go, wat, llvm")
+	output := flag.String("o", "", "目标代码输出文件, 默认与源文件同名")
+	result := flag.String("result", "", "作为程序结果(退出码)返回的变量")
+	globals := flag.Bool("globals", false, "x86后端把变量分配在全局数据段而不是栈上")
+	color := flag.String("color", "auto", "诊断信息是否使用颜色: auto, always, never")
+	astFormat := flag.String("ast", "", "只输出语法树: tree (缩进的树形文本) 或 dot (Graphviz)")
+	treeFormat := flag.String("parsetree", "", "按Mini文法递归下降, 输出具体语法树和最左推导: text 或 dot")
+	lang := flag.String("lang", "", "诊断信息的语言: zh-CN, en (默认取自 LANG 环境变量)")
+	flag.Parse()
+
+	if err := msg.Select(*lang); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if flag.NArg() < 1 {
+		fmt.Println(msg.Get(msg.Usage))
 		os.Exit(1)
 	}
 
+	switch flag.Arg(0) {
+	case "repl":
+		repl.Start(os.Stdin, os.Stdout)
+		return
+	case "fmt":
+		if err := formatFiles(flag.Args()[1:], *color); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "grammar":
+		if err := analyzeGrammar(flag.Args()[1:]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "gen":
+		if err := generatePrograms(flag.Args()[1:]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "coverage":
+		if err := reportCoverage(flag.Args()[1:]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "lsp":
+		if err := lsp.NewServer(os.Stdin, os.Stdout).Run(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// 读取输入文件
-	filename := os.Args[1]
+	filename := flag.Arg(0)
 	input, err := os.ReadFile(filename) // Replace ioutil.ReadFile with os.ReadFile
 	if err != nil {
-		fmt.Printf("读取文件错误: %v\n", err)
+		fmt.Println(msg.Get(msg.ReadFileFailed, err))
 		os.Exit(1)
 	}
 
+	useColor := *color == "always" || *color == "auto" && diag.IsTerminal(os.Stdout)
+	renderer := diag.NewRenderer(filename, string(input), useColor)
+
+	if *treeFormat != "" {
+		if err := derive(string(input), *treeFormat, renderer); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// 初始化词法分析器和语法分析器
 	tokenizer := token.New(string(input))
-	parser := parser.New(tokenizer)
+	p := parser.New(tokenizer)
 
 	// 执行语法分析
-	program := parser.ParseProgram()
+	program := p.ParseProgram()
 
 	// 输出分析结果
-	if len(parser.Errors()) > 0 {
-		fmt.Println("语法分析发现错误:")
-		for _, err := range parser.Errors() {
-			fmt.Println(err)
+	if len(p.Errors()) > 0 {
+		fmt.Println(msg.Get(msg.ParseFailed))
+		for _, err := range p.ErrorList() {
+			fmt.Println(renderer.Render(diag.Diagnostic{
+				Severity: diag.Error,
+				Line:     err.Line,
+				Column:   err.Column,
+				Length:   err.Length,
+				Message:  err.Message,
+				Notes:    err.Notes,
+			}))
 		}
 		os.Exit(1)
 	}
 
-	fmt.Println("语法分析成功! 程序结构:")
+	// -ast 只输出语法树, 便于直接交给 dot 等工具
+	switch *astFormat {
+	case "":
+	case "tree":
+		fmt.Print(parser.Tree(program))
+		return
+	case "dot":
+		fmt.Print(parser.Dot(program))
+		return
+	default:
+		fmt.Println(msg.Get(msg.UnknownASTFormat, *astFormat))
+		os.Exit(1)
+	}
+
+	info := semantic.Check(program)
+	if len(info.Errors) > 0 {
+		fmt.Println(msg.Get(msg.CheckFailed))
+		for _, err := range info.Errors {
+			fmt.Println(renderer.Render(diag.Diagnostic{
+				Severity: diag.Error,
+				Line:     err.Line,
+				Column:   err.Column,
+				Length:   err.Length,
+				Message:  err.Message,
+			}))
+		}
+		os.Exit(1)
+	}
+
+	fmt.Println(msg.Get(msg.ParseSucceeded))
 	fmt.Println(program.String())
+
+	// 之后的分析和各后端只处理变量
+	program = optimizer.InlineConstants(program)
+
+	// 名字遮蔽和数据流检查只给出警告, 不影响分析结果
+	var warnings []diag.Diagnostic
+	for _, w := range info.Warnings {
+		warnings = append(warnings, diag.Diagnostic{
+			Severity: diag.Warning,
+			Line:     w.Line,
+			Column:   w.Column,
+			Length:   w.Length,
+			Message:  w.Message,
+		})
+	}
+	for _, w := range dataflow.CheckUninitialized(program) {
+		warnings = append(warnings, diag.Diagnostic{
+			Severity: diag.Warning,
+			Line:     w.Line,
+			Column:   w.Column,
+			Length:   w.Length,
+			Message:  w.Message,
+		})
+	}
+	if len(warnings) > 0 {
+		fmt.Println(msg.Get(msg.Warnings))
+		for _, w := range warnings {
+			fmt.Println(renderer.Render(w))
+		}
+	}
+
+	if *optimize {
+		fmt.Println(msg.Get(msg.OptimizedProgram))
+		fmt.Println(optimizer.Optimize(program).String())
+	}
+
+	if *showSSA {
+		f := ssa.Build(cfg.Build(program))
+		fmt.Println(msg.Get(msg.SSAForm))
+		fmt.Print(f.String())
+		fmt.Println(msg.Get(msg.AfterSSA))
+		fmt.Print(f.Destruct().String())
+	}
+
+	// 虚拟机和各代码生成后端只处理主程序, 有过程声明时不能运行或生成代码
+	if (*run || *disasm || *target != "") && len(program.Procedures) > 0 {
+		name := program.Procedures[0].Name
+		fmt.Println(renderer.Render(diag.Diagnostic{
+			Severity: diag.Error,
+			Line:     name.Token.Line,
+			Column:   name.Token.Column,
+			Length:   len([]rune(name.Value)),
+			Message:  msg.Get(msg.ProceduresUnsupported, name.Value),
+		}))
+		os.Exit(1)
+	}
+
+	if *run || *disasm {
+		if err := execute(program, *run, *disasm); err != nil {
+			var runtimeErr vm.RuntimeError
+			if errors.As(err, &runtimeErr) {
+				fmt.Println(renderer.Render(diag.Diagnostic{
+					Severity: diag.Error,
+					Line:     runtimeErr.Line,
+					Column:   runtimeErr.Column,
+					Message:  msg.Get(msg.RuntimeFailed, runtimeErr.Message),
+				}))
+			} else {
+				fmt.Println(err)
+			}
+			os.Exit(1)
+		}
+	}
+
+	if *target != "" {
+		opts := x86.Options{Result: *result}
+		if *globals {
+			opts.Storage = x86.Globals
+		}
+		if err := emit(program, *target, filename, *output, opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+}
+
+// formatFiles 实现fmt子命令: 输出规范格式的源程序, -w 时写回原文件
+func formatFiles(args []string, color string) error {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := flags.Bool("w", false, "把结果写回源文件而不是输出到标准输出")
+	indent := flags.Int("indent", 4, "每一级缩进的空格数")
+	tabs := flags.Bool("tabs", false, "使用制表符缩进")
+	flags.Parse(args)
+
+	opts := format.Options{Indent: strings.Repeat(" ", *indent)}
+	if *tabs {
+		opts.Indent = "\t"
+	}
+
+	for _, filename := range flags.Args() {
+		input, err := os.ReadFile(filename)
+		if err != nil {
+			return errors.New(msg.Get(msg.ReadFileFailed, err))
+		}
+		text, err := format.Source(string(input), opts)
+		var errs parser.ParserErrors
+		if errors.As(err, &errs) {
+			useColor := color == "always" || color == "auto" && diag.IsTerminal(os.Stdout)
+			renderer := diag.NewRenderer(filename, string(input), useColor)
+			for _, e := range errs {
+				fmt.Println(renderer.Render(diag.Diagnostic{
+					Severity: diag.Error,
+					Line:     e.Line,
+					Column:   e.Column,
+					Length:   e.Length,
+					Message:  e.Message,
+					Notes:    e.Notes,
+				}))
+			}
+			return fmt.Errorf("%s: %s", filename, msg.Get(msg.ParseFailed))
+		}
+		if *write {
+			if err := os.WriteFile(filename, []byte(text), 0644); err != nil {
+				return errors.New(msg.Get(msg.WriteFileFailed, err))
+			}
+			continue
+		}
+		fmt.Print(text)
+	}
+	return nil
+}
+
+// derive 输出具体语法树和最左推导, dot 格式只输出语法树
+func derive(input, format string, renderer *diag.Renderer) error {
+	if format != "text" && format != "dot" {
+		return errors.New(msg.Get(msg.UnknownParseTreeFormat, format))
+	}
+
+	tree, err := parsetree.Parse(input)
+	var errs parser.ParserErrors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			fmt.Println(renderer.Render(diag.Diagnostic{
+				Severity: diag.Error,
+				Line:     e.Line,
+				Column:   e.Column,
+				Length:   e.Length,
+				Message:  e.Message,
+			}))
+		}
+		return errors.New(msg.Get(msg.ParseFailed))
+	}
+
+	if format == "dot" {
+		fmt.Print(tree.Dot())
+		return nil
+	}
+	fmt.Println(msg.Get(msg.ParseTreeTitle))
+	fmt.Print(tree.String())
+	fmt.Println(msg.Get(msg.DerivationTitle))
+	fmt.Print(tree.DerivationString())
+	return nil
+}
+
+// analyzeGrammar 实现grammar子命令: 输出文法的FIRST/FOLLOW集和LL(1)冲突,
+// -table 输出预测分析表, -trace 用预测分析法分析源程序并输出每一步
+func analyzeGrammar(args []string) error {
+	flags := flag.NewFlagSet("grammar", flag.ExitOnError)
+	table := flags.Bool("table", false, "输出LL(1)预测分析表")
+	trace := flags.String("trace", "", "分析该源程序并输出分析过程")
+	lr := flags.String("lr", "", "改用自底向上分析: lr0, lr1, lalr1")
+	states := flags.Bool("states", false, "与 -lr 一起使用时输出全部项目集")
+	op := flags.Bool("op", false, "改用算符优先分析, 文法须为算符文法 (如 expr.bnf)")
+	transform := flags.String("transform", "", "先依次变换文法, 以逗号分隔: left (消除左递归), factor (提取左公因子), useless (删除无用符号)")
+	output := flags.String("o", "", "与 -transform 一起使用时把变换后的文法写入该文件")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errors.New("使用方法: mini_parser grammar [-transform 变换] [-o 文件] [-lr 种类 | -op] [-table] [-states] [-trace 源文件] <文法文件>")
+	}
+
+	g, err := grammar.Load(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *transform != "" {
+		before := g
+		if g, err = grammar.Transform(g, strings.Split(*transform, ",")); err != nil {
+			return err
+		}
+		if *output != "" {
+			if err := os.WriteFile(*output, []byte(g.String()), 0644); err != nil {
+				return errors.New(msg.Get(msg.WriteFileFailed, err))
+			}
+		}
+		// 没有指定其他分析时输出变换结果, 否则接着分析变换后的文法
+		if !*table && *trace == "" && *lr == "" && !*op {
+			fmt.Print(g.String())
+			fmt.Println("差异:")
+			fmt.Print(grammar.Diff(before, g))
+			return nil
+		}
+	}
+	a := grammar.Analyze(g)
+
+	if *op {
+		t, err := grammar.BuildOP(g)
+		if err != nil {
+			return err
+		}
+		return analyzeOP(t, *table, *trace)
+	}
+
+	if *lr != "" {
+		kind, ok := grammar.ParseLRKind(*lr)
+		if !ok {
+			return fmt.Errorf("未知的LR分析表种类: %s", *lr)
+		}
+		return analyzeLR(grammar.BuildLR(a, kind), *table, *states, *trace)
+	}
+
+	if *trace != "" {
+		input, err := os.ReadFile(*trace)
+		if err != nil {
+			return errors.New(msg.Get(msg.ReadFileFailed, err))
+		}
+		steps, err := grammar.BuildLL1(a).Parse(grammar.Tokenize(string(input)))
+		fmt.Print(grammar.FormatSteps(steps))
+		return renderErrors(*trace, string(input), err)
+	}
+
+	if *table {
+		fmt.Print(grammar.BuildLL1(a).String())
+		return nil
+	}
+
+	fmt.Print(a.String())
+	conflicts := a.LL1Conflicts()
+	if len(conflicts) == 0 {
+		fmt.Println(msg.Get(msg.IsLL1))
+		return nil
+	}
+	fmt.Println(msg.Get(msg.LL1Conflicts))
+	for _, c := range conflicts {
+		fmt.Println("  " + c.String())
+	}
+	return nil
+}
+
+// analyzeLR 输出LR分析表的冲突、分析表或项目集, 或者用它分析源程序并输出语法树
+func analyzeLR(t *grammar.LRTable, table, states bool, trace string) error {
+	if trace != "" {
+		input, err := os.ReadFile(trace)
+		if err != nil {
+			return errors.New(msg.Get(msg.ReadFileFailed, err))
+		}
+		program, steps, err := t.ParseProgram(string(input))
+		fmt.Print(grammar.FormatSteps(steps))
+		if err != nil {
+			return renderErrors(trace, string(input), err)
+		}
+		fmt.Println("语法树:")
+		fmt.Println(program.String())
+		return nil
+	}
+
+	switch {
+	case table:
+		fmt.Print(t.TableString())
+	case states:
+		for _, s := range t.States {
+			fmt.Print(t.StateString(s))
+		}
+	default:
+		fmt.Printf("%s分析表: %d个状态\n", t.Kind, len(t.States))
+		if len(t.Conflicts) == 0 {
+			fmt.Printf("该文法是%s文法\n", t.Kind)
+		}
+		for _, c := range t.Conflicts {
+			fmt.Print(t.ConflictString(c))
+		}
+	}
+	return nil
+}
+
+// renderErrors 按诊断格式输出语法错误, 其他错误原样返回
+func renderErrors(filename, input string, err error) error {
+	var errs parser.ParserErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+	renderer := diag.NewRenderer(filename, input, diag.IsTerminal(os.Stdout))
+	for _, e := range errs {
+		fmt.Println(renderer.Render(diag.Diagnostic{
+			Severity: diag.Error,
+			Line:     e.Line,
+			Column:   e.Column,
+			Length:   e.Length,
+			Message:  e.Message,
+			Notes:    e.Notes,
+		}))
+	}
+	return errors.New(msg.Get(msg.ParseFailed))
+}
+
+// analyzeOP 输出FIRSTVT/LASTVT、优先关系冲突以及与 parser.Precedence 的对照,
+// -table 输出优先关系矩阵, -trace 分析一条赋值语句并与 parser.Parser 的结果比较
+func analyzeOP(t *grammar.OPTable, table bool, trace string) error {
+	if trace != "" {
+		input, err := os.ReadFile(trace)
+		if err != nil {
+			return errors.New(msg.Get(msg.ReadFileFailed, err))
+		}
+		stmt, steps, err := t.ParseAssign(string(input))
+		fmt.Print(grammar.FormatSteps(steps))
+		if err != nil {
+			return renderErrors(trace, string(input), err)
+		}
+		fmt.Println("语法树:")
+		fmt.Println(stmt.String())
+
+		p := parser.New(token.New(string(input)))
+		program := p.ParseProgram()
+		switch {
+		case len(p.Errors()) > 0 || len(program.Statements) != 1:
+			fmt.Println("parser.Parser 不能把它分析为一条语句, 无法比较")
+		case program.Statements[0].String() != stmt.String():
+			fmt.Printf("与 parser.Parser 的结果不同: %s\n", program.Statements[0].String())
+		default:
+			fmt.Println("与 parser.Parser 的结果相同")
+		}
+		return nil
+	}
+
+	if table {
+		fmt.Print(t.MatrixString())
+		return nil
+	}
+
+	fmt.Print(t.VTString())
+	if len(t.Conflicts) == 0 {
+		fmt.Println("该文法是算符优先文法")
+	} else {
+		fmt.Println("优先关系冲突:")
+		for _, c := range t.Conflicts {
+			fmt.Println("  " + c.String())
+		}
+	}
+	if mismatches := t.CheckPrecedences(); len(mismatches) > 0 {
+		fmt.Println("与 parser.Precedence 不一致:")
+		for _, m := range mismatches {
+			fmt.Println("  " + m)
+		}
+	} else {
+		fmt.Println("双目运算符之间的优先关系与 parser.Precedence 一致")
+	}
+	return nil
+}
+
+// generatePrograms 实现gen子命令: 按文法随机生成程序, -mutate 向每个程序注入一个错误,
+// -o 写入目录, -check 用 parser.ParseProgram 分析生成的程序并核对错误位置
+func generatePrograms(args []string) error {
+	flags := flag.NewFlagSet("gen", flag.ExitOnError)
+	n := flags.Int("n", 1, "生成的程序个数")
+	seed := flags.Int64("seed", 1, "随机数种子")
+	depth := flags.Int("depth", gen.DefaultOptions.MaxDepth, "推导树的最大深度")
+	mutate := flags.Bool("mutate", false, "向每个程序注入一个单记号错误")
+	dir := flags.String("o", "", "把程序写入该目录, 文件名为 gen_0001.mini 等")
+	check := flags.Bool("check", false, "分析生成的程序和注入错误后的程序, 核对错误报告")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errors.New("使用方法: mini_parser gen [-n 数量] [-seed 种子] [-depth 深度] [-mutate] [-o 目录] [-check] <文法文件>")
+	}
+
+	g, err := grammar.Load(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	generator, err := gen.New(g, gen.Options{MaxDepth: *depth, Seed: *seed})
+	if err != nil {
+		return err
+	}
+	rng := rand.New(rand.NewSource(*seed))
+
+	if *check {
+		return checkPrograms(generator, rng, *n)
+	}
+
+	if *dir != "" {
+		if err := os.MkdirAll(*dir, 0755); err != nil {
+			return err
+		}
+	}
+	for i := 1; i <= *n; i++ {
+		src := generator.Program()
+		if *mutate {
+			if out, m, ok := gen.Mutate(src, rng); ok {
+				src = out + "// 注入: " + m.String() + "\n"
+			}
+		}
+		if *dir == "" {
+			if *n > 1 {
+				fmt.Printf("// ---- %d ----\n", i)
+			}
+			fmt.Print(src)
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(*dir, fmt.Sprintf("gen_%04d.mini", i)), []byte(src), 0644); err != nil {
+			return errors.New(msg.Get(msg.WriteFileFailed, err))
+		}
+	}
+	return nil
+}
+
+// checkPrograms 生成的程序应当没有语法错误, 注入错误后的程序的第一个错误应当在注入处或相邻的记号上
+func checkPrograms(generator *gen.Generator, rng *rand.Rand, n int) error {
+	const shown = 3 // 每类问题最多列出的例子
+	var falseErrors int
+	injected := map[gen.Kind]int{}
+	missed := map[gen.Kind]int{}
+	misplaced := map[gen.Kind]int{}
+
+	report := func(count int, title, src string) {
+		if count <= shown {
+			fmt.Printf("%s:\n%s\n", title, src)
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		src := generator.Program()
+		if errs := parseErrors(src); len(errs) > 0 {
+			falseErrors++
+			report(falseErrors, "合法程序被报告错误: "+errs[0].Error(), src)
+		}
+
+		out, m, ok := gen.Mutate(src, rng)
+		if !ok {
+			continue
+		}
+		injected[m.Kind]++
+		errs := parseErrors(out)
+		switch {
+		case len(errs) == 0:
+			missed[m.Kind]++
+			report(missed[m.Kind], "注入的错误没有被报告: "+m.String(), out)
+		case !m.Covers(errs[0].Line, errs[0].Column):
+			misplaced[m.Kind]++
+			report(misplaced[m.Kind], fmt.Sprintf("错误位置不符: %s, 报告为 %s", m, errs[0].Error()), out)
+		}
+	}
+
+	fmt.Printf("生成 %d 个程序, 被报告错误的合法程序 %d 个\n", n, falseErrors)
+	problems := falseErrors
+	for _, k := range []gen.Kind{gen.DeleteSemicolon, gen.DropKeyword, gen.SwapOperator} {
+		fmt.Printf("%s: 注入 %d 个, 未报告 %d 个, 位置不符 %d 个\n", k, injected[k], missed[k], misplaced[k])
+		problems += missed[k] + misplaced[k]
+	}
+	if problems > 0 {
+		return fmt.Errorf("发现 %d 个问题", problems)
+	}
+	return nil
+}
+
+func parseErrors(src string) parser.ParserErrors {
+	p := parser.New(token.New(src))
+	p.ParseProgram()
+	return p.ErrorList()
+}
+
+// reportCoverage 实现coverage子命令: 输出一组源程序用到的产生式和触发的报错分支
+func reportCoverage(args []string) error {
+	flags := flag.NewFlagSet("coverage", flag.ExitOnError)
+	htmlFile := flags.String("html", "", "把完整的报告以HTML写入该文件")
+	src := flags.String("src", "", "parser 包的源代码目录, 默认使用编译进程序的源代码")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		return errors.New("使用方法: mini_parser coverage [-html 文件] [-src 目录] <源文件或目录>...")
+	}
+
+	sources, err := coverage.Load(flags.Args())
+	if err != nil {
+		return err
+	}
+	fsys := parser.Sources()
+	if *src != "" {
+		fsys = os.DirFS(*src)
+	}
+	report, err := coverage.Run(sources, fsys)
+	if err != nil {
+		return err
+	}
+	if *htmlFile == "" {
+		fmt.Print(report.Text())
+		return nil
+	}
+
+	page, err := report.HTML()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*htmlFile, []byte(page), 0644); err != nil {
+		return errors.New(msg.Get(msg.WriteFileFailed, err))
+	}
+	fmt.Print(report.Summary())
+	return nil
+}
+
+var extensions = map[string]string{
+	"x86":  ".s",
+	"c":    ".c",
+	"go":   ".go",
+	"wat":  ".wat",
+	"llvm": ".ll",
+}
+
+// emit 生成目标代码并写入文件
+func emit(program *parser.Program, target, filename, output string, opts x86.Options) error {
+	ext, ok := extensions[target]
+	if !ok {
+		return errors.New(msg.Get(msg.UnknownTarget, target))
+	}
+
+	var text string
+	var err error
+	switch target {
+	case "x86":
+		text, err = x86.Generate(program, opts)
+	case "c":
+		text, err = transpile.ToC(program)
+	case "go":
+		text, err = transpile.ToGo(program)
+	case "wat":
+		text, err = wat.Generate(program, opts.Result)
+	case "llvm":
+		text, err = llvm.Generate(program, opts.Result)
+	}
+	if err != nil {
+		return errors.New(msg.Get(msg.CodegenFailed, err))
+	}
+
+	if output == "" {
+		output = strings.TrimSuffix(filename, filepath.Ext(filename)) + ext
+	}
+	if err := os.WriteFile(output, []byte(text), 0644); err != nil {
+		return errors.New(msg.Get(msg.WriteFileFailed, err))
+	}
+	fmt.Println(msg.Get(msg.Generated, output))
+	return nil
+}
+
+func execute(program *parser.Program, run, disasm bool) error {
+	comp := compiler.New()
+	if err := comp.Compile(program); err != nil {
+		return errors.New(msg.Get(msg.CompileFailed, err))
+	}
+	bytecode := comp.Bytecode()
+
+	if disasm {
+		fmt.Println(msg.Get(msg.BytecodeTitle))
+		fmt.Print(bytecode.Disassemble())
+	}
+	if !run {
+		return nil
+	}
+
+	machine := vm.New(bytecode)
+	if err := machine.Run(); err != nil {
+		return err
+	}
+
+	fmt.Println(msg.Get(msg.RunResult))
+	for i, value := range machine.Globals() {
+		if value != nil {
+			fmt.Printf("%s = %s\n", bytecode.Names[i], value.Inspect())
+		}
+	}
+	return nil
 }
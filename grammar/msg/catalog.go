@@ -0,0 +1,302 @@
+package msg
+
+// ID 消息编号
+type ID int
+
+const (
+	// 位置
+	Location ID = iota
+	SyntaxError
+
+	// 语法分析
+	ProgramMissingSemicolon
+	ProgramMissingBegin
+	ProgramMissingEnd
+	ProgramTrailing
+	ProgramForm
+	BeginWithoutEnd
+	MissingSemicolon
+	StatementSeparator
+	IfMissingLParen
+	IfMissingRParen
+	IfMissingThen
+	IfForm
+	IfNeedsThen
+	WhileMissingLParen
+	WhileMissingRParen
+	WhileMissingDo
+	WhileForm
+	WhileNeedsDo
+	ProcedureMissingSemicolon
+	ProcedureMissingBegin
+	ProcedureForm
+	NotCallable
+	ConstForm
+	VarForm
+	UnexpectedStatement
+	CannotParse
+	InvalidInteger
+	InvalidReal
+	ExpectedToken
+	NoProduction
+	UnexpectedToken
+
+	// 数据流检查
+	UsedBeforeAssigned
+
+	// 名称解析
+	DuplicateDeclaration
+	UndeclaredProcedure
+	UndeclaredIdentifier
+	NotAVariable
+	Shadows
+	ArgumentCount
+	ProcedureHasNoValue
+	ReturnOutsideProcedure
+	FunctionReturnsNothing
+	ProcedureReturnsValue
+
+	// 类型检查
+	UnknownType
+	NotConstant
+	AssignToConst
+	AssignType
+	ConditionType
+	OperandType
+	PrefixOperandType
+
+	// 字节码编译
+	UnknownOperator
+	CannotCompile
+	OperandRange
+
+	// 虚拟机
+	UnassignedVariable
+	BangOperand
+	ConditionNotBoolean
+	UnknownOpcode
+	ArithmeticOperands
+	DivisionByZero
+	ModOperands
+	CannotCompare
+	BooleanComparison
+	MinusOperand
+	StackOverflow
+
+	// 词法分析
+	LeadingZeros
+	MultipleDecimalPoints
+	DecimalPointNeedsDigits
+	IdentifierStartsWithDigit
+	IdentifierStart
+	MissingExpression
+	IllegalCharacter
+
+	// 命令行输出
+	ParseFailed
+	Warnings
+	CheckFailed
+	ProceduresUnsupported
+	ParseSucceeded
+	OptimizedProgram
+	CompileFailed
+	RuntimeFailed
+	ReadFileFailed
+	WriteFileFailed
+	Usage
+	UnknownLang
+	UnknownASTFormat
+	SSAForm
+	AfterSSA
+	UnknownParseTreeFormat
+	ParseTreeTitle
+	DerivationTitle
+	IsLL1
+	LL1Conflicts
+	UnknownTarget
+	CodegenFailed
+	Generated
+	BytecodeTitle
+	RunResult
+
+	// 类型推断
+	ChangedType
+	IntegerNotConverted
+
+	// 代码生成
+	RealUnsupported
+	NoSuchVariable
+	UnsupportedStatement
+	UnsupportedOperator
+	UnsupportedExpression
+
+	// REPL
+	ReplBanner
+	ReplHelp
+	ReplASTMode
+	ReplShowTokens
+	ReplReset
+	ReplUnknownCommand
+	On
+	Off
+
+	// 语言服务器
+	UnsupportedMethod
+	InvalidMessage
+
+	idCount
+)
+
+// translation 按 Lang 排列的各语言译文
+type translation [2]string
+
+var catalog = map[ID]translation{
+	Location:    {"第%d行第%d列: %s", "line %d, column %d: %s"},
+	SyntaxError: {"语法错误: 第%d行第%d列 %s", "syntax error: line %d, column %d: %s"},
+
+	ProgramMissingSemicolon:   {"程序头缺少分号", "missing semicolon after program header"},
+	ProgramMissingBegin:       {"程序体必须以begin开始", "program body must start with begin"},
+	ProgramMissingEnd:         {"程序必须以end.结束", "program must end with end."},
+	ProgramTrailing:           {"程序结束符.之后存在多余内容: %s", "unexpected content after final '.': %s"},
+	ProgramForm:               {"程序的形式为 program 名称; begin 语句序列 end.", "a program has the form: program name; begin statements end."},
+	BeginWithoutEnd:           {"begin缺少对应的end", "begin without matching end"},
+	MissingSemicolon:          {"语句末尾缺少分号", "missing semicolon at end of statement"},
+	StatementSeparator:        {"语句之间需要用 ; 分隔", "statements must be separated by ;"},
+	IfMissingLParen:           {"if语句缺少左括号", "missing '(' in if statement"},
+	IfMissingRParen:           {"if语句缺少右括号", "missing ')' in if statement"},
+	IfMissingThen:             {"if语句缺少then关键字", "missing 'then' in if statement"},
+	IfForm:                    {"if语句的形式为 if (条件) then 语句 [else 语句]", "an if statement has the form: if (condition) then statement [else statement]"},
+	IfNeedsThen:               {"if语句需要 then: if (条件) then 语句", "if statement needs then: if (condition) then statement"},
+	WhileMissingLParen:        {"while语句缺少左括号", "missing '(' in while statement"},
+	WhileMissingRParen:        {"while语句缺少右括号", "missing ')' in while statement"},
+	WhileMissingDo:            {"while语句缺少do关键字", "missing 'do' in while statement"},
+	WhileForm:                 {"while语句的形式为 while (条件) do 语句", "a while statement has the form: while (condition) do statement"},
+	WhileNeedsDo:              {"while语句需要 do: while (条件) do 语句", "while statement needs do: while (condition) do statement"},
+	ProcedureMissingSemicolon: {"过程声明缺少分号", "missing semicolon in procedure declaration"},
+	ProcedureMissingBegin:     {"过程体必须以begin开始", "procedure body must start with begin"},
+	ProcedureForm:             {"过程的形式为 procedure 名称(形参表); [var 变量表;] begin 语句序列 end;", "a procedure has the form: procedure name(parameters); [var variables;] begin statements end;"},
+	NotCallable:               {"只能调用过程或函数的名字: %s", "only a procedure or function name can be called: %s"},
+	ConstForm:                 {"常量定义的形式为 名称 = 常量表达式;", "a constant definition has the form: name = constant expression;"},
+	VarForm:                   {"变量声明的形式为 名称, 名称: 类型;", "a variable declaration has the form: name, name: type;"},
+	UnexpectedStatement:       {"意外的语句开始: %s", "unexpected start of statement: %s"},
+	CannotParse:               {"无法解析: %s", "cannot parse: %s"},
+	InvalidInteger:            {"无法解析 %q 为整数", "cannot parse %q as integer"},
+	InvalidReal:               {"无法解析 %q 为实数", "cannot parse %q as real"},
+	ExpectedToken:             {"期望 %s, 实际得到 %s", "expected %s, got %s"},
+	NoProduction:              {"%s 没有以 %s 开始的产生式", "no production of %s starts with %s"},
+	UnexpectedToken:           {"意外的 %s", "unexpected %s"},
+
+	UsedBeforeAssigned: {"变量 %s 可能在赋值前被使用", "variable %s may be used before being assigned"},
+
+	DuplicateDeclaration:   {"%s 重复声明", "%s is declared more than once"},
+	UndeclaredProcedure:    {"未声明的过程或函数 %s", "undeclared procedure or function %s"},
+	UndeclaredIdentifier:   {"未声明的标识符 %s", "undeclared identifier %s"},
+	NotAVariable:           {"%s 是过程或函数, 不能作为变量使用", "%s is a procedure or function, not a variable"},
+	Shadows:                {"%s 遮蔽了第 %d 行的同名声明", "%s shadows the declaration on line %d"},
+	ArgumentCount:          {"%s 需要 %d 个参数, 实际传入 %d 个", "%s takes %d arguments but %d were given"},
+	ProcedureHasNoValue:    {"过程 %s 没有返回值, 不能用在表达式中", "procedure %s has no value and cannot be used in an expression"},
+	ReturnOutsideProcedure: {"return 只能出现在过程或函数中", "return outside a procedure or function"},
+	FunctionReturnsNothing: {"函数 %s 的 return 必须给出返回值", "return in function %s must give a value"},
+	ProcedureReturnsValue:  {"过程 %s 不能返回值", "procedure %s cannot return a value"},
+
+	UnknownType:       {"未知的类型 %s (可用 integer, real, boolean)", "unknown type %s (use integer, real or boolean)"},
+	NotConstant:       {"%s 不是常量, 不能出现在常量定义中", "%s is not a constant and cannot be used in a constant definition"},
+	AssignToConst:     {"不能给常量 %s 赋值", "cannot assign to constant %s"},
+	AssignType:        {"不能把 %s 类型的值赋给 %s 类型的变量 %s", "cannot assign a value of type %s to variable %[3]s of type %[2]s"},
+	ConditionType:     {"%s 语句的条件必须是 boolean 类型, 实际为 %s", "%s condition must be of type boolean, not %s"},
+	OperandType:       {"运算符 %s 不能用于 %s 和 %s 类型的操作数", "operator %s cannot be applied to operands of type %s and %s"},
+	PrefixOperandType: {"运算符 %s 不能用于 %s 类型的操作数", "operator %s cannot be applied to an operand of type %s"},
+
+	UnknownOperator: {"未知的运算符 %s", "unknown operator %s"},
+	CannotCompile:   {"无法编译的语法节点 %T", "cannot compile syntax node %T"},
+	OperandRange:    {"%s 的操作数 %d 超出范围 0..%d (常量表或程序过大)", "operand %[2]d of %[1]s is out of range 0..%[3]d (too many constants or program too large)"},
+
+	UnassignedVariable:  {"变量 %s 未赋值", "variable %s has not been assigned"},
+	BangOperand:         {"运算符 ! 要求布尔类型的操作数", "operator ! requires a boolean operand"},
+	ConditionNotBoolean: {"条件必须为布尔类型", "condition must be a boolean"},
+	UnknownOpcode:       {"未知的操作码 %d", "unknown opcode %d"},
+	ArithmeticOperands:  {"算术运算不支持的类型: %s 和 %s", "unsupported operand types for arithmetic: %s and %s"},
+	DivisionByZero:      {"除数为0", "division by zero"},
+	ModOperands:         {"运算符 %% 要求整数类型的操作数", "operator %% requires integer operands"},
+	CannotCompare:       {"无法比较 %s 和 %s", "cannot compare %s and %s"},
+	BooleanComparison:   {"布尔值只能比较是否相等", "booleans can only be compared for equality"},
+	MinusOperand:        {"运算符 - 不支持 %s 类型", "operator - does not support type %s"},
+	StackOverflow:       {"栈溢出", "stack overflow"},
+
+	LeadingZeros:              {"非法数字格式: 不允许前导零", "illegal number format: leading zeros not allowed"},
+	MultipleDecimalPoints:     {"非法数字格式: 多个小数点", "illegal number format: multiple decimal points"},
+	DecimalPointNeedsDigits:   {"非法数字格式: 小数点后必须有数字", "illegal number format: decimal point must be followed by digits"},
+	IdentifierStartsWithDigit: {"非法标识符: 不能以数字开头", "illegal identifier: cannot start with number"},
+	IdentifierStart:           {"非法标识符: 必须以字母或下划线开头", "illegal identifier: must start with letter or underscore"},
+	MissingExpression:         {"'=' 之后缺少表达式", "missing expression after '='"},
+	IllegalCharacter:          {"非法字符", "illegal character"},
+
+	ParseFailed:           {"语法分析发现错误:", "syntax errors found:"},
+	Warnings:              {"警告:", "warnings:"},
+	CheckFailed:           {"语义检查发现错误:", "semantic errors found:"},
+	ParseSucceeded:        {"语法分析成功! 程序结构:", "parsed successfully, program structure:"},
+	OptimizedProgram:      {"优化后的程序结构:", "optimized program structure:"},
+	CompileFailed:         {"编译错误: %v", "compile error: %v"},
+	RuntimeFailed:         {"运行错误: %s", "runtime error: %s"},
+	ReadFileFailed:        {"读取文件错误: %v", "error reading file: %v"},
+	WriteFileFailed:       {"写入文件错误: %v", "error writing file: %v"},
+	ProceduresUnsupported: {"虚拟机和代码生成后端暂不支持过程和函数: %s", "the virtual machine and code generators do not support procedures and functions yet: %s"},
+	Usage: {`使用方法: mini_parser [-O] [-run] [-disasm] [-emit 目标] <文件路径>
+          mini_parser fmt [-w] [-indent 空格数] [-tabs] <文件路径>...
+          mini_parser grammar [-transform 变换] [-o 文件] [-lr 种类 | -op] [-table] [-states] [-trace 源文件] <文法文件>
+          mini_parser gen [-n 数量] [-seed 种子] [-depth 深度] [-mutate] [-o 目录] [-check] <文法文件>
+          mini_parser coverage [-html 文件] [-src 目录] <源文件或目录>...
+          mini_parser repl
+          mini_parser lsp`, `usage: mini_parser [-O] [-run] [-disasm] [-emit target] <file>
+       mini_parser fmt [-w] [-indent spaces] [-tabs] <file>...
+       mini_parser grammar [-transform transforms] [-o file] [-lr kind | -op] [-table] [-states] [-trace source] <grammar file>
+       mini_parser gen [-n count] [-seed seed] [-depth depth] [-mutate] [-o dir] [-check] <grammar file>
+       mini_parser coverage [-html file] [-src dir] <source file or dir>...
+       mini_parser repl
+       mini_parser lsp`},
+	UnknownLang:            {"未知的语言: %q (支持 zh-CN, en)", "unknown language: %q (supported: zh-CN, en)"},
+	UnknownASTFormat:       {"未知的语法树格式: %s", "unknown syntax tree format: %s"},
+	SSAForm:                {"SSA形式:", "SSA form:"},
+	AfterSSA:               {"退出SSA后:", "after leaving SSA:"},
+	UnknownParseTreeFormat: {"未知的具体语法树格式: %s", "unknown parse tree format: %s"},
+	ParseTreeTitle:         {"具体语法树:", "parse tree:"},
+	DerivationTitle:        {"最左推导:", "leftmost derivation:"},
+	IsLL1:                  {"该文法是LL(1)文法", "the grammar is LL(1)"},
+	LL1Conflicts:           {"LL(1)冲突:", "LL(1) conflicts:"},
+	UnknownTarget:          {"未知的目标: %s", "unknown target: %s"},
+	CodegenFailed:          {"代码生成错误: %v", "code generation error: %v"},
+	Generated:              {"已生成 %s", "generated %s"},
+	BytecodeTitle:          {"字节码:", "bytecode:"},
+	RunResult:              {"运行结果:", "result:"},
+
+	ChangedType:         {"变量 %s 先被赋予%s类型的值, 此处又被赋予%s类型的值", "variable %s was assigned a value of type %s before, but is assigned a value of type %s here"},
+	IntegerNotConverted: {"变量 %s 在别处被赋予real类型的值, 此处的integer值在虚拟机中不会转换为real, 请写作实数(如 1.0)或在 var 部分声明为 real", "variable %s is assigned a real value elsewhere, and the virtual machine does not convert this integer value to real; write a real literal (such as 1.0) or declare it as real in the var section"},
+
+	RealUnsupported:       {"%s后端不支持实数", "the %s backend does not support real numbers"},
+	NoSuchVariable:        {"程序中没有变量 %s", "the program has no variable %s"},
+	UnsupportedStatement:  {"%s后端不支持的语句 %T", "the %s backend does not support statement %T"},
+	UnsupportedOperator:   {"%s后端不支持的运算符 %s", "the %s backend does not support operator %s"},
+	UnsupportedExpression: {"%s后端不支持的表达式 %T", "the %s backend does not support expression %T"},
+
+	ReplBanner: {"Mini REPL, 输入 :help 查看帮助", "Mini REPL, type :help for help"},
+	ReplHelp: {`输入Mini语句并回车执行, 变量在多次输入之间保留。
+未闭合的begin、以then/do/else或运算符结尾的输入会提示继续输入。
+:ast     切换为只显示语法树(不执行)
+:tokens  切换是否显示记号序列
+:reset   清空所有变量
+:quit    退出`, `Enter Mini statements to run them; variables are kept between inputs.
+Input with an unclosed begin, or ending in then/do/else or an operator, asks for more lines.
+:ast     toggle showing only the syntax tree (without running)
+:tokens  toggle showing the token sequence
+:reset   clear all variables
+:quit    quit`},
+	ReplASTMode:        {"语法树模式: %s", "syntax tree mode: %s"},
+	ReplShowTokens:     {"显示记号: %s", "show tokens: %s"},
+	ReplReset:          {"已清空所有变量", "all variables cleared"},
+	ReplUnknownCommand: {"未知的命令 %s, 输入 :help 查看帮助", "unknown command %s, type :help for help"},
+	On:                 {"开", "on"},
+	Off:                {"关", "off"},
+
+	UnsupportedMethod: {"不支持的方法: %s", "unsupported method: %s"},
+	InvalidMessage:    {"无法解析的消息: %v", "cannot parse message: %v"},
+}
@@ -0,0 +1,67 @@
+package msg_test
+
+import (
+	"mini-parser/llvm"
+	"mini-parser/msg"
+	"mini-parser/parser"
+	"mini-parser/repl"
+	"mini-parser/token"
+	"mini-parser/transpile"
+	"mini-parser/types"
+	"mini-parser/wat"
+	"mini-parser/x86"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func parse(t *testing.T, src string) *parser.Program {
+	t.Helper()
+	p := parser.New(token.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("语法错误: %v", p.Errors())
+	}
+	return program
+}
+
+// 选择英文后, 后端、类型推断和REPL的输出中不再有汉字
+func TestEnglishOutput(t *testing.T) {
+	defer msg.SetLang(msg.Current())
+	msg.SetLang(msg.En)
+
+	var outputs []string
+	errorText := func(err error) {
+		t.Helper()
+		if err == nil {
+			t.Fatal("期望出错")
+		}
+		outputs = append(outputs, err.Error())
+	}
+
+	declared := parse(t, "program p; var r: real; begin r := 1.5 end.")
+	errorText(types.CheckIntegral(declared, "x86"))
+	_, err := x86.Generate(parse(t, "x := 1.5"), x86.Options{})
+	errorText(err)
+	_, err = wat.Generate(parse(t, "x := 1"), "y")
+	errorText(err)
+	_, err = llvm.Generate(parse(t, "x := 1"), "y")
+	errorText(err)
+	_, err = transpile.ToC(parse(t, "a := 1; a := 1.5"))
+	errorText(err)
+	_, err = transpile.ToGo(parse(t, "a := 1; if (a > 0) then a := true"))
+	errorText(err)
+
+	var out strings.Builder
+	repl.Start(strings.NewReader(":help\n:ast\n:tokens\n:reset\n:foo\n"), &out)
+	outputs = append(outputs, out.String())
+
+	for _, text := range outputs {
+		for _, r := range text {
+			if unicode.Is(unicode.Han, r) {
+				t.Errorf("英文输出中含有汉字: %s", text)
+				break
+			}
+		}
+	}
+}
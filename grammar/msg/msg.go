@@ -0,0 +1,98 @@
+package msg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Lang 诊断信息使用的语言
+type Lang int
+
+const (
+	ZhCN Lang = iota
+	En
+)
+
+func (l Lang) String() string {
+	if l == En {
+		return "en"
+	}
+	return "zh-CN"
+}
+
+// ParseLang 解析 -lang 参数或 LANG 环境变量的值, 如 zh-CN, en, en_US.UTF-8
+func ParseLang(s string) (Lang, bool) {
+	s = strings.ToLower(s)
+	if i := strings.IndexAny(s, ".@"); i >= 0 {
+		s = s[:i]
+	}
+	switch {
+	case s == "zh" || strings.HasPrefix(s, "zh-") || strings.HasPrefix(s, "zh_"):
+		return ZhCN, true
+	case s == "en" || strings.HasPrefix(s, "en-") || strings.HasPrefix(s, "en_"):
+		return En, true
+	}
+	return ZhCN, false
+}
+
+var current = ZhCN
+
+// SetLang 设置当前语言
+func SetLang(l Lang) {
+	current = l
+}
+
+// Current 返回当前语言
+func Current() Lang {
+	return current
+}
+
+// Select 按优先级选择语言: 显式指定的 flag, 其次是 LANG 环境变量, 否则为中文
+func Select(flag string) error {
+	if flag != "" {
+		l, ok := ParseLang(flag)
+		if !ok {
+			return errors.New(Get(UnknownLang, flag))
+		}
+		SetLang(l)
+		return nil
+	}
+	if l, ok := ParseLang(os.Getenv("LANG")); ok {
+		SetLang(l)
+	}
+	return nil
+}
+
+// Get 按当前语言格式化消息, 缺少译文时退回中文
+func Get(id ID, args ...interface{}) string {
+	return In(current, id, args...)
+}
+
+// In 按指定语言格式化消息
+func In(l Lang, id ID, args ...interface{}) string {
+	t, ok := catalog[id]
+	if !ok {
+		return fmt.Sprintf("<未知消息 %d>", int(id))
+	}
+	format := t[l]
+	if format == "" {
+		format = t[ZhCN]
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Missing 列出缺少某种译文的消息, 新增消息后应保持为空
+func Missing() []string {
+	var out []string
+	for id := ID(0); id < idCount; id++ {
+		t, ok := catalog[id]
+		for _, l := range []Lang{ZhCN, En} {
+			if !ok || t[l] == "" {
+				out = append(out, fmt.Sprintf("%d: %s", int(id), l))
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,10 @@
+package msg
+
+import "testing"
+
+// 每条消息都要有中文和英文译文
+func TestCatalogComplete(t *testing.T) {
+	if missing := Missing(); len(missing) != 0 {
+		t.Errorf("缺少译文的消息: %v", missing)
+	}
+}
@@ -0,0 +1,37 @@
+package object
+
+import "strconv"
+
+type ObjectType string
+
+const (
+	INTEGER_OBJ = "INTEGER"
+	REAL_OBJ    = "REAL"
+	BOOLEAN_OBJ = "BOOLEAN"
+)
+
+type Object interface {
+	Type() ObjectType
+	Inspect() string
+}
+
+type Integer struct {
+	Value int64
+}
+
+func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
+func (i *Integer) Inspect() string  { return strconv.FormatInt(i.Value, 10) }
+
+type Real struct {
+	Value float64
+}
+
+func (r *Real) Type() ObjectType { return REAL_OBJ }
+func (r *Real) Inspect() string  { return strconv.FormatFloat(r.Value, 'g', -1, 64) }
+
+type Boolean struct {
+	Value bool
+}
+
+func (b *Boolean) Type() ObjectType { return BOOLEAN_OBJ }
+func (b *Boolean) Inspect() string  { return strconv.FormatBool(b.Value) }
@@ -0,0 +1,133 @@
+package optimizer
+
+import "mini-parser/parser"
+
+// InlineConstants 把对 const 部分中常量的引用替换为折叠后的值, 返回不含常量定义的新程序。
+// 过程中与常量同名的形参和局部变量遮蔽常量。虚拟机和代码生成后端只处理变量, 需要先做这一步。
+func InlineConstants(program *parser.Program) *parser.Program {
+	if len(program.Consts) == 0 {
+		return program
+	}
+
+	values := map[string]parser.Expression{}
+	for _, c := range program.Consts {
+		values[c.Name.Value] = Expression(substitute(c.Value, values))
+	}
+
+	out := &parser.Program{
+		Token:      program.Token,
+		Name:       program.Name,
+		Vars:       program.Vars,
+		Begin:      program.Begin,
+		Statements: substituteAll(program.Statements, values),
+		End:        program.End,
+	}
+	for _, proc := range program.Procedures {
+		visible := map[string]parser.Expression{}
+		for name, v := range values {
+			visible[name] = v
+		}
+		for _, param := range proc.Parameters {
+			delete(visible, param.Value)
+		}
+		for _, local := range proc.Locals {
+			for _, name := range local.Names {
+				delete(visible, name.Value)
+			}
+		}
+		out.Procedures = append(out.Procedures, &parser.ProcedureDeclaration{
+			Token:      proc.Token,
+			Name:       proc.Name,
+			Parameters: proc.Parameters,
+			Locals:     proc.Locals,
+			Body: &parser.BlockStatement{
+				Token:      proc.Body.Token,
+				Statements: substituteAll(proc.Body.Statements, visible),
+				End:        proc.Body.End,
+			},
+		})
+	}
+	return out
+}
+
+func substituteAll(list []parser.Statement, values map[string]parser.Expression) []parser.Statement {
+	var out []parser.Statement
+	for _, s := range list {
+		out = append(out, substituteStmt(s, values))
+	}
+	return out
+}
+
+func substituteBlock(b *parser.BlockStatement, values map[string]parser.Expression) *parser.BlockStatement {
+	if b == nil {
+		return nil
+	}
+	return &parser.BlockStatement{Token: b.Token, Statements: substituteAll(b.Statements, values), End: b.End}
+}
+
+func substituteStmt(stmt parser.Statement, values map[string]parser.Expression) parser.Statement {
+	switch s := stmt.(type) {
+	case *parser.AssignStatement:
+		return &parser.AssignStatement{Token: s.Token, Name: s.Name, Value: substitute(s.Value, values)}
+	case *parser.IfExpression:
+		return &parser.IfExpression{
+			Token:       s.Token,
+			Condition:   substitute(s.Condition, values),
+			Consequence: substituteBlock(s.Consequence, values),
+			Else:        s.Else,
+			Alternative: substituteBlock(s.Alternative, values),
+		}
+	case *parser.WhileExpression:
+		return &parser.WhileExpression{
+			Token:     s.Token,
+			Condition: substitute(s.Condition, values),
+			Body:      substituteBlock(s.Body, values),
+		}
+	case *parser.BlockStatement:
+		return substituteBlock(s, values)
+	case *parser.CallStatement:
+		return &parser.CallStatement{Token: s.Token, Call: substitute(s.Call, values).(*parser.CallExpression)}
+	case *parser.ReturnStatement:
+		if s.Value == nil {
+			return s
+		}
+		return &parser.ReturnStatement{Token: s.Token, Value: substitute(s.Value, values)}
+	}
+	return stmt
+}
+
+// substitute 替换表达式中的常量; 值为字面量时换用引用处的位置, 以便运行错误指向引用处
+func substitute(expr parser.Expression, values map[string]parser.Expression) parser.Expression {
+	switch e := expr.(type) {
+	case *parser.Identifier:
+		v, ok := values[e.Value]
+		if !ok {
+			return e
+		}
+		switch lit := v.(type) {
+		case *parser.IntegerLiteral:
+			return intLiteral(e, lit.Value)
+		case *parser.RealLiteral:
+			return realLiteral(e, lit.Value)
+		case *parser.Boolean:
+			return boolLiteral(e, lit.Value)
+		}
+		return v
+	case *parser.PrefixExpression:
+		return &parser.PrefixExpression{Token: e.Token, Operator: e.Operator, Right: substitute(e.Right, values)}
+	case *parser.InfixExpression:
+		return &parser.InfixExpression{
+			Token:    e.Token,
+			Left:     substitute(e.Left, values),
+			Operator: e.Operator,
+			Right:    substitute(e.Right, values),
+		}
+	case *parser.CallExpression:
+		call := &parser.CallExpression{Token: e.Token, Function: e.Function, Rparen: e.Rparen}
+		for _, arg := range e.Arguments {
+			call.Arguments = append(call.Arguments, substitute(arg, values))
+		}
+		return call
+	}
+	return expr
+}
@@ -0,0 +1,282 @@
+package optimizer
+
+import (
+	"math"
+	"mini-parser/parser"
+	"mini-parser/token"
+	"strconv"
+)
+
+// Expression 折叠常量子表达式并应用代数恒等式, 结果节点沿用原节点的起始位置
+func Expression(expr parser.Expression) parser.Expression {
+	switch e := expr.(type) {
+	case *parser.PrefixExpression:
+		right := Expression(e.Right)
+		if folded := foldPrefix(e, right); folded != nil {
+			return folded
+		}
+		return &parser.PrefixExpression{Token: e.Token, Operator: e.Operator, Right: right}
+	case *parser.InfixExpression:
+		left := Expression(e.Left)
+		right := Expression(e.Right)
+		if folded := foldInfix(e, left, right); folded != nil {
+			return folded
+		}
+		if simplified := simplify(e, left, right); simplified != nil {
+			return simplified
+		}
+		return &parser.InfixExpression{Token: e.Token, Left: left, Operator: e.Operator, Right: right}
+	case *parser.CallExpression:
+		call := &parser.CallExpression{Token: e.Token, Function: e.Function, Rparen: e.Rparen}
+		for _, arg := range e.Arguments {
+			call.Arguments = append(call.Arguments, Expression(arg))
+		}
+		return call
+	default:
+		return expr
+	}
+}
+
+func foldPrefix(e *parser.PrefixExpression, right parser.Expression) parser.Expression {
+	switch r := right.(type) {
+	case *parser.IntegerLiteral:
+		if e.Operator == "-" {
+			return intLiteral(e, -r.Value)
+		}
+	case *parser.RealLiteral:
+		if e.Operator == "-" {
+			return realLiteral(e, -r.Value)
+		}
+	case *parser.Boolean:
+		if e.Operator == "!" {
+			return boolLiteral(e, !r.Value)
+		}
+	}
+	return nil
+}
+
+func foldInfix(e *parser.InfixExpression, left, right parser.Expression) parser.Expression {
+	if l, ok := left.(*parser.IntegerLiteral); ok {
+		if r, ok := right.(*parser.IntegerLiteral); ok {
+			return foldInteger(e, l.Value, r.Value)
+		}
+	}
+	if l, ok := left.(*parser.Boolean); ok {
+		if r, ok := right.(*parser.Boolean); ok {
+			return foldBoolean(e, l.Value, r.Value)
+		}
+	}
+	l, lok := realValue(left)
+	r, rok := realValue(right)
+	if lok && rok {
+		return foldReal(e, l, r)
+	}
+	return nil
+}
+
+func foldInteger(e *parser.InfixExpression, l, r int64) parser.Expression {
+	switch e.Operator {
+	case "+":
+		return intLiteral(e, l+r)
+	case "-":
+		return intLiteral(e, l-r)
+	case "*":
+		return intLiteral(e, l*r)
+	case "/", "%":
+		// 除数为0时保留原表达式, 由运行时报告错误
+		if r == 0 {
+			return nil
+		}
+		if e.Operator == "/" {
+			return intLiteral(e, l/r)
+		}
+		return intLiteral(e, l%r)
+	}
+	return compare(e, float64(l), float64(r))
+}
+
+func foldReal(e *parser.InfixExpression, l, r float64) parser.Expression {
+	switch e.Operator {
+	case "+":
+		return realLiteral(e, l+r)
+	case "-":
+		return realLiteral(e, l-r)
+	case "*":
+		return realLiteral(e, l*r)
+	case "/":
+		if r == 0 {
+			return nil
+		}
+		return realLiteral(e, l/r)
+	}
+	return compare(e, l, r)
+}
+
+func compare(e *parser.InfixExpression, l, r float64) parser.Expression {
+	switch e.Operator {
+	case "<":
+		return boolLiteral(e, l < r)
+	case ">":
+		return boolLiteral(e, l > r)
+	case "<=":
+		return boolLiteral(e, l <= r)
+	case ">=":
+		return boolLiteral(e, l >= r)
+	case "=":
+		return boolLiteral(e, l == r)
+	case "!=":
+		return boolLiteral(e, l != r)
+	}
+	return nil
+}
+
+func foldBoolean(e *parser.InfixExpression, l, r bool) parser.Expression {
+	switch e.Operator {
+	case "&&":
+		return boolLiteral(e, l && r)
+	case "||":
+		return boolLiteral(e, l || r)
+	case "=":
+		return boolLiteral(e, l == r)
+	case "!=":
+		return boolLiteral(e, l != r)
+	}
+	return nil
+}
+
+// simplify 应用 x+0, x-0, x*1, x/1 以及布尔短路等恒等式
+func simplify(e *parser.InfixExpression, left, right parser.Expression) parser.Expression {
+	switch e.Operator {
+	case "+":
+		if isIdentity(right, 0, left) {
+			return left
+		}
+		if isIdentity(left, 0, right) {
+			return right
+		}
+	case "-":
+		if isIdentity(right, 0, left) {
+			return left
+		}
+	case "*":
+		if isIdentity(right, 1, left) {
+			return left
+		}
+		if isIdentity(left, 1, right) {
+			return right
+		}
+	case "/":
+		if isIdentity(right, 1, left) {
+			return left
+		}
+	case "&&":
+		if b, ok := left.(*parser.Boolean); ok {
+			if b.Value {
+				return right
+			}
+			return left
+		}
+		if b, ok := right.(*parser.Boolean); ok && b.Value {
+			return left
+		}
+	case "||":
+		if b, ok := left.(*parser.Boolean); ok {
+			if b.Value {
+				return left
+			}
+			return right
+		}
+		if b, ok := right.(*parser.Boolean); ok && !b.Value {
+			return left
+		}
+	}
+	return nil
+}
+
+// isIdentity 判断常量 expr 的值是否为 v, 并且去掉它之后结果的类型不变:
+// 整数常量不改变另一操作数的类型, 实数常量会把整数运算变为实数运算, 只能在另一操作数已知为实数时去掉
+func isIdentity(expr parser.Expression, v float64, other parser.Expression) bool {
+	switch e := expr.(type) {
+	case *parser.IntegerLiteral:
+		return float64(e.Value) == v
+	case *parser.RealLiteral:
+		return e.Value == v && isReal(other)
+	}
+	return false
+}
+
+// isReal 不依赖变量类型就能确定表达式的值为实数
+func isReal(expr parser.Expression) bool {
+	switch e := expr.(type) {
+	case *parser.RealLiteral:
+		return true
+	case *parser.PrefixExpression:
+		return e.Operator == "-" && isReal(e.Right)
+	case *parser.InfixExpression:
+		switch e.Operator {
+		case "+", "-", "*", "/":
+			return isReal(e.Left) || isReal(e.Right)
+		}
+	}
+	return false
+}
+
+func realValue(expr parser.Expression) (float64, bool) {
+	switch e := expr.(type) {
+	case *parser.IntegerLiteral:
+		return float64(e.Value), true
+	case *parser.RealLiteral:
+		return e.Value, true
+	}
+	return 0, false
+}
+
+// startToken 返回表达式最左端的记号, 用作折叠结果的位置
+func startToken(expr parser.Expression) token.Token {
+	switch e := expr.(type) {
+	case *parser.InfixExpression:
+		return startToken(e.Left)
+	case *parser.Identifier:
+		return e.Token
+	case *parser.IntegerLiteral:
+		return e.Token
+	case *parser.RealLiteral:
+		return e.Token
+	case *parser.Boolean:
+		return e.Token
+	case *parser.PrefixExpression:
+		return e.Token
+	case *parser.CallExpression:
+		return e.Token
+	}
+	return token.Token{}
+}
+
+func intLiteral(orig parser.Expression, v int64) parser.Expression {
+	tok := startToken(orig)
+	tok.Type = token.NUMBER
+	tok.Literal = strconv.FormatInt(v, 10)
+	return &parser.IntegerLiteral{Token: tok, Value: v}
+}
+
+func realLiteral(orig parser.Expression, v float64) parser.Expression {
+	tok := startToken(orig)
+	tok.Type = token.REAL
+	tok.Literal = strconv.FormatFloat(v, 'f', -1, 64)
+	if v == math.Trunc(v) && !math.IsInf(v, 0) {
+		// 保证输出仍能被识别为实数
+		tok.Literal += ".0"
+	}
+	return &parser.RealLiteral{Token: tok, Value: v}
+}
+
+func boolLiteral(orig parser.Expression, v bool) parser.Expression {
+	tok := startToken(orig)
+	tok.Type = token.FALSE
+	tok.Literal = "false"
+	if v {
+		tok.Type = token.TRUE
+		tok.Literal = "true"
+	}
+	return &parser.Boolean{Token: tok, Value: v}
+}
@@ -0,0 +1,79 @@
+package optimizer
+
+import (
+	"mini-parser/parser"
+	"mini-parser/token"
+	"testing"
+)
+
+func parse(t *testing.T, src string) *parser.Program {
+	t.Helper()
+	p := parser.New(token.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("语法错误: %v", p.Errors())
+	}
+	return program
+}
+
+func TestExpression(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		// 常量折叠
+		{"1 + 2 * 3", "7"},
+		{"7 / 2", "3"},
+		{"7 % 3", "1"},
+		{"-(2 - 5)", "3"},
+		{"7 / 1.0", "7.0"},
+		{"1.5 * 2", "3.0"},
+		{"2 < 3.5", "true"},
+		{"!(1 = 1)", "false"},
+		{"true && false", "false"},
+		{"x / 0", "(x / 0)"},
+		{"1 / 0", "(1 / 0)"},
+		// 整数单位元不改变另一操作数的类型
+		{"x + 0", "x"},
+		{"0 + x", "x"},
+		{"x - 0", "x"},
+		{"x * 1", "x"},
+		{"1 * x", "x"},
+		{"x / 1", "x"},
+		{"x * (2 - 1)", "x"},
+		// 实数单位元会把整数运算变为实数运算, x 的类型未知时保留
+		{"x + 0.0", "(x + 0.0)"},
+		{"x - 0.0", "(x - 0.0)"},
+		{"x * 1.0", "(x * 1.0)"},
+		{"1.0 * x", "(1.0 * x)"},
+		{"x / 1.0", "(x / 1.0)"},
+		// 另一操作数已知为实数时可以去掉
+		{"(x + 0.5) * 1.0", "(x + 0.5)"},
+		{"-(x * 2.5) / 1.0", "(-(x * 2.5))"},
+		{"0 - x", "(0 - x)"},
+		// 布尔恒等式
+		{"true && c", "c"},
+		{"c && true", "c"},
+		{"false && c", "false"},
+		{"true || c", "true"},
+		{"false || c", "c"},
+		{"c || false", "c"},
+		{"c && false", "(c && false)"},
+	}
+	for _, tt := range tests {
+		program := parse(t, "r := "+tt.src)
+		expr := program.Statements[0].(*parser.AssignStatement).Value
+		if got := Expression(expr).String(); got != tt.want {
+			t.Errorf("%s 优化为 %s, 期望 %s", tt.src, got, tt.want)
+		}
+	}
+}
+
+// 折叠结果沿用原表达式最左端记号的位置
+func TestFoldPosition(t *testing.T) {
+	program := parse(t, "r :=\n  (1 + 2) * 3")
+	folded := Expression(program.Statements[0].(*parser.AssignStatement).Value).(*parser.IntegerLiteral)
+	if folded.Token.Line != 2 || folded.Token.Column != 4 || folded.Token.Literal != "9" {
+		t.Errorf("折叠结果的记号为 %+v", folded.Token)
+	}
+}
@@ -0,0 +1,97 @@
+package optimizer
+
+import "mini-parser/parser"
+
+// Optimize 对程序做常量折叠、代数化简和死分支删除, 返回新的语法树, 原语法树不被修改
+func Optimize(program *parser.Program) *parser.Program {
+	out := &parser.Program{
+		Token:      program.Token,
+		Name:       program.Name,
+		Consts:     program.Consts,
+		Vars:       program.Vars,
+		Begin:      program.Begin,
+		Statements: Statements(program.Statements),
+		End:        program.End,
+	}
+	for _, proc := range program.Procedures {
+		out.Procedures = append(out.Procedures, &parser.ProcedureDeclaration{
+			Token:      proc.Token,
+			Name:       proc.Name,
+			Parameters: proc.Parameters,
+			Locals:     proc.Locals,
+			Body:       block(proc.Body),
+		})
+	}
+	return out
+}
+
+// Statements 优化语句序列, 被删除的分支不再出现在结果中
+func Statements(list []parser.Statement) []parser.Statement {
+	var out []parser.Statement
+	for _, s := range list {
+		out = append(out, Statement(s)...)
+	}
+	return out
+}
+
+// Statement 优化单条语句; 条件为常量的分支语句被替换为实际执行的分支
+func Statement(stmt parser.Statement) []parser.Statement {
+	switch s := stmt.(type) {
+	case *parser.AssignStatement:
+		return []parser.Statement{&parser.AssignStatement{
+			Token: s.Token,
+			Name:  s.Name,
+			Value: Expression(s.Value),
+		}}
+	case *parser.CallStatement:
+		return []parser.Statement{&parser.CallStatement{
+			Token: s.Token,
+			Call:  Expression(s.Call).(*parser.CallExpression),
+		}}
+	case *parser.ReturnStatement:
+		out := &parser.ReturnStatement{Token: s.Token}
+		if s.Value != nil {
+			out.Value = Expression(s.Value)
+		}
+		return []parser.Statement{out}
+	case *parser.BlockStatement:
+		return []parser.Statement{block(s)}
+	case *parser.IfExpression:
+		cond := Expression(s.Condition)
+		if b, ok := cond.(*parser.Boolean); ok {
+			if b.Value {
+				return Statements(s.Consequence.Statements)
+			}
+			if s.Alternative != nil {
+				return Statements(s.Alternative.Statements)
+			}
+			return nil
+		}
+		out := &parser.IfExpression{
+			Token:       s.Token,
+			Condition:   cond,
+			Consequence: block(s.Consequence),
+		}
+		if s.Alternative != nil {
+			out.Else = s.Else
+			out.Alternative = block(s.Alternative)
+		}
+		return []parser.Statement{out}
+	case *parser.WhileExpression:
+		cond := Expression(s.Condition)
+		if b, ok := cond.(*parser.Boolean); ok && !b.Value {
+			return nil
+		}
+		return []parser.Statement{&parser.WhileExpression{
+			Token:     s.Token,
+			Condition: cond,
+			Body:      block(s.Body),
+		}}
+	default:
+		return []parser.Statement{stmt}
+	}
+}
+
+func block(b *parser.BlockStatement) *parser.BlockStatement {
+	return &parser.BlockStatement{Token: b.Token, Statements: Statements(b.Statements), End: b.End}
+}
@@ -0,0 +1,31 @@
+package optimizer
+
+import (
+	"mini-parser/parser"
+	"testing"
+)
+
+func TestOptimize(t *testing.T) {
+	program := parse(t, `program p;
+begin
+    if (1 > 2) then x := 1 else x := 2;
+    while (false) do x := x + 1;
+    if (x > 0) then
+    begin
+        y := x * 1
+    end
+end.`)
+	out := Optimize(program)
+	if got, want := out.String(), "x := 2;if(x > 0) then y := x;"; got != want {
+		t.Errorf("优化结果 %s, 期望 %s", got, want)
+	}
+
+	// 语句块保留 begin 和 end 的位置
+	block := out.Statements[1].(*parser.IfExpression).Consequence
+	if block.Token.Line != 6 || block.End.Line != 8 {
+		t.Errorf("语句块的位置为 %d-%d, 期望 6-8", block.Token.Line, block.End.Line)
+	}
+	if out.End != program.End {
+		t.Errorf("程序末尾的 end 为 %+v", out.End)
+	}
+}
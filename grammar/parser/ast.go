@@ -18,7 +18,14 @@ type Expression interface {
 }
 
 type Program struct {
+	Token      token.Token // program关键字, 无程序头时为空
+	Name       *Identifier
+	Consts     []*ConstDeclaration
+	Vars       []*VarDeclaration
+	Procedures []*ProcedureDeclaration // 程序头与主程序的begin之间声明的过程和函数
+	Begin      token.Token             // 主程序的begin, 无程序头时为空
 	Statements []Statement
+	End        token.Token // 程序末尾的end, 无程序头时为空
 }
 
 func (ie *IfExpression) statementNode()    {} // Add this for if statements
@@ -33,6 +40,16 @@ func (p *Program) TokenLiteral() string {
 
 func (p *Program) String() string {
 	var out string
+	if len(p.Consts) > 0 {
+		out += "const "
+		for _, c := range p.Consts {
+			out += c.String()
+		}
+	}
+	out += varSection(p.Vars)
+	for _, proc := range p.Procedures {
+		out += proc.String()
+	}
 	for _, s := range p.Statements {
 		out += s.String()
 	}
@@ -57,6 +74,15 @@ func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
+type RealLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (rl *RealLiteral) expressionNode()      {}
+func (rl *RealLiteral) TokenLiteral() string { return rl.Token.Literal }
+func (rl *RealLiteral) String() string       { return rl.Token.Literal }
+
 type Boolean struct {
 	Token token.Token
 	Value bool
@@ -92,8 +118,9 @@ func (ie *InfixExpression) String() string {
 }
 
 type BlockStatement struct {
-	Token      token.Token
+	Token      token.Token // begin, 或then/else/do之后单条语句的第一个记号
 	Statements []Statement
+	End        token.Token // 与begin对应的end
 }
 
 func (bs *BlockStatement) statementNode()       {}
@@ -110,6 +137,7 @@ type IfExpression struct {
 	Token       token.Token
 	Condition   Expression
 	Consequence *BlockStatement
+	Else        token.Token // 没有else部分时为空
 	Alternative *BlockStatement
 }
 
@@ -157,3 +185,116 @@ func (as *AssignStatement) String() string {
 	out += ";"
 	return out
 }
+
+// ProcedureDeclaration 过程或函数的声明, 只有函数可以用 return 返回值
+type ProcedureDeclaration struct {
+	Token      token.Token // procedure 或 function
+	Name       *Identifier
+	Parameters []*Identifier
+	Locals     []*VarDeclaration // var 部分声明的局部变量
+	Body       *BlockStatement
+}
+
+// IsFunction 是否为函数声明
+func (pd *ProcedureDeclaration) IsFunction() bool { return pd.Token.Type == token.FUNCTION }
+
+func (pd *ProcedureDeclaration) TokenLiteral() string { return pd.Token.Literal }
+func (pd *ProcedureDeclaration) String() string {
+	out := pd.Token.Literal + " " + pd.Name.String() + "(" + identList(pd.Parameters) + "); "
+	return out + varSection(pd.Locals) + "begin " + pd.Body.String() + " end;"
+}
+
+func identList(idents []*Identifier) string {
+	var out string
+	for i, ident := range idents {
+		if i > 0 {
+			out += ", "
+		}
+		out += ident.String()
+	}
+	return out
+}
+
+type ReturnStatement struct {
+	Token token.Token
+	Value Expression // 过程中的 return 没有返回值
+}
+
+func (rs *ReturnStatement) statementNode()       {}
+func (rs *ReturnStatement) TokenLiteral() string { return rs.Token.Literal }
+func (rs *ReturnStatement) String() string {
+	out := "return"
+	if rs.Value != nil {
+		out += " " + rs.Value.String()
+	}
+	return out + ";"
+}
+
+type CallExpression struct {
+	Token     token.Token // 被调用的过程名
+	Function  *Identifier
+	Arguments []Expression
+	Rparen    token.Token
+}
+
+func (ce *CallExpression) expressionNode()      {}
+func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CallExpression) String() string {
+	out := ce.Function.String() + "("
+	for i, arg := range ce.Arguments {
+		if i > 0 {
+			out += ", "
+		}
+		out += arg.String()
+	}
+	return out + ")"
+}
+
+// CallStatement 作为语句的过程调用, 函数的返回值被丢弃
+type CallStatement struct {
+	Token token.Token
+	Call  *CallExpression
+}
+
+func (cs *CallStatement) statementNode()       {}
+func (cs *CallStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs *CallStatement) String() string       { return cs.Call.String() + ";" }
+
+// ConstDeclaration const 部分中的一个常量定义, 如 max = 100;
+type ConstDeclaration struct {
+	Token token.Token // 常量名
+	Name  *Identifier
+	Value Expression
+}
+
+func (cd *ConstDeclaration) TokenLiteral() string { return cd.Token.Literal }
+func (cd *ConstDeclaration) String() string {
+	return cd.Name.String() + " = " + cd.Value.String() + "; "
+}
+
+// VarDeclaration var 部分中共用一个类型的一组变量, 如 x, y: integer; 没有标注类型时 Type 为nil
+type VarDeclaration struct {
+	Token token.Token // 第一个变量名
+	Names []*Identifier
+	Type  *Identifier
+}
+
+func (vd *VarDeclaration) TokenLiteral() string { return vd.Token.Literal }
+func (vd *VarDeclaration) String() string {
+	out := identList(vd.Names)
+	if vd.Type != nil {
+		out += ": " + vd.Type.String()
+	}
+	return out + "; "
+}
+
+func varSection(decls []*VarDeclaration) string {
+	if len(decls) == 0 {
+		return ""
+	}
+	out := "var "
+	for _, d := range decls {
+		out += d.String()
+	}
+	return out
+}
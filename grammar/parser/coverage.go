@@ -0,0 +1,40 @@
+package parser
+
+import (
+	"embed"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+)
+
+// Site 源文件中的一处调用
+type Site struct {
+	File string // 文件名, 不含目录
+	Line int
+}
+
+// sources 编译进程序的 parser 包源文件, 行号与 runtime.Caller 报告的一致
+//
+//go:embed *.go
+var sources embed.FS
+
+// Sources 返回 parser 包自身的源文件, 供覆盖率统计扫描报错分支, 不依赖当前目录
+func Sources() fs.FS {
+	return sources
+}
+
+// RecordErrors 把之后每个 addError 和 expectPeek 调用处报错的次数累加到 hits 中, 供覆盖率统计使用。
+// hits 为nil时停止记录。多个分析器可以共用一个 hits, 但不能在不同的goroutine中同时使用
+func (p *Parser) RecordErrors(hits map[Site]int) {
+	p.hits = hits
+}
+
+// recordError 记录报错的调用位置, skip 为从调用者到该位置的栈帧数
+func (p *Parser) recordError(skip int) {
+	if p.hits == nil {
+		return
+	}
+	if _, file, line, ok := runtime.Caller(skip + 1); ok {
+		p.hits[Site{filepath.Base(file), line}]++
+	}
+}
@@ -0,0 +1,249 @@
+package parser
+
+import (
+	"fmt"
+	"mini-parser/token"
+	"strings"
+	"unicode/utf8"
+)
+
+// child 带有字段名的子节点
+type child struct {
+	label string
+	node  Node
+}
+
+// children 按源程序顺序返回节点的子节点, 与 Inspect 的访问顺序一致
+func children(node Node) []child {
+	var out []child
+	add := func(label string, n Node) {
+		if !isNilNode(n) {
+			out = append(out, child{label, n})
+		}
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		for i, c := range n.Consts {
+			add(fmt.Sprintf("Consts[%d]", i), c)
+		}
+		for i, v := range n.Vars {
+			add(fmt.Sprintf("Vars[%d]", i), v)
+		}
+		for i, proc := range n.Procedures {
+			add(fmt.Sprintf("Procedures[%d]", i), proc)
+		}
+		for i, s := range n.Statements {
+			add(fmt.Sprintf("Statements[%d]", i), s)
+		}
+	case *ProcedureDeclaration:
+		for i, param := range n.Parameters {
+			add(fmt.Sprintf("Parameters[%d]", i), param)
+		}
+		for i, local := range n.Locals {
+			add(fmt.Sprintf("Locals[%d]", i), local)
+		}
+		add("Body", n.Body)
+	case *ConstDeclaration:
+		add("Value", n.Value)
+	case *VarDeclaration:
+		for i, name := range n.Names {
+			add(fmt.Sprintf("Names[%d]", i), name)
+		}
+		add("Type", n.Type)
+	case *ReturnStatement:
+		add("Value", n.Value)
+	case *CallStatement:
+		add("Call", n.Call)
+	case *CallExpression:
+		for i, arg := range n.Arguments {
+			add(fmt.Sprintf("Arguments[%d]", i), arg)
+		}
+	case *BlockStatement:
+		for i, s := range n.Statements {
+			add(fmt.Sprintf("Statements[%d]", i), s)
+		}
+	case *AssignStatement:
+		add("Name", n.Name)
+		add("Value", n.Value)
+	case *IfExpression:
+		add("Condition", n.Condition)
+		add("Consequence", n.Consequence)
+		add("Alternative", n.Alternative)
+	case *WhileExpression:
+		add("Condition", n.Condition)
+		add("Body", n.Body)
+	case *PrefixExpression:
+		add("Right", n.Right)
+	case *InfixExpression:
+		add("Left", n.Left)
+		add("Right", n.Right)
+	}
+	return out
+}
+
+// describe 返回节点类型以及运算符、字面量或名字
+func describe(node Node) (kind, detail string) {
+	kind = strings.TrimPrefix(fmt.Sprintf("%T", node), "*parser.")
+	switch n := node.(type) {
+	case *Program:
+		if n.Name != nil {
+			detail = n.Name.Value
+		}
+	case *Identifier:
+		detail = n.Value
+	case *IntegerLiteral, *RealLiteral, *Boolean:
+		detail = n.TokenLiteral()
+	case *PrefixExpression:
+		detail = n.Operator
+	case *InfixExpression:
+		detail = n.Operator
+	case *ProcedureDeclaration:
+		detail = n.Token.Literal + " " + n.Name.Value
+	case *CallExpression:
+		detail = n.Function.Value
+	case *ConstDeclaration:
+		detail = n.Name.Value
+	}
+	return kind, detail
+}
+
+// tokens 返回节点自身(不含子节点)在源程序中的记号
+func tokens(node Node) []token.Token {
+	switch n := node.(type) {
+	case *Program:
+		return []token.Token{n.Token, n.End}
+	case *BlockStatement:
+		return []token.Token{n.Token, n.End}
+	case *Identifier:
+		return []token.Token{n.Token}
+	case *IntegerLiteral:
+		return []token.Token{n.Token}
+	case *RealLiteral:
+		return []token.Token{n.Token}
+	case *Boolean:
+		return []token.Token{n.Token}
+	case *PrefixExpression:
+		return []token.Token{n.Token}
+	case *InfixExpression:
+		return []token.Token{n.Token}
+	case *AssignStatement:
+		return []token.Token{n.Token}
+	case *IfExpression:
+		return []token.Token{n.Token}
+	case *WhileExpression:
+		return []token.Token{n.Token}
+	case *ProcedureDeclaration:
+		return []token.Token{n.Token, n.Name.Token}
+	case *ReturnStatement:
+		return []token.Token{n.Token}
+	case *ConstDeclaration:
+		return []token.Token{n.Token}
+	case *VarDeclaration:
+		return []token.Token{n.Token}
+	case *CallStatement:
+		return []token.Token{n.Token}
+	case *CallExpression:
+		return []token.Token{n.Token, n.Rparen}
+	}
+	return nil
+}
+
+// Span 返回节点覆盖的源程序范围, 结束位置为最后一个字符所在的列
+func Span(node Node) (startLine, startCol, endLine, endCol int) {
+	Inspect(node, func(n Node) bool {
+		for _, tok := range tokens(n) {
+			if tok.Line == 0 {
+				continue
+			}
+			if startLine == 0 || tok.Line < startLine || tok.Line == startLine && tok.Column < startCol {
+				startLine, startCol = tok.Line, tok.Column
+			}
+			last := tok.Column + utf8.RuneCountInString(tok.Literal) - 1
+			if tok.Line > endLine || tok.Line == endLine && last > endCol {
+				endLine, endCol = tok.Line, last
+			}
+		}
+		return true
+	})
+	return
+}
+
+func spanString(node Node) string {
+	startLine, startCol, endLine, endCol := Span(node)
+	if startLine == 0 {
+		return "[]"
+	}
+	return fmt.Sprintf("[%d:%d-%d:%d]", startLine, startCol, endLine, endCol)
+}
+
+// Tree 以缩进的ASCII树输出语法树, 每行包含字段名、节点类型、运算符或字面量以及范围
+func Tree(node Node) string {
+	var out strings.Builder
+	var visit func(n Node, label, prefix, childPrefix string)
+	visit = func(n Node, label, prefix, childPrefix string) {
+		kind, detail := describe(n)
+		out.WriteString(prefix)
+		if label != "" {
+			out.WriteString(label + ": ")
+		}
+		out.WriteString(kind)
+		if detail != "" {
+			out.WriteString(" " + detail)
+		}
+		out.WriteString(" " + spanString(n) + "\n")
+
+		kids := children(n)
+		for i, c := range kids {
+			if i == len(kids)-1 {
+				visit(c.node, c.label, childPrefix+"└── ", childPrefix+"    ")
+			} else {
+				visit(c.node, c.label, childPrefix+"├── ", childPrefix+"│   ")
+			}
+		}
+	}
+	if !isNilNode(node) {
+		visit(node, "", "", "")
+	}
+	return out.String()
+}
+
+// Dot 以Graphviz DOT格式输出语法树, 每个节点对应一个图节点, 边上标注字段名
+func Dot(node Node) string {
+	var out strings.Builder
+	out.WriteString("digraph AST {\n")
+	out.WriteString("\tnode [shape=box, fontname=\"monospace\"];\n")
+
+	count := 0
+	var visit func(n Node) int
+	visit = func(n Node) int {
+		id := count
+		count++
+		kind, detail := describe(n)
+		label := kind
+		if detail != "" {
+			label += "\n" + detail
+		}
+		label += "\n" + spanString(n)
+		fmt.Fprintf(&out, "\tn%d [label=%s];\n", id, dotQuote(label))
+		for _, c := range children(n) {
+			childID := visit(c.node)
+			fmt.Fprintf(&out, "\tn%d -> n%d [label=%s];\n", id, childID, dotQuote(c.label))
+		}
+		return id
+	}
+	if !isNilNode(node) {
+		visit(node)
+	}
+
+	out.WriteString("}\n")
+	return out.String()
+}
+
+// dotQuote 转义DOT字符串中的引号、反斜杠和换行
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"mini-parser/token"
+	"testing"
+)
+
+func parse(t *testing.T, src string) *Program {
+	t.Helper()
+	p := New(token.New(src))
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("%q: %v", src, errs)
+	}
+	return program
+}
+
+const dumpSource = `program p;
+var x: integer;
+begin
+  x := -1 + 2 * x;
+  if (x > 0) then f(x, 1) else x := 0
+end.
+`
+
+// 子节点按源程序顺序排列并标出字段名, 范围按字符计列号
+func TestTree(t *testing.T) {
+	got := Tree(parse(t, dumpSource))
+	want := `Program p [1:1-6:3]
+├── Vars[0]: VarDeclaration [2:5-2:14]
+│   ├── Names[0]: Identifier x [2:5-2:5]
+│   └── Type: Identifier integer [2:8-2:14]
+├── Statements[0]: AssignStatement [4:3-4:17]
+│   ├── Name: Identifier x [4:3-4:3]
+│   └── Value: InfixExpression + [4:8-4:17]
+│       ├── Left: PrefixExpression - [4:8-4:9]
+│       │   └── Right: IntegerLiteral 1 [4:9-4:9]
+│       └── Right: InfixExpression * [4:13-4:17]
+│           ├── Left: IntegerLiteral 2 [4:13-4:13]
+│           └── Right: Identifier x [4:17-4:17]
+└── Statements[1]: IfExpression [5:3-5:37]
+    ├── Condition: InfixExpression > [5:7-5:11]
+    │   ├── Left: Identifier x [5:7-5:7]
+    │   └── Right: IntegerLiteral 0 [5:11-5:11]
+    ├── Consequence: BlockStatement [5:19-5:25]
+    │   └── Statements[0]: CallStatement [5:19-5:25]
+    │       └── Call: CallExpression f [5:19-5:25]
+    │           ├── Arguments[0]: Identifier x [5:21-5:21]
+    │           └── Arguments[1]: IntegerLiteral 1 [5:24-5:24]
+    └── Alternative: BlockStatement [5:32-5:37]
+        └── Statements[0]: AssignStatement [5:32-5:37]
+            ├── Name: Identifier x [5:32-5:32]
+            └── Value: IntegerLiteral 0 [5:37-5:37]
+`
+	if got != want {
+		t.Errorf("语法树\n%s\n期望\n%s", got, want)
+	}
+
+	if got := Tree(nil); got != "" {
+		t.Errorf("空节点输出 %q", got)
+	}
+}
+
+func TestDot(t *testing.T) {
+	got := Dot(parse(t, "x := a + 1"))
+	want := `digraph AST {
+	node [shape=box, fontname="monospace"];
+	n0 [label="Program\n[1:1-1:10]"];
+	n1 [label="AssignStatement\n[1:1-1:10]"];
+	n2 [label="Identifier\nx\n[1:1-1:1]"];
+	n1 -> n2 [label="Name"];
+	n3 [label="InfixExpression\n+\n[1:6-1:10]"];
+	n4 [label="Identifier\na\n[1:6-1:6]"];
+	n3 -> n4 [label="Left"];
+	n5 [label="IntegerLiteral\n1\n[1:10-1:10]"];
+	n3 -> n5 [label="Right"];
+	n1 -> n3 [label="Value"];
+	n0 -> n1 [label="Statements[0]"];
+}
+`
+	if got != want {
+		t.Errorf("DOT\n%s\n期望\n%s", got, want)
+	}
+
+	if got := dotQuote("a\"b\\c\nd"); got != `"a\"b\\c\nd"` {
+		t.Errorf("dotQuote = %s", got)
+	}
+}
+
+// 范围覆盖所有子节点的记号, 汉字按一列计; 括号不在语法树中, 不计入范围
+func TestSpan(t *testing.T) {
+	program := parse(t, "变量 := (总数 + 1) * 2")
+	stmt := program.Statements[0].(*AssignStatement)
+	tests := []struct {
+		node Node
+		want string
+	}{
+		{stmt, "[1:1-1:18]"},
+		{stmt.Name, "[1:1-1:2]"},
+		{stmt.Value, "[1:8-1:18]"},
+		{stmt.Value.(*InfixExpression).Left, "[1:8-1:13]"},
+		{&Program{}, "[]"},
+	}
+	for _, tt := range tests {
+		if got := spanString(tt.node); got != tt.want {
+			t.Errorf("%s 的范围为 %s, 期望 %s", tt.node, got, tt.want)
+		}
+	}
+}
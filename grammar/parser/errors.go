@@ -1,15 +1,17 @@
 package parser
 
-import "fmt"
+import "mini-parser/msg"
 
 type ParserError struct {
 	Line    int
 	Column  int
+	Length  int // 出错记号的字符数
 	Message string
+	Notes   []string
 }
 
 func (e ParserError) Error() string {
-	return fmt.Sprintf("语法错误: 第%d行第%d列 %s", e.Line, e.Column, e.Message)
+	return msg.Get(msg.SyntaxError, e.Line, e.Column, e.Message)
 }
 
 type ParserErrors []ParserError
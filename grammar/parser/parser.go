@@ -1,10 +1,11 @@
 package parser
 
 import (
-	"fmt"
+	"mini-parser/msg"
 	"mini-parser/token"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 type (
@@ -14,23 +15,25 @@ type (
 
 type Parser struct {
 	tokenizer      *token.Tokenizer
-	errors         []string
+	errors         ParserErrors
 	curToken       token.Token
 	peekToken      token.Token
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
+	hits           map[Site]int // 非nil时记录各处报错的次数, 见 RecordErrors
 }
 
 func New(tokenizer *token.Tokenizer) *Parser {
 	p := &Parser{
 		tokenizer: tokenizer,
-		errors:    []string{},
+		errors:    ParserErrors{},
 	}
 
 	// 注册前缀解析函数
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.NUMBER, p.parseIntegerLiteral)
+	p.registerPrefix(token.REAL, p.parseRealLiteral)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
@@ -43,12 +46,16 @@ func New(tokenizer *token.Tokenizer) *Parser {
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NEQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LE, p.parseInfixExpression)
+	p.registerInfix(token.GE, p.parseInfixExpression)
 	p.registerInfix(token.AND, p.parseInfixExpression)
 	p.registerInfix(token.OR, p.parseInfixExpression)
+	p.registerInfix(token.LPAREN, p.parseCallExpression)
 
 	// 读取两个token初始化当前和下一个token
 	p.nextToken()
@@ -65,15 +72,223 @@ func (p *Parser) nextToken() {
 func (p *Parser) ParseProgram() *Program {
 	program := &Program{}
 
-	for p.curToken.Type != token.EOF {
+	// 没有程序头时按语句序列分析
+	if !p.curTokenIs(token.PROGRAM) {
+		program.Statements = p.parseStatementList(token.EOF)
+		return program
+	}
+
+	program.Token = p.curToken
+	if !p.expectPeek(token.IDENT) {
+		return program
+	}
+	program.Name = &Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.SEMICOLON) {
+		p.addError(msg.ProgramMissingSemicolon)
+		p.addNote(msg.ProgramForm)
+		return program
+	}
+	if p.peekTokenIs(token.CONST) {
+		p.nextToken()
+		consts, ok := p.parseConstSection()
+		program.Consts = consts
+		if !ok {
+			return program
+		}
+	}
+	if p.peekTokenIs(token.VAR) {
+		p.nextToken()
+		vars, ok := p.parseVarSection()
+		program.Vars = vars
+		if !ok {
+			return program
+		}
+	}
+	for p.peekTokenIs(token.PROCEDURE) || p.peekTokenIs(token.FUNCTION) {
+		p.nextToken()
+		proc := p.parseProcedureDeclaration()
+		if proc == nil {
+			return program
+		}
+		program.Procedures = append(program.Procedures, proc)
+	}
+	if !p.expectPeek(token.BEGIN) {
+		p.addError(msg.ProgramMissingBegin)
+		p.addNote(msg.ProgramForm)
+		return program
+	}
+
+	body := p.parseBlockStatement()
+	program.Begin = body.Token
+	program.Statements = body.Statements
+	if !p.curTokenIs(token.END) {
+		return program
+	}
+	program.End = body.End
+
+	if !p.expectPeek(token.DOT) {
+		p.addError(msg.ProgramMissingEnd)
+		p.addNote(msg.ProgramForm)
+		return program
+	}
+	p.nextToken()
+	if !p.curTokenIs(token.EOF) {
+		p.addError(msg.ProgramTrailing, p.curToken.Literal)
+	}
+
+	return program
+}
+
+// parseProcedureDeclaration 分析 procedure 名称(形参表); [var 变量表;] begin 语句序列 end;
+// 形参表可以连同括号一起省略
+func (p *Parser) parseProcedureDeclaration() *ProcedureDeclaration {
+	proc := &ProcedureDeclaration{Token: p.curToken}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	proc.Name = &Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekTokenIs(token.LPAREN) {
+		p.nextToken()
+		if !p.peekTokenIs(token.RPAREN) {
+			params, ok := p.parseIdentifierList()
+			if !ok {
+				return nil
+			}
+			proc.Parameters = params
+		}
+		if !p.expectPeek(token.RPAREN) {
+			return nil
+		}
+	}
+	if !p.expectPeek(token.SEMICOLON) {
+		p.addError(msg.ProcedureMissingSemicolon)
+		p.addNote(msg.ProcedureForm)
+		return nil
+	}
+
+	if p.peekTokenIs(token.VAR) {
+		p.nextToken()
+		locals, ok := p.parseVarSection()
+		if !ok {
+			return nil
+		}
+		proc.Locals = locals
+	}
+
+	if !p.expectPeek(token.BEGIN) {
+		p.addError(msg.ProcedureMissingBegin)
+		p.addNote(msg.ProcedureForm)
+		return nil
+	}
+	proc.Body = p.parseBlockStatement()
+	if !p.curTokenIs(token.END) {
+		return nil
+	}
+	if !p.expectPeek(token.SEMICOLON) {
+		p.addError(msg.ProcedureMissingSemicolon)
+		p.addNote(msg.ProcedureForm)
+		return nil
+	}
+	return proc
+}
+
+// parseConstSection 分析 const 之后的常量定义 名称 = 表达式; 直到下一个记号不是标识符
+func (p *Parser) parseConstSection() ([]*ConstDeclaration, bool) {
+	var consts []*ConstDeclaration
+	for {
+		if !p.expectPeek(token.IDENT) {
+			return consts, false
+		}
+		c := &ConstDeclaration{Token: p.curToken, Name: &Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+		if !p.expectPeek(token.EQ) {
+			p.addNote(msg.ConstForm)
+			return consts, false
+		}
+		p.nextToken()
+		c.Value = p.parseExpression(LOWEST)
+		if !p.expectPeek(token.SEMICOLON) {
+			p.addNote(msg.ConstForm)
+			return consts, false
+		}
+		consts = append(consts, c)
+		if !p.peekTokenIs(token.IDENT) {
+			return consts, true
+		}
+	}
+}
+
+// parseVarSection 分析 var 之后的变量声明 名称, 名称[: 类型]; 直到下一个记号不是标识符
+func (p *Parser) parseVarSection() ([]*VarDeclaration, bool) {
+	var vars []*VarDeclaration
+	for {
+		names, ok := p.parseIdentifierList()
+		if !ok {
+			return vars, false
+		}
+		v := &VarDeclaration{Token: names[0].Token, Names: names}
+		if p.peekTokenIs(token.COLON) {
+			p.nextToken()
+			if !p.expectPeek(token.IDENT) {
+				return vars, false
+			}
+			v.Type = &Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		}
+		if !p.expectPeek(token.SEMICOLON) {
+			p.addNote(msg.VarForm)
+			return vars, false
+		}
+		vars = append(vars, v)
+		if !p.peekTokenIs(token.IDENT) {
+			return vars, true
+		}
+	}
+}
+
+// parseIdentifierList 分析以逗号分隔的标识符, 当前记号为标识符之前的记号
+func (p *Parser) parseIdentifierList() ([]*Identifier, bool) {
+	var idents []*Identifier
+	for {
+		if !p.expectPeek(token.IDENT) {
+			return nil, false
+		}
+		idents = append(idents, &Identifier{Token: p.curToken, Value: p.curToken.Literal})
+		if !p.peekTokenIs(token.COMMA) {
+			return idents, true
+		}
+		p.nextToken()
+	}
+}
+
+// parseStatementList 分析以分号分隔的语句序列, 遇到end记号时停止
+func (p *Parser) parseStatementList(end token.TokenType) []Statement {
+	var statements []Statement
+
+	for !p.curTokenIs(end) && !p.curTokenIs(token.EOF) {
+		errCount := len(p.errors)
 		stmt := p.parseStatement()
 		if stmt != nil {
-			program.Statements = append(program.Statements, stmt)
+			statements = append(statements, stmt)
+		}
+
+		switch {
+		case p.peekTokenIs(token.SEMICOLON):
+			p.nextToken()
+		case p.peekTokenIs(end), p.peekTokenIs(token.EOF), p.curTokenIs(token.SEMICOLON):
+		case end == token.END && p.peekTokenIs(token.DOT):
+			// 程序结束符提前出现, 说明外层块没有闭合
+			p.addError(msg.BeginWithoutEnd)
+			return statements
+		case len(p.errors) == errCount:
+			// 语句本身没有出错时才报告缺少分号, 避免连锁错误
+			p.addError(msg.MissingSemicolon)
+			p.addNote(msg.StatementSeparator)
 		}
 		p.nextToken()
 	}
 
-	return program
+	return statements
 }
 
 func (p *Parser) parseAssignStatement() *AssignStatement {
@@ -91,10 +306,6 @@ func (p *Parser) parseAssignStatement() *AssignStatement {
 	p.nextToken()
 	stmt.Value = p.parseExpression(LOWEST)
 
-	if p.peekTokenIs(token.SEMICOLON) {
-		p.nextToken()
-	}
-
 	return stmt
 }
 
@@ -102,7 +313,8 @@ func (p *Parser) parseIfStatement() *IfExpression {
 	expr := &IfExpression{Token: p.curToken}
 
 	if !p.expectPeek(token.LPAREN) {
-		p.addError("if语句缺少左括号")
+		p.addError(msg.IfMissingLParen)
+		p.addNote(msg.IfForm)
 		return nil
 	}
 
@@ -110,20 +322,25 @@ func (p *Parser) parseIfStatement() *IfExpression {
 	expr.Condition = p.parseExpression(LOWEST) // 修正拼写
 
 	if !p.expectPeek(token.RPAREN) {
-		p.addError("if语句缺少右括号")
+		p.addError(msg.IfMissingRParen)
+		p.addNote(msg.IfForm)
 		return nil
 	}
 
 	if !p.expectPeek(token.THEN) {
-		p.addError("if语句缺少then关键字")
+		p.addError(msg.IfMissingThen)
+		p.addNote(msg.IfNeedsThen)
 		return nil
 	}
 
-	expr.Consequence = p.parseBlockStatement()
+	p.nextToken()
+	expr.Consequence = p.parseBodyStatement()
 
 	if p.peekTokenIs(token.ELSE) {
 		p.nextToken()
-		expr.Alternative = p.parseBlockStatement()
+		expr.Else = p.curToken
+		p.nextToken()
+		expr.Alternative = p.parseBodyStatement()
 	}
 
 	return expr
@@ -133,7 +350,8 @@ func (p *Parser) parseWhileStatement() *WhileExpression {
 	expr := &WhileExpression{Token: p.curToken}
 
 	if !p.expectPeek(token.LPAREN) {
-		p.addError("while语句缺少左括号")
+		p.addError(msg.WhileMissingLParen)
+		p.addNote(msg.WhileForm)
 		return nil
 	}
 
@@ -141,16 +359,19 @@ func (p *Parser) parseWhileStatement() *WhileExpression {
 	expr.Condition = p.parseExpression(LOWEST) // 修正拼写
 
 	if !p.expectPeek(token.RPAREN) {
-		p.addError("while语句缺少右括号")
+		p.addError(msg.WhileMissingRParen)
+		p.addNote(msg.WhileForm)
 		return nil
 	}
 
 	if !p.expectPeek(token.DO) {
-		p.addError("while语句缺少do关键字")
+		p.addError(msg.WhileMissingDo)
+		p.addNote(msg.WhileNeedsDo)
 		return nil
 	}
 
-	expr.Body = p.parseBlockStatement()
+	p.nextToken()
+	expr.Body = p.parseBodyStatement()
 
 	return expr
 }
@@ -160,38 +381,86 @@ func (p *Parser) parseStatement() Statement {
 	for p.curToken.Type == token.ILLEGAL && strings.HasPrefix(p.curToken.Literal, "//") {
 		p.nextToken()
 	}
+
 	switch p.curToken.Type {
 	case token.IDENT:
+		if p.peekTokenIs(token.LPAREN) {
+			return p.parseCallStatement()
+		}
 		return p.parseAssignStatement()
+	case token.RETURN:
+		return p.parseReturnStatement()
 	case token.IF:
 		return p.parseIfStatement()
 	case token.WHILE:
 		return p.parseWhileStatement()
+	case token.BEGIN:
+		return p.parseBlockStatement()
 	default:
-		p.addError("意外的语句开始: %s", p.curToken.Literal)
+		p.addError(msg.UnexpectedStatement, p.curToken.Literal)
+		return nil
+	}
+}
+
+func (p *Parser) parseCallStatement() *CallStatement {
+	stmt := &CallStatement{Token: p.curToken}
+	function := p.parseIdentifier()
+	p.nextToken()
+
+	call, ok := p.parseCallExpression(function).(*CallExpression)
+	if !ok {
 		return nil
 	}
+	stmt.Call = call
+	return stmt
 }
+
+// parseReturnStatement 分析 return [表达式], 后面是语句的结束时没有返回值
+func (p *Parser) parseReturnStatement() *ReturnStatement {
+	stmt := &ReturnStatement{Token: p.curToken}
+
+	switch p.peekToken.Type {
+	case token.SEMICOLON, token.END, token.ELSE, token.EOF:
+		return stmt
+	}
+
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+	return stmt
+}
+
 func (p *Parser) parseBlockStatement() *BlockStatement {
 	block := &BlockStatement{Token: p.curToken}
 
 	p.nextToken()
+	block.Statements = p.parseStatementList(token.END)
 
-	for !p.curTokenIs(token.END) && !p.curTokenIs(token.EOF) {
-		stmt := p.parseStatement()
-		if stmt != nil {
-			block.Statements = append(block.Statements, stmt)
-		}
-		p.nextToken()
+	if p.curTokenIs(token.END) {
+		block.End = p.curToken
+	} else {
+		p.addError(msg.BeginWithoutEnd)
 	}
 
 	return block
 }
 
+// parseBodyStatement 分析then/else/do之后的单条语句, 统一包装为语句块
+func (p *Parser) parseBodyStatement() *BlockStatement {
+	if p.curTokenIs(token.BEGIN) {
+		return p.parseBlockStatement()
+	}
+
+	block := &BlockStatement{Token: p.curToken}
+	if stmt := p.parseStatement(); stmt != nil {
+		block.Statements = append(block.Statements, stmt)
+	}
+	return block
+}
+
 func (p *Parser) parseExpression(precedence int) Expression {
 	prefix := p.prefixParseFns[p.curToken.Type]
 	if prefix == nil {
-		p.addError("无法解析: %s", p.curToken.Literal)
+		p.addError(msg.CannotParse, p.curToken.Literal)
 		return nil
 	}
 	leftExp := prefix()
@@ -218,7 +487,20 @@ func (p *Parser) parseIntegerLiteral() Expression {
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
-		p.addError("无法解析 %q 为整数", p.curToken.Literal)
+		p.addError(msg.InvalidInteger, p.curToken.Literal)
+		return nil
+	}
+
+	lit.Value = value
+	return lit
+}
+
+func (p *Parser) parseRealLiteral() Expression {
+	lit := &RealLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		p.addError(msg.InvalidReal, p.curToken.Literal)
 		return nil
 	}
 
@@ -271,6 +553,35 @@ func (p *Parser) parseGroupedExpression() Expression {
 	return exp
 }
 
+// parseCallExpression 分析调用的实参表, 当前记号为 (
+func (p *Parser) parseCallExpression(function Expression) Expression {
+	if function == nil {
+		return nil
+	}
+	// 被调用的不是名字时仍分析实参表, 以免后面的记号引起连锁错误
+	ident, ok := function.(*Identifier)
+	if !ok {
+		p.addError(msg.NotCallable, function.String())
+		ident = &Identifier{}
+	}
+	call := &CallExpression{Token: ident.Token, Function: ident}
+
+	if !p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		call.Arguments = append(call.Arguments, p.parseExpression(LOWEST))
+		for p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+			p.nextToken()
+			call.Arguments = append(call.Arguments, p.parseExpression(LOWEST))
+		}
+	}
+	if !p.expectPeek(token.RPAREN) || !ok {
+		return nil
+	}
+	call.Rparen = p.curToken
+	return call
+}
+
 // 辅助函数
 func (p *Parser) registerPrefix(tokenType token.TokenType, fn prefixParseFn) {
 	p.prefixParseFns[tokenType] = fn
@@ -312,20 +623,43 @@ func (p *Parser) curPrecedence() int {
 	return LOWEST
 }
 
-func (p *Parser) addError(format string, args ...interface{}) {
-	msg := fmt.Sprintf("第%d行第%d列: %s",
-		p.curToken.Line, p.curToken.Column,
-		fmt.Sprintf(format, args...))
-	p.errors = append(p.errors, msg)
+func (p *Parser) addError(id msg.ID, args ...interface{}) {
+	p.recordError(1)
+	p.errors = append(p.errors, ParserError{
+		Line:    p.curToken.Line,
+		Column:  p.curToken.Column,
+		Length:  utf8.RuneCountInString(p.curToken.Literal),
+		Message: msg.Get(id, args...),
+	})
 }
 
+// addPeekError 只由 expectPeek 调用, 覆盖率按调用 expectPeek 的位置统计
 func (p *Parser) addPeekError(t token.TokenType) {
-	msg := fmt.Sprintf("第%d行第%d列: 期望 %s, 实际得到 %s",
-		p.peekToken.Line, p.peekToken.Column,
-		t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.recordError(2)
+	p.errors = append(p.errors, ParserError{
+		Line:    p.peekToken.Line,
+		Column:  p.peekToken.Column,
+		Length:  utf8.RuneCountInString(p.peekToken.Literal),
+		Message: msg.Get(msg.ExpectedToken, t, p.peekToken.Type),
+	})
+}
+
+// addNote 为最近一条错误补充说明
+func (p *Parser) addNote(id msg.ID, args ...interface{}) {
+	if n := len(p.errors); n > 0 {
+		p.errors[n-1].Notes = append(p.errors[n-1].Notes, msg.Get(id, args...))
+	}
 }
 
 func (p *Parser) Errors() []string {
+	out := make([]string, len(p.errors))
+	for i, err := range p.errors {
+		out[i] = msg.Get(msg.Location, err.Line, err.Column, err.Message)
+	}
+	return out
+}
+
+// ErrorList 返回带位置信息的语法错误
+func (p *Parser) ErrorList() ParserErrors {
 	return p.errors
 }
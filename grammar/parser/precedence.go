@@ -18,10 +18,27 @@ var precedences = map[token.TokenType]int{
 	token.NEQ:      EQUALS,
 	token.LT:       LESSGREATER,
 	token.GT:       LESSGREATER,
+	token.LE:       LESSGREATER,
+	token.GE:       LESSGREATER,
 	token.PLUS:     SUM,
 	token.MINUS:    SUM,
 	token.SLASH:    PRODUCT,
 	token.ASTERISK: PRODUCT,
+	token.PERCENT:  PRODUCT,
 	token.AND:      EQUALS,
 	token.OR:       EQUALS,
+	token.LPAREN:   CALL,
+}
+
+// Precedence 返回中缀运算符的优先级, 不是运算符时为 LOWEST
+func Precedence(t token.TokenType) int {
+	if p, ok := precedences[t]; ok {
+		return p
+	}
+	return LOWEST
+}
+
+// IsBinary 是否为二元运算符; ( 有 CALL 优先级, 但它表示调用而不是运算符
+func IsBinary(t token.TokenType) bool {
+	return t != token.LPAREN && Precedence(t) != LOWEST
 }
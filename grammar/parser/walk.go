@@ -0,0 +1,110 @@
+package parser
+
+// Inspect 按深度优先顺序遍历语法树, f返回false时不再访问该节点的子节点
+func Inspect(node Node, f func(Node) bool) {
+	if isNilNode(node) || !f(node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		for _, c := range n.Consts {
+			Inspect(c, f)
+		}
+		for _, v := range n.Vars {
+			Inspect(v, f)
+		}
+		for _, proc := range n.Procedures {
+			Inspect(proc, f)
+		}
+		for _, s := range n.Statements {
+			Inspect(s, f)
+		}
+	case *ProcedureDeclaration:
+		Inspect(n.Name, f)
+		for _, param := range n.Parameters {
+			Inspect(param, f)
+		}
+		for _, local := range n.Locals {
+			Inspect(local, f)
+		}
+		Inspect(n.Body, f)
+	case *ConstDeclaration:
+		Inspect(n.Name, f)
+		Inspect(n.Value, f)
+	case *VarDeclaration:
+		for _, name := range n.Names {
+			Inspect(name, f)
+		}
+		Inspect(n.Type, f)
+	case *ReturnStatement:
+		Inspect(n.Value, f)
+	case *CallStatement:
+		Inspect(n.Call, f)
+	case *CallExpression:
+		Inspect(n.Function, f)
+		for _, arg := range n.Arguments {
+			Inspect(arg, f)
+		}
+	case *BlockStatement:
+		for _, s := range n.Statements {
+			Inspect(s, f)
+		}
+	case *AssignStatement:
+		Inspect(n.Name, f)
+		Inspect(n.Value, f)
+	case *IfExpression:
+		Inspect(n.Condition, f)
+		Inspect(n.Consequence, f)
+		Inspect(n.Alternative, f)
+	case *WhileExpression:
+		Inspect(n.Condition, f)
+		Inspect(n.Body, f)
+	case *PrefixExpression:
+		Inspect(n.Right, f)
+	case *InfixExpression:
+		Inspect(n.Left, f)
+		Inspect(n.Right, f)
+	}
+}
+
+// Variables 按第一次出现的顺序返回程序中的变量名: var 部分声明的变量以及语句中出现的标识符。
+// var 部分中的类型名不是变量
+func Variables(program *Program) []string {
+	var names []string
+	seen := map[string]bool{}
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	Inspect(program, func(n Node) bool {
+		switch n := n.(type) {
+		case *VarDeclaration:
+			for _, name := range n.Names {
+				add(name.Value)
+			}
+			return false
+		case *Identifier:
+			add(n.Value)
+		}
+		return true
+	})
+	return names
+}
+
+// isNilNode 判断接口中保存的是否为nil指针(如缺省的else分支)
+func isNilNode(node Node) bool {
+	switch n := node.(type) {
+	case nil:
+		return true
+	case *BlockStatement:
+		return n == nil
+	case *Identifier:
+		return n == nil
+	case *CallExpression:
+		return n == nil
+	}
+	return false
+}
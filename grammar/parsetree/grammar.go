@@ -0,0 +1,229 @@
+package parsetree
+
+import (
+	"mini-parser/token"
+	"strings"
+)
+
+// Symbol 文法符号, 非终结符写作 <名称>
+type Symbol struct {
+	Name     string
+	Terminal bool
+}
+
+func (s Symbol) String() string {
+	return s.Name
+}
+
+// Production 产生式, Body 为空表示 ε
+type Production struct {
+	Head string
+	Body []Symbol
+}
+
+func (p Production) String() string {
+	if len(p.Body) == 0 {
+		return p.Head + " → ε"
+	}
+	names := make([]string, len(p.Body))
+	for i, s := range p.Body {
+		names[i] = s.Name
+	}
+	return p.Head + " → " + strings.Join(names, " ")
+}
+
+// Mini 文法的非终结符
+const (
+	Program       = "<程序>"
+	ProcList      = "<过程声明表>"
+	Proc          = "<过程声明>"
+	ProcKind      = "<过程种类>"
+	Params        = "<形参部分>"
+	ParamList     = "<形参表>"
+	IdentList     = "<标识符表>"
+	IdentListTail = "<标识符表尾>"
+	ConstPart     = "<常量部分>"
+	ConstList     = "<常量定义表>"
+	ConstDef      = "<常量定义>"
+	Vars          = "<变量部分>"
+	VarList       = "<变量声明表>"
+	VarDecl       = "<变量声明>"
+	TypePart      = "<类型部分>"
+	Compound      = "<复合语句>"
+	StmtList      = "<语句表>"
+	StmtListTail  = "<语句表尾>"
+	Stmt          = "<语句>"
+	Simple        = "<简单语句>"
+	SimpleTail    = "<简单语句尾>"
+	If            = "<条件语句>"
+	Else          = "<否则部分>"
+	While         = "<循环语句>"
+	Return        = "<返回语句>"
+	ReturnValue   = "<返回值>"
+	Expr          = "<表达式>"
+	ExprTail      = "<表达式尾>"
+	LogicOp       = "<逻辑运算符>"
+	Rel           = "<关系表达式>"
+	RelTail       = "<关系表达式尾>"
+	RelOp         = "<关系运算符>"
+	Arith         = "<算术表达式>"
+	ArithTail     = "<算术表达式尾>"
+	AddOp         = "<加法运算符>"
+	Term          = "<项>"
+	TermTail      = "<项尾>"
+	MulOp         = "<乘法运算符>"
+	Factor        = "<因子>"
+	Call          = "<调用部分>"
+	Args          = "<实参部分>"
+	ArgList       = "<实参表>"
+	ArgListTail   = "<实参表尾>"
+)
+
+// 代表一类单词的终结符
+const (
+	Ident   = "标识符"
+	Integer = "整数"
+	Real    = "实数"
+)
+
+// classes 单词类终结符对应的记号类型, 其余终结符的名字就是记号类型
+var classes = map[string]token.TokenType{
+	Ident:   token.IDENT,
+	Integer: token.NUMBER,
+	Real:    token.REAL,
+}
+
+func terminalType(name string) token.TokenType {
+	if t, ok := classes[name]; ok {
+		return t
+	}
+	return token.TokenType(name)
+}
+
+func nt(name string) Symbol { return Symbol{Name: name} }
+func t(name string) Symbol  { return Symbol{Name: name, Terminal: true} }
+
+// 运算符所属的非终结符, 与 Parser 的优先级一致: = != && || 最低, 其次是关系运算符
+var operators = map[string][]token.TokenType{
+	LogicOp: {token.EQ, token.NEQ, token.AND, token.OR},
+	RelOp:   {token.LT, token.GT, token.LE, token.GE},
+	AddOp:   {token.PLUS, token.MINUS},
+	MulOp:   {token.ASTERISK, token.SLASH, token.PERCENT},
+}
+
+// Mini 文法的产生式, 递归下降分析时按名字引用
+var (
+	programHeader = Production{Program, []Symbol{t(token.PROGRAM), t(Ident), t(token.SEMICOLON), nt(ConstPart), nt(Vars), nt(ProcList), nt(Compound), t(token.DOT)}}
+	programBare   = Production{Program, []Symbol{nt(StmtList)}}
+	procList      = Production{ProcList, []Symbol{nt(Proc), nt(ProcList)}}
+	procListEmpty = Production{ProcList, nil}
+	proc          = Production{Proc, []Symbol{nt(ProcKind), t(Ident), nt(Params), t(token.SEMICOLON), nt(Vars), nt(Compound), t(token.SEMICOLON)}}
+	kindProcedure = Production{ProcKind, []Symbol{t(token.PROCEDURE)}}
+	kindFunction  = Production{ProcKind, []Symbol{t(token.FUNCTION)}}
+	params        = Production{Params, []Symbol{t(token.LPAREN), nt(ParamList), t(token.RPAREN)}}
+	paramsEmpty   = Production{Params, nil}
+	paramList     = Production{ParamList, []Symbol{nt(IdentList)}}
+	paramListNone = Production{ParamList, nil}
+	identList     = Production{IdentList, []Symbol{t(Ident), nt(IdentListTail)}}
+	identListTail = Production{IdentListTail, []Symbol{t(token.COMMA), t(Ident), nt(IdentListTail)}}
+	identListEnd  = Production{IdentListTail, nil}
+	constPart     = Production{ConstPart, []Symbol{t(token.CONST), nt(ConstDef), nt(ConstList)}}
+	constNone     = Production{ConstPart, nil}
+	constList     = Production{ConstList, []Symbol{nt(ConstDef), nt(ConstList)}}
+	constListEnd  = Production{ConstList, nil}
+	constDef      = Production{ConstDef, []Symbol{t(Ident), t(token.EQ), nt(Expr), t(token.SEMICOLON)}}
+	vars          = Production{Vars, []Symbol{t(token.VAR), nt(VarDecl), nt(VarList)}}
+	varsEmpty     = Production{Vars, nil}
+	varList       = Production{VarList, []Symbol{nt(VarDecl), nt(VarList)}}
+	varListEnd    = Production{VarList, nil}
+	varDecl       = Production{VarDecl, []Symbol{nt(IdentList), nt(TypePart), t(token.SEMICOLON)}}
+	typePart      = Production{TypePart, []Symbol{t(token.COLON), t(Ident)}}
+	typeNone      = Production{TypePart, nil}
+	compound      = Production{Compound, []Symbol{t(token.BEGIN), nt(StmtList), t(token.END)}}
+	stmtList      = Production{StmtList, []Symbol{nt(Stmt), nt(StmtListTail)}}
+	stmtListEmpty = Production{StmtList, nil}
+	stmtListTail  = Production{StmtListTail, []Symbol{t(token.SEMICOLON), nt(StmtList)}}
+	stmtListEnd   = Production{StmtListTail, nil}
+	stmtSimple    = Production{Stmt, []Symbol{nt(Simple)}}
+	stmtIf        = Production{Stmt, []Symbol{nt(If)}}
+	stmtWhile     = Production{Stmt, []Symbol{nt(While)}}
+	stmtCompound  = Production{Stmt, []Symbol{nt(Compound)}}
+	stmtReturn    = Production{Stmt, []Symbol{nt(Return)}}
+	simple        = Production{Simple, []Symbol{t(Ident), nt(SimpleTail)}}
+	simpleAssign  = Production{SimpleTail, []Symbol{t(token.ASSIGN), nt(Expr)}}
+	simpleCall    = Production{SimpleTail, []Symbol{nt(Args)}}
+	ifStmt        = Production{If, []Symbol{t(token.IF), t(token.LPAREN), nt(Expr), t(token.RPAREN), t(token.THEN), nt(Stmt), nt(Else)}}
+	elsePart      = Production{Else, []Symbol{t(token.ELSE), nt(Stmt)}}
+	elseEmpty     = Production{Else, nil}
+	whileStmt     = Production{While, []Symbol{t(token.WHILE), t(token.LPAREN), nt(Expr), t(token.RPAREN), t(token.DO), nt(Stmt)}}
+	returnStmt    = Production{Return, []Symbol{t(token.RETURN), nt(ReturnValue)}}
+	returnValue   = Production{ReturnValue, []Symbol{nt(Expr)}}
+	returnNone    = Production{ReturnValue, nil}
+	expr          = Production{Expr, []Symbol{nt(Rel), nt(ExprTail)}}
+	exprTail      = Production{ExprTail, []Symbol{nt(LogicOp), nt(Rel), nt(ExprTail)}}
+	exprEnd       = Production{ExprTail, nil}
+	rel           = Production{Rel, []Symbol{nt(Arith), nt(RelTail)}}
+	relTail       = Production{RelTail, []Symbol{nt(RelOp), nt(Arith), nt(RelTail)}}
+	relEnd        = Production{RelTail, nil}
+	arith         = Production{Arith, []Symbol{nt(Term), nt(ArithTail)}}
+	arithTail     = Production{ArithTail, []Symbol{nt(AddOp), nt(Term), nt(ArithTail)}}
+	arithEnd      = Production{ArithTail, nil}
+	term          = Production{Term, []Symbol{nt(Factor), nt(TermTail)}}
+	termTail      = Production{TermTail, []Symbol{nt(MulOp), nt(Factor), nt(TermTail)}}
+	termEnd       = Production{TermTail, nil}
+	factorMinus   = Production{Factor, []Symbol{t(token.MINUS), nt(Factor)}}
+	factorNot     = Production{Factor, []Symbol{t(token.BANG), nt(Factor)}}
+	factorGroup   = Production{Factor, []Symbol{t(token.LPAREN), nt(Expr), t(token.RPAREN)}}
+	factorIdent   = Production{Factor, []Symbol{t(Ident), nt(Call)}}
+	factorInteger = Production{Factor, []Symbol{t(Integer)}}
+	factorReal    = Production{Factor, []Symbol{t(Real)}}
+	factorTrue    = Production{Factor, []Symbol{t(token.TRUE)}}
+	factorFalse   = Production{Factor, []Symbol{t(token.FALSE)}}
+	call          = Production{Call, []Symbol{nt(Args)}}
+	callNone      = Production{Call, nil}
+	args          = Production{Args, []Symbol{t(token.LPAREN), nt(ArgList), t(token.RPAREN)}}
+	argList       = Production{ArgList, []Symbol{nt(Expr), nt(ArgListTail)}}
+	argListNone   = Production{ArgList, nil}
+	argListTail   = Production{ArgListTail, []Symbol{t(token.COMMA), nt(Expr), nt(ArgListTail)}}
+	argListEnd    = Production{ArgListTail, nil}
+)
+
+// 运算符的产生式, 如 <加法运算符> → +
+var (
+	operatorHeads = []string{LogicOp, RelOp, AddOp, MulOp}
+	operatorRules = map[token.TokenType]Production{}
+)
+
+func init() {
+	for _, head := range operatorHeads {
+		for _, op := range operators[head] {
+			operatorRules[op] = Production{head, []Symbol{t(string(op))}}
+		}
+	}
+}
+
+// Grammar 返回递归下降分析所依据的全部产生式, 运算符的产生式排在最后
+func Grammar() []Production {
+	prods := []Production{
+		programHeader, programBare,
+		constPart, constNone, constList, constListEnd, constDef,
+		vars, varsEmpty, varList, varListEnd, varDecl, typePart, typeNone,
+		procList, procListEmpty, proc, kindProcedure, kindFunction,
+		params, paramsEmpty, paramList, paramListNone,
+		identList, identListTail, identListEnd,
+		compound, stmtList, stmtListEmpty, stmtListTail, stmtListEnd,
+		stmtSimple, stmtIf, stmtWhile, stmtCompound, stmtReturn,
+		simple, simpleAssign, simpleCall,
+		ifStmt, elsePart, elseEmpty, whileStmt, returnStmt, returnValue, returnNone,
+		expr, exprTail, exprEnd, rel, relTail, relEnd,
+		arith, arithTail, arithEnd, term, termTail, termEnd,
+		factorMinus, factorNot, factorGroup, factorIdent, factorInteger, factorReal, factorTrue, factorFalse,
+		call, callNone, args, argList, argListNone, argListTail, argListEnd,
+	}
+	for _, head := range operatorHeads {
+		for _, op := range operators[head] {
+			prods = append(prods, operatorRules[op])
+		}
+	}
+	return prods
+}
@@ -0,0 +1,450 @@
+package parsetree
+
+import (
+	"fmt"
+	"mini-parser/msg"
+	"mini-parser/parser"
+	"mini-parser/token"
+	"strings"
+	"unicode/utf8"
+)
+
+// Node 具体语法树的节点: 非终结符的子节点按产生式右部排列, 终结符带有匹配的记号
+type Node struct {
+	Symbol   Symbol
+	Token    token.Token // 仅终结符有效
+	Children []*Node
+}
+
+// Tree 分析结果: 具体语法树以及按应用顺序排列的产生式
+type Tree struct {
+	Root  *Node
+	Steps []Production
+}
+
+// Parse 按 Mini 文法递归下降分析源程序, 记录每一步应用的产生式。
+// 递归下降总是先展开最左边的非终结符, 所以 Steps 就是最左推导的顺序。
+func Parse(src string) (*Tree, error) {
+	p := &rd{tokenizer: token.New(src)}
+	p.next()
+
+	tree := &Tree{Root: &Node{Symbol: nt(Program)}}
+	p.program(tree.Root)
+	if p.err == nil && p.cur.Type != token.EOF {
+		p.fail(msg.Get(msg.ExpectedToken, token.EOF, p.cur.Literal))
+	}
+	if p.err != nil {
+		return nil, parser.ParserErrors{*p.err}
+	}
+	tree.Steps = p.steps
+	return tree, nil
+}
+
+type rd struct {
+	tokenizer *token.Tokenizer
+	cur       token.Token
+	steps     []Production
+	err       *parser.ParserError
+}
+
+func (p *rd) next() {
+	p.cur = p.tokenizer.NextToken()
+}
+
+// fail 记录第一个错误, 之后的分析不再继续
+func (p *rd) fail(message string) {
+	if p.err != nil {
+		return
+	}
+	p.err = &parser.ParserError{
+		Line:    p.cur.Line,
+		Column:  p.cur.Column,
+		Length:  utf8.RuneCountInString(p.cur.Literal),
+		Message: message,
+	}
+}
+
+// expand 对节点应用产生式: 依次匹配右部的终结符, 递归展开非终结符
+func (p *rd) expand(n *Node, prod Production) {
+	if p.err != nil {
+		return
+	}
+	p.steps = append(p.steps, prod)
+	for _, s := range prod.Body {
+		n.Children = append(n.Children, &Node{Symbol: s})
+	}
+	for _, c := range n.Children {
+		if c.Symbol.Terminal {
+			p.match(c)
+		} else {
+			p.rules(c.Symbol.Name)(c)
+		}
+		if p.err != nil {
+			return
+		}
+	}
+}
+
+func (p *rd) match(n *Node) {
+	if p.cur.Type != terminalType(n.Symbol.Name) {
+		p.fail(msg.Get(msg.ExpectedToken, n.Symbol.Name, p.cur.Literal))
+		return
+	}
+	n.Token = p.cur
+	p.next()
+}
+
+// noProduction 当前记号不能开始该非终结符的任何产生式
+func (p *rd) noProduction(n *Node) {
+	p.fail(msg.Get(msg.NoProduction, n.Symbol.Name, p.cur.Literal))
+}
+
+// rules 返回展开非终结符的分析函数
+func (p *rd) rules(name string) func(*Node) {
+	switch name {
+	case Program:
+		return p.program
+	case ProcList:
+		return func(n *Node) { p.choose(n, procList, procListEmpty, token.PROCEDURE, token.FUNCTION) }
+	case Proc:
+		return func(n *Node) { p.expand(n, proc) }
+	case ProcKind:
+		return p.procKind
+	case Params:
+		return func(n *Node) { p.choose(n, params, paramsEmpty, token.LPAREN) }
+	case ParamList:
+		return func(n *Node) { p.choose(n, paramList, paramListNone, token.IDENT) }
+	case IdentList:
+		return func(n *Node) { p.expand(n, identList) }
+	case IdentListTail:
+		return func(n *Node) { p.choose(n, identListTail, identListEnd, token.COMMA) }
+	case ConstPart:
+		return func(n *Node) { p.choose(n, constPart, constNone, token.CONST) }
+	case ConstList:
+		return func(n *Node) { p.choose(n, constList, constListEnd, token.IDENT) }
+	case ConstDef:
+		return func(n *Node) { p.expand(n, constDef) }
+	case Vars:
+		return func(n *Node) { p.choose(n, vars, varsEmpty, token.VAR) }
+	case VarList:
+		return func(n *Node) { p.choose(n, varList, varListEnd, token.IDENT) }
+	case VarDecl:
+		return func(n *Node) { p.expand(n, varDecl) }
+	case TypePart:
+		return func(n *Node) { p.choose(n, typePart, typeNone, token.COLON) }
+	case Compound:
+		return p.compound
+	case StmtList:
+		return p.stmtList
+	case StmtListTail:
+		return p.stmtListTail
+	case Stmt:
+		return p.stmt
+	case Simple:
+		return func(n *Node) { p.expand(n, simple) }
+	case SimpleTail:
+		return p.simpleTail
+	case If:
+		return func(n *Node) { p.expand(n, ifStmt) }
+	case Else:
+		return p.elsePart
+	case While:
+		return func(n *Node) { p.expand(n, whileStmt) }
+	case Return:
+		return func(n *Node) { p.expand(n, returnStmt) }
+	case ReturnValue:
+		return p.returnValue
+	case Expr:
+		return func(n *Node) { p.expand(n, expr) }
+	case ExprTail:
+		return func(n *Node) { p.tail(n, LogicOp, exprTail, exprEnd) }
+	case Rel:
+		return func(n *Node) { p.expand(n, rel) }
+	case RelTail:
+		return func(n *Node) { p.tail(n, RelOp, relTail, relEnd) }
+	case Arith:
+		return func(n *Node) { p.expand(n, arith) }
+	case ArithTail:
+		return func(n *Node) { p.tail(n, AddOp, arithTail, arithEnd) }
+	case Term:
+		return func(n *Node) { p.expand(n, term) }
+	case TermTail:
+		return func(n *Node) { p.tail(n, MulOp, termTail, termEnd) }
+	case Factor:
+		return p.factor
+	case Call:
+		return func(n *Node) { p.choose(n, call, callNone, token.LPAREN) }
+	case Args:
+		return func(n *Node) { p.expand(n, args) }
+	case ArgList:
+		return p.argList
+	case ArgListTail:
+		return func(n *Node) { p.choose(n, argListTail, argListEnd, token.COMMA) }
+	}
+	return p.operator
+}
+
+func (p *rd) program(n *Node) {
+	if p.cur.Type == token.PROGRAM {
+		p.expand(n, programHeader)
+	} else {
+		p.expand(n, programBare)
+	}
+}
+
+// choose 当前记号是 first 之一时展开 more, 否则展开 ε 产生式 end
+func (p *rd) choose(n *Node, more, end Production, first ...token.TokenType) {
+	for _, t := range first {
+		if p.cur.Type == t {
+			p.expand(n, more)
+			return
+		}
+	}
+	p.expand(n, end)
+}
+
+func (p *rd) procKind(n *Node) {
+	switch p.cur.Type {
+	case token.PROCEDURE:
+		p.expand(n, kindProcedure)
+	case token.FUNCTION:
+		p.expand(n, kindFunction)
+	default:
+		p.noProduction(n)
+	}
+}
+
+func (p *rd) compound(n *Node) {
+	p.expand(n, compound)
+}
+
+// startsStmt 当前记号是否属于 FIRST(<语句>)
+func (p *rd) startsStmt() bool {
+	switch p.cur.Type {
+	case token.IDENT, token.IF, token.WHILE, token.BEGIN, token.RETURN:
+		return true
+	}
+	return false
+}
+
+func (p *rd) stmtList(n *Node) {
+	if p.startsStmt() {
+		p.expand(n, stmtList)
+	} else {
+		p.expand(n, stmtListEmpty)
+	}
+}
+
+func (p *rd) stmtListTail(n *Node) {
+	if p.cur.Type == token.SEMICOLON {
+		p.expand(n, stmtListTail)
+	} else {
+		p.expand(n, stmtListEnd)
+	}
+}
+
+func (p *rd) stmt(n *Node) {
+	switch p.cur.Type {
+	case token.IDENT:
+		p.expand(n, stmtSimple)
+	case token.IF:
+		p.expand(n, stmtIf)
+	case token.WHILE:
+		p.expand(n, stmtWhile)
+	case token.BEGIN:
+		p.expand(n, stmtCompound)
+	case token.RETURN:
+		p.expand(n, stmtReturn)
+	default:
+		p.noProduction(n)
+	}
+}
+
+func (p *rd) simpleTail(n *Node) {
+	switch p.cur.Type {
+	case token.ASSIGN:
+		p.expand(n, simpleAssign)
+	case token.LPAREN:
+		p.expand(n, simpleCall)
+	default:
+		p.noProduction(n)
+	}
+}
+
+// startsExpr 当前记号是否属于 FIRST(<表达式>)
+func (p *rd) startsExpr() bool {
+	switch p.cur.Type {
+	case token.MINUS, token.BANG, token.LPAREN, token.IDENT, token.NUMBER, token.REAL, token.TRUE, token.FALSE:
+		return true
+	}
+	return false
+}
+
+// returnValue return 之后不是表达式的开始时没有返回值
+func (p *rd) returnValue(n *Node) {
+	if p.startsExpr() {
+		p.expand(n, returnValue)
+	} else {
+		p.expand(n, returnNone)
+	}
+}
+
+func (p *rd) argList(n *Node) {
+	if p.startsExpr() {
+		p.expand(n, argList)
+	} else {
+		p.expand(n, argListNone)
+	}
+}
+
+// elsePart 有else时总是与最近的if结合
+func (p *rd) elsePart(n *Node) {
+	if p.cur.Type == token.ELSE {
+		p.expand(n, elsePart)
+	} else {
+		p.expand(n, elseEmpty)
+	}
+}
+
+// tail 展开 <X尾> → <运算符> <Y> <X尾> | ε, 当前记号是该级运算符时选第一个产生式
+func (p *rd) tail(n *Node, op string, more, end Production) {
+	if rule, ok := operatorRules[p.cur.Type]; ok && rule.Head == op {
+		p.expand(n, more)
+	} else {
+		p.expand(n, end)
+	}
+}
+
+func (p *rd) operator(n *Node) {
+	rule, ok := operatorRules[p.cur.Type]
+	if !ok || rule.Head != n.Symbol.Name {
+		p.noProduction(n)
+		return
+	}
+	p.expand(n, rule)
+}
+
+func (p *rd) factor(n *Node) {
+	switch p.cur.Type {
+	case token.MINUS:
+		p.expand(n, factorMinus)
+	case token.BANG:
+		p.expand(n, factorNot)
+	case token.LPAREN:
+		p.expand(n, factorGroup)
+	case token.IDENT:
+		p.expand(n, factorIdent)
+	case token.NUMBER:
+		p.expand(n, factorInteger)
+	case token.REAL:
+		p.expand(n, factorReal)
+	case token.TRUE:
+		p.expand(n, factorTrue)
+	case token.FALSE:
+		p.expand(n, factorFalse)
+	default:
+		p.noProduction(n)
+	}
+}
+
+// Derivation 返回最左推导的句型序列, 第一个是开始符号, 最后一个是终结符串
+func (t *Tree) Derivation() []string {
+	form := []Symbol{nt(Program)}
+	out := []string{sentential(form)}
+	for _, step := range t.Steps {
+		for i, s := range form {
+			if !s.Terminal {
+				rest := append(append([]Symbol{}, step.Body...), form[i+1:]...)
+				form = append(form[:i], rest...)
+				break
+			}
+		}
+		out = append(out, sentential(form))
+	}
+	return out
+}
+
+func sentential(form []Symbol) string {
+	if len(form) == 0 {
+		return "ε"
+	}
+	names := make([]string, len(form))
+	for i, s := range form {
+		names[i] = s.Name
+	}
+	return strings.Join(names, " ")
+}
+
+// label 节点的显示文本, 单词类终结符附带单词本身
+func (n *Node) label() string {
+	if n.Symbol.Terminal {
+		if _, ok := classes[n.Symbol.Name]; ok {
+			return n.Symbol.Name + " " + n.Token.Literal
+		}
+	}
+	return n.Symbol.Name
+}
+
+// String 以缩进的树形文本输出具体语法树, ε 产生式的子节点显示为 ε
+func (t *Tree) String() string {
+	var out strings.Builder
+	var visit func(n *Node, prefix, childPrefix string)
+	visit = func(n *Node, prefix, childPrefix string) {
+		out.WriteString(prefix + n.label() + "\n")
+		if !n.Symbol.Terminal && len(n.Children) == 0 {
+			out.WriteString(childPrefix + "└── ε\n")
+		}
+		for i, c := range n.Children {
+			if i == len(n.Children)-1 {
+				visit(c, childPrefix+"└── ", childPrefix+"    ")
+			} else {
+				visit(c, childPrefix+"├── ", childPrefix+"│   ")
+			}
+		}
+	}
+	visit(t.Root, "", "")
+	return out.String()
+}
+
+// DerivationString 每行一个句型, 用 ⇒ 连接并标出所用的产生式
+func (t *Tree) DerivationString() string {
+	var out strings.Builder
+	forms := t.Derivation()
+	out.WriteString("   " + forms[0] + "\n")
+	for i, step := range t.Steps {
+		fmt.Fprintf(&out, "⇒ %s    [%s]\n", forms[i+1], step)
+	}
+	return out.String()
+}
+
+// Dot 以Graphviz DOT格式输出具体语法树, 非终结符为椭圆, 终结符为方框
+func (t *Tree) Dot() string {
+	var out strings.Builder
+	out.WriteString("digraph ParseTree {\n")
+	out.WriteString("\tnode [fontname=\"monospace\"];\n")
+
+	count := 0
+	var visit func(n *Node) int
+	visit = func(n *Node) int {
+		id := count
+		count++
+		shape := "ellipse"
+		if n.Symbol.Terminal {
+			shape = "box"
+		}
+		fmt.Fprintf(&out, "\tn%d [label=%q, shape=%s];\n", id, n.label(), shape)
+		if !n.Symbol.Terminal && len(n.Children) == 0 {
+			fmt.Fprintf(&out, "\tn%d [label=\"ε\", shape=plaintext];\n", count)
+			fmt.Fprintf(&out, "\tn%d -> n%d;\n", id, count)
+			count++
+		}
+		for _, c := range n.Children {
+			fmt.Fprintf(&out, "\tn%d -> n%d;\n", id, visit(c))
+		}
+		return id
+	}
+	visit(t.Root)
+
+	out.WriteString("}\n")
+	return out.String()
+}
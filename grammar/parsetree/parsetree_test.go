@@ -0,0 +1,100 @@
+package parsetree_test
+
+import (
+	"math/rand"
+	"mini-parser/gen"
+	"mini-parser/grammar"
+	"mini-parser/parser"
+	"mini-parser/parsetree"
+	"mini-parser/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// parsetree 的递归下降分析器独立于 parser.Parser 手写而成, 两者应当接受同样的程序
+func TestMatchesParser(t *testing.T) {
+	g, err := grammar.Load(filepath.Join("..", "mini.bnf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	productions := map[string]bool{}
+	for _, p := range parsetree.Grammar() {
+		productions[p.String()] = true
+	}
+
+	rejected := 0
+	check := func(name, src string) {
+		t.Helper()
+		tree, err := parsetree.Parse(src)
+		p := parser.New(token.New(src))
+		p.ParseProgram()
+		errs := p.ErrorList()
+		switch {
+		case err != nil && len(errs) == 0:
+			t.Errorf("%s: parsetree 报告 %v, parser 接受\n%s", name, err, src)
+		case err == nil && len(errs) > 0:
+			t.Errorf("%s: parser 报告 %v, parsetree 接受\n%s", name, errs[0], src)
+		case err != nil:
+			rejected++
+		default:
+			checkTree(t, name, src, tree, productions)
+		}
+	}
+
+	for _, name := range []string{"test_correct", "test_complex", "test_error"} {
+		src, err := os.ReadFile(filepath.Join("..", name+".mini"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(name, string(src))
+	}
+
+	generator, err := gen.New(g, gen.DefaultOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		src := generator.Program()
+		check("生成的程序", src)
+		if out, m, ok := gen.Mutate(src, rng); ok {
+			check(m.String(), out)
+		}
+	}
+	if rejected < 100 {
+		t.Errorf("只有 %d 个程序被拒绝, 变异没有起作用", rejected)
+	}
+}
+
+// checkTree 检查每个内部节点都按文法中的一条产生式展开, 叶子依次就是源程序的记号
+func checkTree(t *testing.T, name, src string, tree *parsetree.Tree, productions map[string]bool) {
+	t.Helper()
+	var leaves []string
+	var walk func(n *parsetree.Node)
+	walk = func(n *parsetree.Node) {
+		if n.Symbol.Terminal {
+			leaves = append(leaves, n.Token.Literal)
+			return
+		}
+		prod := parsetree.Production{Head: n.Symbol.Name}
+		for _, c := range n.Children {
+			prod.Body = append(prod.Body, c.Symbol)
+			walk(c)
+		}
+		if !productions[prod.String()] {
+			t.Errorf("%s: 节点按文法中没有的产生式展开: %s", name, prod)
+		}
+	}
+	walk(tree.Root)
+
+	var tokens []string
+	tokenizer := token.New(src)
+	for tok := tokenizer.NextToken(); tok.Type != token.EOF; tok = tokenizer.NextToken() {
+		tokens = append(tokens, tok.Literal)
+	}
+	if got, want := strings.Join(leaves, " "), strings.Join(tokens, " "); got != want {
+		t.Errorf("%s: 语法树的叶子\n%s\n源程序的记号\n%s", name, got, want)
+	}
+}
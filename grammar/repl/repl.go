@@ -0,0 +1,198 @@
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mini-parser/compiler"
+	"mini-parser/msg"
+	"mini-parser/object"
+	"mini-parser/optimizer"
+	"mini-parser/parser"
+	"mini-parser/semantic"
+	"mini-parser/token"
+	"mini-parser/vm"
+	"strings"
+)
+
+const (
+	PROMPT      = ">> "
+	CONT_PROMPT = ".. "
+)
+
+type session struct {
+	out       io.Writer
+	showAST   bool
+	showToken bool
+	names     []string
+	constants []object.Object
+	globals   []object.Object
+}
+
+// Start 启动交互式解释器, 直到输入结束或:quit
+func Start(in io.Reader, out io.Writer) {
+	scanner := bufio.NewScanner(in)
+	s := &session{out: out}
+
+	fmt.Fprintln(out, msg.Get(msg.ReplBanner))
+	var buf strings.Builder
+	for {
+		if buf.Len() == 0 {
+			fmt.Fprint(out, PROMPT)
+		} else {
+			fmt.Fprint(out, CONT_PROMPT)
+		}
+		if !scanner.Scan() {
+			return
+		}
+		line := scanner.Text()
+
+		if buf.Len() == 0 && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			if !s.command(strings.TrimSpace(line)) {
+				return
+			}
+			continue
+		}
+
+		buf.WriteString(line)
+		buf.WriteString("\n")
+		if Incomplete(buf.String()) {
+			continue
+		}
+
+		input := buf.String()
+		buf.Reset()
+		if strings.TrimSpace(input) != "" {
+			s.eval(input)
+		}
+	}
+}
+
+// command 执行元命令, 返回false表示退出
+func (s *session) command(cmd string) bool {
+	switch cmd {
+	case ":quit", ":q":
+		return false
+	case ":help":
+		fmt.Fprintln(s.out, msg.Get(msg.ReplHelp))
+	case ":ast":
+		s.showAST = !s.showAST
+		fmt.Fprintln(s.out, msg.Get(msg.ReplASTMode, onOff(s.showAST)))
+	case ":tokens":
+		s.showToken = !s.showToken
+		fmt.Fprintln(s.out, msg.Get(msg.ReplShowTokens, onOff(s.showToken)))
+	case ":reset":
+		s.names, s.constants, s.globals = nil, nil, nil
+		fmt.Fprintln(s.out, msg.Get(msg.ReplReset))
+	default:
+		fmt.Fprintln(s.out, msg.Get(msg.ReplUnknownCommand, cmd))
+	}
+	return true
+}
+
+func onOff(b bool) string {
+	if b {
+		return msg.Get(msg.On)
+	}
+	return msg.Get(msg.Off)
+}
+
+func (s *session) eval(input string) {
+	if s.showToken {
+		t := token.New(input)
+		for tok := t.NextToken(); tok.Type != token.EOF; tok = t.NextToken() {
+			fmt.Fprintf(s.out, "%d:%d\t%s\t%q\n", tok.Line, tok.Column, tok.Type, tok.Literal)
+		}
+	}
+
+	p := parser.New(token.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		for _, err := range p.Errors() {
+			fmt.Fprintln(s.out, err)
+		}
+		return
+	}
+
+	if s.showAST {
+		for _, stmt := range program.Statements {
+			fmt.Fprintln(s.out, stmt.String())
+		}
+		return
+	}
+
+	// 与命令行一样先做语义检查, 虚拟机不能执行过程声明和调用
+	if errs := semantic.Check(program).Errors; len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintln(s.out, err)
+		}
+		return
+	}
+	if len(program.Procedures) > 0 {
+		name := program.Procedures[0].Name
+		fmt.Fprintln(s.out, msg.Get(msg.Location, name.Token.Line, name.Token.Column, msg.Get(msg.ProceduresUnsupported, name.Value)))
+		return
+	}
+	program = optimizer.InlineConstants(program)
+
+	comp := compiler.NewWithState(s.names, s.constants)
+	if err := comp.Compile(program); err != nil {
+		fmt.Fprintln(s.out, msg.Get(msg.CompileFailed, err))
+		return
+	}
+	bytecode := comp.Bytecode()
+
+	machine := vm.NewWithGlobals(bytecode, s.globals)
+	err := machine.Run()
+	// 即使运行出错, 出错前完成的赋值也保留下来
+	s.names, s.constants, s.globals = bytecode.Names, bytecode.Constants, machine.Globals()
+	if err != nil {
+		fmt.Fprintln(s.out, err)
+		return
+	}
+
+	// 显示本次输入中被赋值的变量
+	shown := map[string]bool{}
+	parser.Inspect(program, func(n parser.Node) bool {
+		if assign, ok := n.(*parser.AssignStatement); ok && !shown[assign.Name.Value] {
+			shown[assign.Name.Value] = true
+			if value, ok := machine.Lookup(assign.Name.Value); ok {
+				fmt.Fprintf(s.out, "%s = %s\n", assign.Name.Value, value.Inspect())
+			}
+		}
+		return true
+	})
+}
+
+// Incomplete 判断输入是否还需要继续: 存在未闭合的begin或括号, 或以需要后续内容的记号结尾
+func Incomplete(input string) bool {
+	t := token.New(input)
+	depth, parens := 0, 0
+	var last token.Token
+	for tok := t.NextToken(); tok.Type != token.EOF; tok = t.NextToken() {
+		switch tok.Type {
+		case token.BEGIN:
+			depth++
+		case token.END:
+			depth--
+		case token.LPAREN:
+			parens++
+		case token.RPAREN:
+			parens--
+		}
+		last = tok
+	}
+
+	if depth > 0 || parens > 0 {
+		return true
+	}
+
+	switch last.Type {
+	case token.IF, token.THEN, token.ELSE, token.WHILE, token.DO, token.ASSIGN,
+		token.PLUS, token.MINUS, token.ASTERISK, token.SLASH, token.PERCENT,
+		token.LT, token.GT, token.LE, token.GE, token.EQ, token.NEQ,
+		token.AND, token.OR, token.BANG:
+		return true
+	}
+	return false
+}
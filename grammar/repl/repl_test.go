@@ -0,0 +1,136 @@
+package repl
+
+import (
+	"mini-parser/msg"
+	"strings"
+	"testing"
+)
+
+func TestIncomplete(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"x := 1", false},
+		{"", false},
+		{"begin", true},
+		{"begin x := 1;", true},
+		{"begin x := 1 end", false},
+		{"begin begin x := 1 end", true},
+		{"x := (1 + 2", true},
+		{"x := ((1 + 2) * 3", true},
+		{"x := (1 + 2)", false},
+		{"x := 1 +", true},
+		{"x := a &&", true},
+		{"x :=", true},
+		{"if (x > 1) then", true},
+		{"if (x > 1) then y := 1 else", true},
+		{"while (x < 10) do", true},
+		{"x := !", true},
+		// 多余的 end 和右括号留给语法分析器报错
+		{"end", false},
+		{"x := 1)", false},
+	}
+	for _, tt := range tests {
+		if got := Incomplete(tt.input); got != tt.want {
+			t.Errorf("Incomplete(%q) = %v, 期望 %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+// transcript 把每行输入交给 Start, 返回欢迎信息之后的全部输出
+func transcript(t *testing.T, lines ...string) string {
+	t.Helper()
+	var out strings.Builder
+	Start(strings.NewReader(strings.Join(lines, "\n")+"\n"), &out)
+	banner := msg.Get(msg.ReplBanner) + "\n"
+	if !strings.HasPrefix(out.String(), banner) {
+		t.Fatalf("输出没有以欢迎信息开始:\n%s", out.String())
+	}
+	return strings.TrimPrefix(out.String(), banner)
+}
+
+// 未完成的输入显示续行提示符, 直到 begin 和括号闭合、不再以运算符结尾
+func TestContinuation(t *testing.T) {
+	got := transcript(t,
+		"x := 1 +",
+		"  2",
+		"begin",
+		"  y := (x",
+		"    * 3);",
+		"  z := y",
+		"end",
+	)
+	want := ">> .. x = 3\n>> .. .. .. .. y = 9\nz = 9\n>> "
+	if got != want {
+		t.Errorf("输出 %q\n期望 %q", got, want)
+	}
+}
+
+// 变量的值在多次输入之间保留, :reset 之后清空
+func TestState(t *testing.T) {
+	got := transcript(t,
+		"x := 2",
+		"y := x * 10",
+		"x := x + y",
+		":reset",
+		"z := x",
+		"x := 7",
+		"x := x - 1",
+	)
+	want := strings.Join([]string{
+		">> x = 2",
+		">> y = 20",
+		">> x = 22",
+		">> " + msg.Get(msg.ReplReset),
+		">> 运行错误: 第1行第6列: 变量 x 未赋值",
+		">> x = 7",
+		">> x = 6",
+		">> ",
+	}, "\n")
+	if got != want {
+		t.Errorf("输出\n%s\n期望\n%s", got, want)
+	}
+}
+
+// 出错的输入不影响已有的变量, 运行出错前完成的赋值保留下来
+func TestErrors(t *testing.T) {
+	got := transcript(t,
+		"x := 1",
+		"x := ;",
+		"y := x; z := 1 / 0",
+		"w := y + x",
+		":bogus",
+		":quit",
+		"x := 100",
+	)
+	want := strings.Join([]string{
+		">> x = 1",
+		">> 第1行第6列: 无法解析: ;",
+		">> 运行错误: 第1行第16列: 除数为0",
+		">> w = 2",
+		">> " + msg.Get(msg.ReplUnknownCommand, ":bogus"),
+		">> ",
+	}, "\n")
+	if got != want {
+		t.Errorf("输出\n%s\n期望\n%s", got, want)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	got := transcript(t, ":ast", "x := 1 + 2 * 3", ":ast", ":tokens", "y := 1", ":q")
+	want := strings.Join([]string{
+		">> " + msg.Get(msg.ReplASTMode, msg.Get(msg.On)),
+		">> x := (1 + (2 * 3));",
+		">> " + msg.Get(msg.ReplASTMode, msg.Get(msg.Off)),
+		">> " + msg.Get(msg.ReplShowTokens, msg.Get(msg.On)),
+		">> 1:1\tIDENT\t\"y\"",
+		"1:3\t:=\t\":=\"",
+		"1:6\tNUMBER\t\"1\"",
+		"y = 1",
+		">> ",
+	}, "\n")
+	if got != want {
+		t.Errorf("输出\n%s\n期望\n%s", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+package semantic
+
+import (
+	"mini-parser/msg"
+	"mini-parser/parser"
+)
+
+type callChecker struct {
+	info   *Info
+	errors errorList
+}
+
+// CheckCalls 检查过程和函数的调用与 return: 实参个数与形参不符、在表达式中调用过程,
+// 以及 return 与所在过程的种类不符。被调用的名字已由 Resolve 解析
+func CheckCalls(info *Info) []Error {
+	c := &callChecker{info: info}
+	for _, proc := range info.Program.Procedures {
+		c.check(proc.Body, proc)
+	}
+	for _, s := range info.Program.Statements {
+		c.check(s, nil)
+	}
+	return c.errors.sorted()
+}
+
+// check 检查 node 中的调用和 return, proc 为所在的过程, 主程序中为nil
+func (c *callChecker) check(node parser.Node, proc *parser.ProcedureDeclaration) {
+	parser.Inspect(node, func(n parser.Node) bool {
+		switch n := n.(type) {
+		case *parser.CallStatement:
+			c.call(n.Call, false)
+			for _, arg := range n.Call.Arguments {
+				c.check(arg, proc)
+			}
+			return false
+		case *parser.CallExpression:
+			c.call(n, true)
+		case *parser.ReturnStatement:
+			c.ret(n, proc)
+		}
+		return true
+	})
+}
+
+// call 检查一次调用, value 表示调用出现在表达式中, 需要返回值
+func (c *callChecker) call(call *parser.CallExpression, value bool) {
+	sym := c.info.Symbols[call.Function]
+	if sym == nil || sym.Proc == nil {
+		return
+	}
+	name, proc := sym.Name, sym.Proc
+	if len(call.Arguments) != len(proc.Parameters) {
+		c.errors.at(call.Token, msg.ArgumentCount, name, len(proc.Parameters), len(call.Arguments))
+	}
+	if value && !proc.IsFunction() {
+		c.errors.at(call.Token, msg.ProcedureHasNoValue, name)
+	}
+}
+
+func (c *callChecker) ret(stmt *parser.ReturnStatement, proc *parser.ProcedureDeclaration) {
+	switch {
+	case proc == nil:
+		c.errors.at(stmt.Token, msg.ReturnOutsideProcedure)
+	case proc.IsFunction() && stmt.Value == nil:
+		c.errors.at(stmt.Token, msg.FunctionReturnsNothing, proc.Name.Value)
+	case !proc.IsFunction() && stmt.Value != nil:
+		c.errors.at(stmt.Token, msg.ProcedureReturnsValue, proc.Name.Value)
+	}
+}
@@ -0,0 +1,35 @@
+package semantic
+
+import (
+	"mini-parser/parser"
+	"testing"
+)
+
+func TestCheckCalls(t *testing.T) {
+	runDiagnostics(t, []diagnosticTest{
+		{name: "matching", src: `program p;
+var x: integer;
+procedure show(a); begin end;
+function twice(a); begin return a * 2 end;
+begin show(1); x := twice(x) end.`},
+		{name: "too many", src: "program p;\nprocedure show(a); begin end;\nbegin show(1, 2) end.",
+			errors: []string{"3:7 show 需要 1 个参数, 实际传入 2 个"}},
+		{name: "too few", src: "program p;\nvar x: integer;\nfunction max(a, b); begin return a end;\nbegin x := max(1) end.",
+			errors: []string{"4:12 max 需要 2 个参数, 实际传入 1 个"}},
+		{name: "procedure value", src: "program p;\nvar x: integer;\nprocedure show; begin end;\nbegin x := show() end.",
+			errors: []string{"4:12 过程 show 没有返回值, 不能用在表达式中"}},
+		{name: "return outside", src: "program p;\nbegin return end.",
+			errors: []string{"2:7 return 只能出现在过程或函数中"}},
+		{name: "function return", src: "program p;\nfunction f(a); begin return end;\nbegin end.",
+			errors: []string{"2:22 函数 f 的 return 必须给出返回值"}},
+		{name: "procedure return", src: "program p;\nprocedure f; begin return 1 end;\nbegin end.",
+			errors: []string{"2:20 过程 f 不能返回值"}},
+		// 实参中的调用同样检查
+		{name: "nested argument", src: "program p;\nprocedure show(a); begin end;\nfunction f(a); begin return a end;\nbegin show(f()) end.",
+			errors: []string{"4:12 f 需要 1 个参数, 实际传入 0 个"}},
+	}, func(program *parser.Program) *Info {
+		info := Resolve(program)
+		info.Errors = CheckCalls(info)
+		return info
+	})
+}
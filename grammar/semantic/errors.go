@@ -0,0 +1,69 @@
+// Package semantic 在语法分析之后检查程序中名字和类型的使用是否合法
+package semantic
+
+import (
+	"mini-parser/msg"
+	"mini-parser/parser"
+	"mini-parser/token"
+	"sort"
+	"unicode/utf8"
+)
+
+type Error struct {
+	Line    int
+	Column  int
+	Length  int
+	Message string
+}
+
+func (e Error) String() string {
+	return msg.Get(msg.Location, e.Line, e.Column, e.Message)
+}
+
+type errorList []Error
+
+// at 在记号处报告错误
+func (l *errorList) at(tok token.Token, id msg.ID, args ...interface{}) {
+	*l = append(*l, Error{
+		Line:    tok.Line,
+		Column:  tok.Column,
+		Length:  utf8.RuneCountInString(tok.Literal),
+		Message: msg.Get(id, args...),
+	})
+}
+
+// over 在整个节点的范围上报告错误, 节点跨行时只标出起始位置
+func (l *errorList) over(node parser.Node, id msg.ID, args ...interface{}) {
+	startLine, startCol, endLine, endCol := parser.Span(node)
+	length := 1
+	if startLine == endLine {
+		length = endCol - startCol + 1
+	}
+	*l = append(*l, Error{
+		Line:    startLine,
+		Column:  startCol,
+		Length:  length,
+		Message: msg.Get(id, args...),
+	})
+}
+
+// sorted 按位置排序, 同一位置的错误保持报告的顺序
+func (l errorList) sorted() []Error {
+	sort.SliceStable(l, func(i, j int) bool {
+		if l[i].Line != l[j].Line {
+			return l[i].Line < l[j].Line
+		}
+		return l[i].Column < l[j].Column
+	})
+	return l
+}
+
+// Check 依次进行名字解析、过程调用检查和类型检查, 结果中的错误按位置排序
+func Check(program *parser.Program) *Info {
+	info := Resolve(program)
+	errs := errorList(info.Errors)
+	errs = append(errs, CheckCalls(info)...)
+	errs = append(errs, CheckTypes(info)...)
+	info.Errors = errs.sorted()
+	return info
+}
@@ -0,0 +1,187 @@
+package semantic
+
+import (
+	"mini-parser/msg"
+	"mini-parser/parser"
+	"mini-parser/types"
+)
+
+// Info 名字解析的结果
+type Info struct {
+	Program *parser.Program
+	Global  *Scope
+	// Symbols 每个标识符(声明处和引用处)对应的名字, 未声明的标识符和类型名不在其中
+	Symbols map[*parser.Identifier]*Symbol
+	// Scopes 程序、过程和语句块对应的作用域, 过程体与过程共用一个作用域
+	Scopes   map[parser.Node]*Scope
+	Errors   []Error
+	Warnings []Error
+}
+
+type resolver struct {
+	info     *Info
+	scope    *Scope
+	implicit bool // 程序没有 const 和 var 部分, 变量不需要声明
+	constant bool // 正在解析常量的值
+	errors   errorList
+	warnings errorList
+}
+
+// Resolve 为程序、过程和语句块建立嵌套的作用域, 把每个标识符链接到它的声明。
+// 报告未声明的名字和同一作用域中重复的声明, 内层的声明遮蔽外层的同名声明时给出警告。
+// 没有声明部分的程序中, 未声明的变量在第一次出现时加入程序作用域。
+func Resolve(program *parser.Program) *Info {
+	r := &resolver{
+		info: &Info{
+			Program: program,
+			Symbols: map[*parser.Identifier]*Symbol{},
+			Scopes:  map[parser.Node]*Scope{},
+		},
+		implicit: len(program.Consts) == 0 && len(program.Vars) == 0,
+	}
+	r.scope = newScope(ProgramScope, program, nil)
+	r.info.Global = r.scope
+	r.info.Scopes[program] = r.scope
+
+	// 常量的值只能引用在它之前定义的常量
+	for _, c := range program.Consts {
+		r.constant = true
+		r.node(c.Value)
+		r.constant = false
+		r.declare(c.Name, Constant, types.Unknown)
+	}
+	r.declareVars(program.Vars)
+
+	// 先登记全部过程, 过程可以调用在它之后声明的过程以及它自己
+	for _, proc := range program.Procedures {
+		kind := Procedure
+		if proc.IsFunction() {
+			kind = Function
+		}
+		if sym := r.declare(proc.Name, kind, types.Unknown); sym != nil {
+			sym.Proc = proc
+		}
+	}
+	for _, proc := range program.Procedures {
+		r.procedure(proc)
+	}
+	for _, s := range program.Statements {
+		r.node(s)
+	}
+
+	r.info.Errors = r.errors.sorted()
+	r.info.Warnings = r.warnings.sorted()
+	return r.info
+}
+
+// declare 在当前作用域中声明名字, 重复声明时返回nil
+func (r *resolver) declare(ident *parser.Identifier, kind SymbolKind, typ types.Type) *Symbol {
+	if r.scope.LookupLocal(ident.Value) != nil {
+		r.errors.at(ident.Token, msg.DuplicateDeclaration, ident.Value)
+		return nil
+	}
+	// 隐式变量在使用时才加入, 是否被遮蔽取决于过程与使用处的先后, 不作警告
+	if outer := r.scope.Parent.Lookup(ident.Value); outer != nil && !outer.Implicit {
+		r.warnings.at(ident.Token, msg.Shadows, ident.Value, outer.Decl.Token.Line)
+	}
+	sym := &Symbol{Name: ident.Value, Kind: kind, Type: typ, Decl: ident}
+	r.scope.insert(sym)
+	r.info.Symbols[ident] = sym
+	return sym
+}
+
+func (r *resolver) declareVars(decls []*parser.VarDeclaration) {
+	for _, decl := range decls {
+		t := types.Unknown
+		if decl.Type != nil {
+			var ok bool
+			if t, ok = types.Lookup(decl.Type.Value); !ok {
+				r.errors.at(decl.Type.Token, msg.UnknownType, decl.Type.Value)
+			}
+		}
+		for _, name := range decl.Names {
+			r.declare(name, Variable, t)
+		}
+	}
+}
+
+func (r *resolver) procedure(proc *parser.ProcedureDeclaration) {
+	r.scope = newScope(ProcedureScope, proc, r.scope)
+	r.info.Scopes[proc] = r.scope
+	r.info.Scopes[proc.Body] = r.scope
+	for _, param := range proc.Parameters {
+		r.declare(param, Parameter, types.Unknown)
+	}
+	r.declareVars(proc.Locals)
+	for _, s := range proc.Body.Statements {
+		r.node(s)
+	}
+	r.scope = r.scope.Parent
+}
+
+// node 解析语句或表达式中的名字, 语句块进入新的作用域
+func (r *resolver) node(node parser.Node) {
+	parser.Inspect(node, func(n parser.Node) bool {
+		switch n := n.(type) {
+		case *parser.BlockStatement:
+			r.scope = newScope(BlockScope, n, r.scope)
+			r.info.Scopes[n] = r.scope
+			for _, s := range n.Statements {
+				r.node(s)
+			}
+			r.scope = r.scope.Parent
+			return false
+		case *parser.CallExpression:
+			r.call(n)
+			for _, arg := range n.Arguments {
+				r.node(arg)
+			}
+			return false
+		case *parser.Identifier:
+			r.use(n)
+		}
+		return true
+	})
+}
+
+// use 解析作为常量或变量使用的名字
+func (r *resolver) use(ident *parser.Identifier) {
+	sym := r.scope.Lookup(ident.Value)
+	switch {
+	case sym == nil && r.implicit:
+		sym = &Symbol{Name: ident.Value, Kind: Variable, Decl: ident, Implicit: true}
+		r.info.Global.insert(sym)
+		r.info.Symbols[ident] = sym
+		return
+	case sym == nil:
+		r.errors.at(ident.Token, msg.UndeclaredIdentifier, ident.Value)
+		return
+	case sym.Kind == Procedure || sym.Kind == Function:
+		r.errors.at(ident.Token, msg.NotAVariable, ident.Value)
+	}
+	r.link(ident, sym)
+}
+
+func (r *resolver) call(call *parser.CallExpression) {
+	name := call.Function
+	if r.constant {
+		// 常量定义在过程声明之前, 不能调用过程
+		r.errors.at(call.Token, msg.NotConstant, name.Value)
+		return
+	}
+	sym := r.scope.Lookup(name.Value)
+	switch {
+	case sym == nil:
+		r.errors.at(call.Token, msg.UndeclaredProcedure, name.Value)
+		return
+	case sym.Proc == nil:
+		// 形参或局部变量遮蔽了同名的过程时也会走到这里
+		r.errors.at(call.Token, msg.NotCallable, name.Value)
+	}
+	r.link(name, sym)
+}
+
+func (r *resolver) link(ident *parser.Identifier, sym *Symbol) {
+	r.info.Symbols[ident] = sym
+	sym.Uses = append(sym.Uses, ident)
+}
@@ -0,0 +1,129 @@
+package semantic
+
+import (
+	"fmt"
+	"mini-parser/parser"
+	"mini-parser/token"
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, src string) *parser.Program {
+	t.Helper()
+	p := parser.New(token.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("语法错误: %v", p.Errors())
+	}
+	return program
+}
+
+// format 把诊断排成 "行:列 消息", 便于整体比较
+func format(errs []Error) string {
+	var out []string
+	for _, e := range errs {
+		out = append(out, fmt.Sprintf("%d:%d %s", e.Line, e.Column, e.Message))
+	}
+	return strings.Join(out, "\n")
+}
+
+// diagnosticTest 一个程序及期望的错误和警告
+type diagnosticTest struct {
+	name     string
+	src      string
+	errors   []string
+	warnings []string
+}
+
+func runDiagnostics(t *testing.T, tests []diagnosticTest, check func(*parser.Program) *Info) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := check(parse(t, tt.src))
+			if got, want := format(info.Errors), strings.Join(tt.errors, "\n"); got != want {
+				t.Errorf("错误\n%s\n期望\n%s", got, want)
+			}
+			if got, want := format(info.Warnings), strings.Join(tt.warnings, "\n"); got != want {
+				t.Errorf("警告\n%s\n期望\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	runDiagnostics(t, []diagnosticTest{
+		{name: "declared", src: "program p; var x, y: integer; begin x := 1; y := x end."},
+		{name: "undeclared", src: "program p; var x: integer; begin x := y + 1 end.",
+			errors: []string{"1:39 未声明的标识符 y"}},
+		// 没有声明部分的程序中变量是隐式的
+		{name: "implicit", src: "x := 1; y := x + z"},
+		{name: "duplicate var", src: "program p;\nvar x: integer; y, x: real;\nbegin x := 1 end.",
+			errors: []string{"2:20 x 重复声明"}},
+		{name: "duplicate const", src: "program p;\nconst n = 1;\nvar n: integer;\nbegin end.",
+			errors: []string{"3:5 n 重复声明"}},
+		{name: "procedure and var", src: "program p;\nvar f: integer;\nprocedure f; begin end;\nbegin end.",
+			errors: []string{"3:11 f 重复声明"}},
+		{name: "duplicate parameter", src: "program p;\nprocedure f(a, a); begin end;\nbegin end.",
+			errors: []string{"2:16 a 重复声明"}},
+		{name: "local and parameter", src: "program p;\nprocedure f(a);\nvar a;\nbegin end;\nbegin end.",
+			errors: []string{"3:5 a 重复声明"}},
+		// 形参和局部变量遮蔽全局的同名声明, 只给出警告
+		{name: "shadow", src: "program p;\nvar x, y: integer;\nprocedure f(x);\nvar y;\nbegin y := x end;\nbegin end.",
+			warnings: []string{"3:13 x 遮蔽了第 2 行的同名声明", "4:5 y 遮蔽了第 2 行的同名声明"}},
+		// 常量只能引用在它之前定义的常量
+		{name: "const order", src: "program p;\nconst a = b + 1; b = 2;\nbegin end.",
+			errors: []string{"2:11 未声明的标识符 b"}},
+		{name: "local outside", src: "program p;\nvar x: integer;\nprocedure f;\nvar t;\nbegin t := 1 end;\nbegin x := t end.",
+			errors: []string{"6:12 未声明的标识符 t"}},
+		{name: "procedure as variable", src: "program p;\nvar x: integer;\nfunction f(a); begin return a end;\nbegin x := f + 1 end.",
+			errors: []string{"4:12 f 是过程或函数, 不能作为变量使用"}},
+		{name: "undeclared procedure", src: "program p;\nbegin g() end.",
+			errors: []string{"2:7 未声明的过程或函数 g"}},
+		{name: "variable called", src: "program p;\nvar x: integer;\nbegin x() end.",
+			errors: []string{"3:7 只能调用过程或函数的名字: x"}},
+		// 过程可以调用在它之后声明的过程
+		{name: "forward call", src: "program p;\nprocedure a; begin b() end;\nprocedure b; begin a() end;\nbegin a() end."},
+	}, Resolve)
+}
+
+// 每个标识符链接到作用域中最近的声明, 并记录引用处
+func TestSymbols(t *testing.T) {
+	program := parse(t, `program p;
+var x: integer;
+procedure f(x);
+begin x := x + 1 end;
+begin x := 2; f(x) end.`)
+	info := Resolve(program)
+	if len(info.Errors) > 0 {
+		t.Fatalf("错误: %s", format(info.Errors))
+	}
+
+	global := info.Global.Lookup("x")
+	if global == nil || global.Kind != Variable || global.Decl.Token.Line != 2 {
+		t.Fatalf("全局的 x: %+v", global)
+	}
+	param := info.Scopes[program.Procedures[0]].LookupLocal("x")
+	if param == nil || param.Kind != Parameter || param.Decl.Token.Line != 3 {
+		t.Fatalf("形参 x: %+v", param)
+	}
+
+	uses := func(sym *Symbol) string {
+		var out []string
+		for _, use := range sym.Uses {
+			if info.Symbols[use] != sym {
+				t.Errorf("%d:%d 的 x 没有链接到它的声明", use.Token.Line, use.Token.Column)
+			}
+			out = append(out, fmt.Sprintf("%d:%d", use.Token.Line, use.Token.Column))
+		}
+		return strings.Join(out, " ")
+	}
+	if got := uses(param); got != "4:7 4:12" {
+		t.Errorf("形参 x 的引用处 %s", got)
+	}
+	if got := uses(global); got != "5:7 5:17" {
+		t.Errorf("全局 x 的引用处 %s", got)
+	}
+	if f := info.Global.Lookup("f"); f == nil || f.Kind != Procedure || len(f.Uses) != 1 {
+		t.Errorf("过程 f: %+v", f)
+	}
+}
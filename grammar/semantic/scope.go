@@ -0,0 +1,109 @@
+package semantic
+
+import (
+	"mini-parser/parser"
+	"mini-parser/types"
+)
+
+// SymbolKind 名字的种类
+type SymbolKind int
+
+const (
+	Variable SymbolKind = iota
+	Constant
+	Parameter
+	Procedure
+	Function
+)
+
+func (k SymbolKind) String() string {
+	switch k {
+	case Constant:
+		return "const"
+	case Parameter:
+		return "parameter"
+	case Procedure:
+		return "procedure"
+	case Function:
+		return "function"
+	}
+	return "var"
+}
+
+// Symbol 作用域中声明的一个名字
+type Symbol struct {
+	Name  string
+	Kind  SymbolKind
+	Type  types.Type // 形参、过程、函数和没有标注类型的变量为 Unknown; 常量的类型由 CheckTypes 填写
+	Decl  *parser.Identifier
+	Uses  []*parser.Identifier // 按出现顺序排列的引用处, 不含声明处
+	Scope *Scope
+	// Implicit 表示没有声明部分的程序中由使用引入的变量, Decl 为第一次出现处
+	Implicit bool
+	// Proc 过程和函数的声明
+	Proc *parser.ProcedureDeclaration
+}
+
+// ScopeKind 作用域的种类
+type ScopeKind int
+
+const (
+	ProgramScope ScopeKind = iota
+	ProcedureScope
+	BlockScope
+)
+
+func (k ScopeKind) String() string {
+	switch k {
+	case ProcedureScope:
+		return "procedure"
+	case BlockScope:
+		return "block"
+	}
+	return "program"
+}
+
+// Scope 嵌套的作用域: 程序包含过程和语句块, 过程包含语句块, 语句块可以再包含语句块。
+// Mini 的语句块中不能声明名字, 块作用域只记录嵌套结构, 其中的名字都在外层查找
+type Scope struct {
+	Kind     ScopeKind
+	Node     parser.Node // *parser.Program, *parser.ProcedureDeclaration 或 *parser.BlockStatement
+	Parent   *Scope
+	Children []*Scope
+	names    map[string]*Symbol
+	symbols  []*Symbol
+}
+
+func newScope(kind ScopeKind, node parser.Node, parent *Scope) *Scope {
+	s := &Scope{Kind: kind, Node: node, Parent: parent, names: map[string]*Symbol{}}
+	if parent != nil {
+		parent.Children = append(parent.Children, s)
+	}
+	return s
+}
+
+// Symbols 按声明顺序返回本作用域中的名字
+func (s *Scope) Symbols() []*Symbol {
+	return s.symbols
+}
+
+// LookupLocal 只在本作用域中查找
+func (s *Scope) LookupLocal(name string) *Symbol {
+	return s.names[name]
+}
+
+// Lookup 从本作用域开始逐层向外查找
+func (s *Scope) Lookup(name string) *Symbol {
+	for ; s != nil; s = s.Parent {
+		if sym, ok := s.names[name]; ok {
+			return sym
+		}
+	}
+	return nil
+}
+
+func (s *Scope) insert(sym *Symbol) {
+	sym.Scope = s
+	s.names[sym.Name] = sym
+	s.symbols = append(s.symbols, sym)
+}
@@ -0,0 +1,170 @@
+package semantic
+
+import (
+	"mini-parser/msg"
+	"mini-parser/parser"
+	"mini-parser/types"
+)
+
+type typeChecker struct {
+	info   *Info
+	errors errorList
+}
+
+// CheckTypes 按 const 和 var 部分的声明检查程序: 运算符的操作数类型不匹配、赋值类型不符、
+// 给常量赋值, 以及 if/while 的条件不是 boolean。同时填写常量的类型。
+// 没有声明部分的程序中变量是隐式的, 只按赋值推断隐式变量的类型, 不做检查。
+// 形参、函数结果和没有标注类型的变量类型未知, 可以与任何类型相容。
+// Mini 的形参表和函数头不标注类型, 因此不检查实参与形参、返回值与使用处的类型,
+// 如把 true 传给参与算术运算的形参不会报告。
+func CheckTypes(info *Info) []Error {
+	program := info.Program
+	if len(program.Consts) == 0 && len(program.Vars) == 0 {
+		inferred := types.Infer(program)
+		for _, sym := range info.Global.Symbols() {
+			if sym.Implicit {
+				sym.Type = inferred.Vars[sym.Name]
+			}
+		}
+		return nil
+	}
+
+	c := &typeChecker{info: info}
+	for _, decl := range program.Consts {
+		// 常量值中的调用已由 Resolve 报告, 类型未知
+		t := c.expr(decl.Value)
+		if sym := info.Symbols[decl.Name]; sym != nil {
+			sym.Type = t
+		}
+	}
+	for _, proc := range program.Procedures {
+		c.stmt(proc.Body)
+	}
+	for _, s := range program.Statements {
+		c.stmt(s)
+	}
+	return c.errors.sorted()
+}
+
+func (c *typeChecker) stmt(stmt parser.Statement) {
+	switch s := stmt.(type) {
+	case *parser.AssignStatement:
+		value := c.expr(s.Value)
+		sym := c.info.Symbols[s.Name]
+		switch {
+		case sym == nil:
+		case sym.Kind == Constant:
+			c.errors.at(s.Name.Token, msg.AssignToConst, s.Name.Value)
+		case !assignable(sym.Type, value):
+			c.errors.over(s.Value, msg.AssignType, value, sym.Type, s.Name.Value)
+		}
+	case *parser.IfExpression:
+		c.condition("if", s.Condition)
+		c.stmt(s.Consequence)
+		if s.Alternative != nil {
+			c.stmt(s.Alternative)
+		}
+	case *parser.WhileExpression:
+		c.condition("while", s.Condition)
+		c.stmt(s.Body)
+	case *parser.BlockStatement:
+		for _, inner := range s.Statements {
+			c.stmt(inner)
+		}
+	case *parser.CallStatement:
+		c.expr(s.Call)
+	case *parser.ReturnStatement:
+		if s.Value != nil {
+			c.expr(s.Value)
+		}
+	}
+}
+
+func (c *typeChecker) condition(keyword string, cond parser.Expression) {
+	if t := c.expr(cond); t != types.Boolean && t != types.Unknown {
+		c.errors.over(cond, msg.ConditionType, keyword, t)
+	}
+}
+
+// expr 返回表达式的类型, 出错的子表达式类型未知, 不再引起连锁错误
+func (c *typeChecker) expr(expr parser.Expression) types.Type {
+	switch e := expr.(type) {
+	case *parser.IntegerLiteral:
+		return types.Integer
+	case *parser.RealLiteral:
+		return types.Real
+	case *parser.Boolean:
+		return types.Boolean
+	case *parser.Identifier:
+		// 未声明的名字已由 Resolve 报告, 过程名的类型未知
+		if sym := c.info.Symbols[e]; sym != nil {
+			return sym.Type
+		}
+		return types.Unknown
+	case *parser.CallExpression:
+		// 被调用的名字由 Resolve 和 CheckCalls 检查, 这里只检查实参
+		for _, arg := range e.Arguments {
+			c.expr(arg)
+		}
+		return types.Unknown
+	case *parser.PrefixExpression:
+		right := c.expr(e.Right)
+		want := numeric
+		if e.Operator == "!" {
+			want = boolean
+		}
+		if !want(right) {
+			c.errors.at(e.Token, msg.PrefixOperandType, e.Operator, right)
+			return types.Unknown
+		}
+		return right
+	case *parser.InfixExpression:
+		return c.infix(e)
+	}
+	return types.Unknown
+}
+
+func (c *typeChecker) infix(e *parser.InfixExpression) types.Type {
+	l, r := c.expr(e.Left), c.expr(e.Right)
+	var ok bool
+	result := types.Boolean
+	switch e.Operator {
+	case "+", "-", "*", "/":
+		ok = numeric(l) && numeric(r)
+		switch {
+		case l == types.Unknown || r == types.Unknown:
+			result = types.Unknown
+		case l == types.Real || r == types.Real:
+			result = types.Real
+		default:
+			result = types.Integer
+		}
+	case "%":
+		ok = integer(l) && integer(r)
+		result = types.Integer
+	case "<", ">", "<=", ">=":
+		ok = numeric(l) && numeric(r)
+	case "=", "!=":
+		ok = numeric(l) && numeric(r) || boolean(l) && boolean(r)
+	case "&&", "||":
+		ok = boolean(l) && boolean(r)
+	}
+	if !ok {
+		c.errors.at(e.Token, msg.OperandType, e.Operator, l, r)
+		return types.Unknown
+	}
+	return result
+}
+
+func numeric(t types.Type) bool {
+	return t == types.Integer || t == types.Real || t == types.Unknown
+}
+
+func integer(t types.Type) bool { return t == types.Integer || t == types.Unknown }
+func boolean(t types.Type) bool { return t == types.Boolean || t == types.Unknown }
+
+// assignable integer 的值可以赋给 real 变量, 反之不行
+func assignable(variable, value types.Type) bool {
+	return variable == value || variable == types.Unknown || value == types.Unknown ||
+		variable == types.Real && value == types.Integer
+}
@@ -0,0 +1,66 @@
+package semantic
+
+import (
+	"mini-parser/parser"
+	"testing"
+)
+
+func TestCheckTypes(t *testing.T) {
+	const decls = "program p;\nconst n = 10; r0 = 1.5;\nvar i: integer; r: real; b: boolean;\n"
+	runDiagnostics(t, []diagnosticTest{
+		{name: "well typed", src: decls + `begin
+    i := n % 3 + 1; r := i / 2 * r0; r := 2;
+    b := (i > 1) && !(r <= 1.5) || (i = n);
+    while (b) do b := false
+end.`},
+		{name: "arithmetic", src: decls + "begin i := i + b end.",
+			errors: []string{"4:14 运算符 + 不能用于 integer 和 boolean 类型的操作数"}},
+		{name: "mod", src: decls + "begin i := i % r end.",
+			errors: []string{"4:14 运算符 % 不能用于 integer 和 real 类型的操作数"}},
+		{name: "logical", src: decls + "begin b := i && b end.",
+			errors: []string{"4:14 运算符 && 不能用于 integer 和 boolean 类型的操作数"}},
+		{name: "equality", src: decls + "begin b := b = 1 end.",
+			errors: []string{"4:14 运算符 = 不能用于 boolean 和 integer 类型的操作数"}},
+		{name: "not", src: decls + "begin b := !i end.",
+			errors: []string{"4:12 运算符 ! 不能用于 integer 类型的操作数"}},
+		{name: "minus", src: decls + "begin b := -b end.",
+			errors: []string{"4:12 运算符 - 不能用于 boolean 类型的操作数"}},
+		// 出错的子表达式类型未知, 外层不再报告
+		{name: "no cascade", src: decls + "begin i := (i + b) * 2 end.",
+			errors: []string{"4:15 运算符 + 不能用于 integer 和 boolean 类型的操作数"}},
+		{name: "if condition", src: decls + "begin if (i) then i := 1 end.",
+			errors: []string{"4:11 if 语句的条件必须是 boolean 类型, 实际为 integer"}},
+		{name: "while condition", src: decls + "begin while (r + 1) do r := 0 end.",
+			errors: []string{"4:14 while 语句的条件必须是 boolean 类型, 实际为 real"}},
+		{name: "assign const", src: decls + "begin n := 1 end.",
+			errors: []string{"4:7 不能给常量 n 赋值"}},
+		{name: "real to integer", src: decls + "begin i := r * 2 end.",
+			errors: []string{"4:12 不能把 real 类型的值赋给 integer 类型的变量 i"}},
+		// 常量的类型取自它的值
+		{name: "const type", src: decls + "begin i := r0 end.",
+			errors: []string{"4:12 不能把 real 类型的值赋给 integer 类型的变量 i"}},
+		{name: "boolean to real", src: decls + "begin r := i < 2 end.",
+			errors: []string{"4:12 不能把 boolean 类型的值赋给 real 类型的变量 r"}},
+		// 形参、函数结果和没有标注类型的局部变量类型未知, 与任何类型相容
+		{name: "unknown", src: decls + `function f(a);
+var t;
+begin t := a && true; return a + 1 end;
+begin i := f(b); b := f(1) end.`},
+		// 没有声明部分的程序不做类型检查
+		{name: "implicit", src: "x := 1 + true; if (x) then y := 1"},
+	}, func(program *parser.Program) *Info {
+		info := Resolve(program)
+		info.Errors = CheckTypes(info)
+		return info
+	})
+}
+
+// 没有声明部分的程序中, 隐式变量的类型由 types.Infer 推断
+func TestImplicitTypes(t *testing.T) {
+	info := Check(parse(t, "i := 1; r := 2.5; b := i < r"))
+	for name, want := range map[string]string{"i": "integer", "r": "real", "b": "boolean"} {
+		if sym := info.Global.Lookup(name); sym == nil || sym.Type.String() != want {
+			t.Errorf("%s 的类型应为 %s: %+v", name, want, sym)
+		}
+	}
+}
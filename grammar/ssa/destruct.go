@@ -0,0 +1,72 @@
+package ssa
+
+import (
+	"mini-parser/cfg"
+	"mini-parser/parser"
+	"mini-parser/token"
+)
+
+// Destruct 把SSA形式翻译回普通控制流图: 每个phi替换为前驱块末尾的复制语句,
+// 关键边(前驱有多个后继且后继有多个前驱)先被拆分, 以免复制语句在另一条路径上执行
+func (f *Function) Destruct() *cfg.Graph {
+	g := &cfg.Graph{}
+	blocks := make([]*cfg.Block, len(f.Blocks))
+	for _, b := range f.Blocks {
+		blocks[b.ID] = &cfg.Block{ID: b.ID, Stmts: append([]parser.Statement(nil), b.Stmts...), Cond: b.Cond}
+		g.Blocks = append(g.Blocks, blocks[b.ID])
+	}
+	g.Entry = blocks[f.Graph.Entry.ID]
+	g.Exit = blocks[f.Graph.Exit.ID]
+
+	for _, b := range f.Blocks {
+		from := blocks[b.ID]
+		for _, succ := range b.Succs {
+			to := blocks[succ.ID]
+			phis := f.Blocks[succ.ID].Phis
+			j := predIndex(succ, b.Block)
+
+			// 没有phi时直接连边
+			if len(phis) == 0 {
+				connect(from, to)
+				continue
+			}
+
+			copyBlock := from
+			if len(b.Succs) > 1 && len(succ.Preds) > 1 {
+				copyBlock = &cfg.Block{ID: len(g.Blocks)}
+				g.Blocks = append(g.Blocks, copyBlock)
+				connect(from, copyBlock)
+				connect(copyBlock, to)
+			} else {
+				connect(from, to)
+			}
+			for _, phi := range phis {
+				copyBlock.Stmts = append(copyBlock.Stmts, copyStmt(phi.Dest, phi.Args[j]))
+			}
+		}
+	}
+
+	return g
+}
+
+func predIndex(b, pred *cfg.Block) int {
+	for i, p := range b.Preds {
+		if p == pred {
+			return i
+		}
+	}
+	return -1
+}
+
+func connect(from, to *cfg.Block) {
+	from.Succs = append(from.Succs, to)
+	to.Preds = append(to.Preds, from)
+}
+
+func copyStmt(dest, src string) *parser.AssignStatement {
+	return &parser.AssignStatement{
+		Token: token.Token{Type: token.IDENT, Literal: dest},
+		Name:  &parser.Identifier{Token: token.Token{Type: token.IDENT, Literal: dest}, Value: dest},
+		Value: &parser.Identifier{Token: token.Token{Type: token.IDENT, Literal: src}, Value: src},
+	}
+}
@@ -0,0 +1,253 @@
+package ssa
+
+import (
+	"fmt"
+	"mini-parser/cfg"
+	"mini-parser/dataflow"
+	"mini-parser/parser"
+	"strings"
+)
+
+// Phi 基本块入口处的phi函数, Args与所在块的Preds一一对应
+type Phi struct {
+	Var  string
+	Dest string
+	Args []string
+}
+
+// Block SSA形式的基本块, 语句和条件中的变量都已改名为带版本号的名字
+type Block struct {
+	*cfg.Block
+	Phis  []*Phi
+	Stmts []parser.Statement
+	Cond  parser.Expression
+}
+
+// Function SSA形式的程序
+type Function struct {
+	Graph  *cfg.Graph
+	Dom    *cfg.DomTree
+	Blocks []*Block
+	// Final 出口处每个被赋值的变量的SSA名字, 即程序结束时该变量的值
+	Final map[string]string
+}
+
+// Name 返回变量的SSA名字, 版本0表示程序入口处未赋值的初值
+func Name(v string, version int) string {
+	return fmt.Sprintf("%s.%d", v, version)
+}
+
+// Build 在控制流图上插入phi函数并重命名变量, 得到剪枝的SSA形式
+func Build(g *cfg.Graph) *Function {
+	f := &Function{Graph: g, Dom: cfg.Dominators(g), Blocks: make([]*Block, len(g.Blocks)), Final: map[string]string{}}
+	for _, b := range g.Blocks {
+		f.Blocks[b.ID] = &Block{Block: b}
+	}
+
+	vars := f.insertPhis()
+
+	r := &renamer{f: f, vars: vars, counter: map[string]int{}, stacks: map[string][]string{}}
+	r.rename(f.Blocks[g.Entry.ID])
+
+	return f
+}
+
+// insertPhis 在变量定值点的迭代支配边界上放置phi, 只保留变量在块入口活跃的phi。
+// 程序结束时所有变量都是结果(虚拟机打印它们, 后端用 -result 选取), 因此在出口处都活跃
+// 返回被赋值的变量
+func (f *Function) insertPhis() []string {
+	defsites := map[string][]*cfg.Block{}
+	var vars []string
+	for _, b := range f.Graph.Blocks {
+		for _, s := range b.Stmts {
+			if assign, ok := s.(*parser.AssignStatement); ok {
+				name := assign.Name.Value
+				if _, ok := defsites[name]; !ok {
+					vars = append(vars, name)
+				}
+				defsites[name] = append(defsites[name], b)
+			}
+		}
+	}
+
+	exit := dataflow.Set[string]{}
+	for _, v := range vars {
+		exit[v] = struct{}{}
+	}
+	live := dataflow.LivenessAt(f.Graph, exit)
+
+	for _, v := range vars {
+		// 入口块隐含定义了所有变量的初值
+		worklist := append([]*cfg.Block{f.Graph.Entry}, defsites[v]...)
+		hasPhi := map[*cfg.Block]bool{}
+		queued := map[*cfg.Block]bool{}
+		for _, b := range worklist {
+			queued[b] = true
+		}
+
+		for len(worklist) > 0 {
+			b := worklist[0]
+			worklist = worklist[1:]
+			for _, y := range f.Dom.Frontier[b.ID] {
+				if hasPhi[y] || !live.In[y.ID].Has(v) {
+					continue
+				}
+				hasPhi[y] = true
+				block := f.Blocks[y.ID]
+				block.Phis = append(block.Phis, &Phi{Var: v, Args: make([]string, len(y.Preds))})
+				if !queued[y] {
+					queued[y] = true
+					worklist = append(worklist, y)
+				}
+			}
+		}
+	}
+	return vars
+}
+
+type renamer struct {
+	f       *Function
+	vars    []string
+	counter map[string]int
+	stacks  map[string][]string
+}
+
+func (r *renamer) newName(v string) string {
+	r.counter[v]++
+	name := Name(v, r.counter[v])
+	r.stacks[v] = append(r.stacks[v], name)
+	return name
+}
+
+func (r *renamer) current(v string) string {
+	if stack := r.stacks[v]; len(stack) > 0 {
+		return stack[len(stack)-1]
+	}
+	return Name(v, 0)
+}
+
+func (r *renamer) rename(b *Block) {
+	pushed := map[string]int{}
+
+	for _, phi := range b.Phis {
+		phi.Dest = r.newName(phi.Var)
+		pushed[phi.Var]++
+	}
+
+	for _, s := range b.Block.Stmts {
+		if call, ok := s.(*parser.CallStatement); ok {
+			b.Stmts = append(b.Stmts, &parser.CallStatement{
+				Token: call.Token,
+				Call:  renameExpr(call.Call, r.current).(*parser.CallExpression),
+			})
+			continue
+		}
+		assign, ok := s.(*parser.AssignStatement)
+		if !ok {
+			b.Stmts = append(b.Stmts, s)
+			continue
+		}
+		value := renameExpr(assign.Value, r.current)
+		name := assign.Name.Value
+		dest := r.newName(name)
+		pushed[name]++
+		b.Stmts = append(b.Stmts, &parser.AssignStatement{
+			Token: assign.Token,
+			Name:  &parser.Identifier{Token: assign.Name.Token, Value: dest},
+			Value: value,
+		})
+	}
+
+	if b.Block.Cond != nil {
+		b.Cond = renameExpr(b.Block.Cond, r.current)
+	}
+	if b.Block == r.f.Graph.Exit {
+		for _, v := range r.vars {
+			r.f.Final[v] = r.current(v)
+		}
+	}
+
+	for _, succ := range b.Succs {
+		for j, pred := range succ.Preds {
+			if pred != b.Block {
+				continue
+			}
+			for _, phi := range r.f.Blocks[succ.ID].Phis {
+				phi.Args[j] = r.current(phi.Var)
+			}
+		}
+	}
+
+	for _, child := range r.f.Dom.Children[b.ID] {
+		r.rename(r.f.Blocks[child.ID])
+	}
+
+	for v, n := range pushed {
+		r.stacks[v] = r.stacks[v][:len(r.stacks[v])-n]
+	}
+}
+
+// renameExpr 复制表达式, 其中的变量引用按name改名
+func renameExpr(expr parser.Expression, name func(string) string) parser.Expression {
+	switch e := expr.(type) {
+	case *parser.Identifier:
+		return &parser.Identifier{Token: e.Token, Value: name(e.Value)}
+	case *parser.PrefixExpression:
+		return &parser.PrefixExpression{Token: e.Token, Operator: e.Operator, Right: renameExpr(e.Right, name)}
+	case *parser.InfixExpression:
+		return &parser.InfixExpression{
+			Token:    e.Token,
+			Left:     renameExpr(e.Left, name),
+			Operator: e.Operator,
+			Right:    renameExpr(e.Right, name),
+		}
+	case *parser.CallExpression:
+		call := &parser.CallExpression{Token: e.Token, Function: e.Function, Rparen: e.Rparen}
+		for _, arg := range e.Arguments {
+			call.Arguments = append(call.Arguments, renameExpr(arg, name))
+		}
+		return call
+	}
+	return expr
+}
+
+func (f *Function) String() string {
+	var out strings.Builder
+	for _, b := range f.Blocks {
+		fmt.Fprintf(&out, "B%d:", b.ID)
+		switch b.Block {
+		case f.Graph.Entry:
+			out.WriteString(" (entry)")
+		case f.Graph.Exit:
+			out.WriteString(" (exit)")
+		}
+		if idom := f.Dom.Idom[b.ID]; idom != nil {
+			fmt.Fprintf(&out, " idom=B%d", idom.ID)
+		}
+		if df := f.Dom.Frontier[b.ID]; len(df) > 0 {
+			ids := make([]string, len(df))
+			for i, d := range df {
+				ids[i] = fmt.Sprintf("B%d", d.ID)
+			}
+			fmt.Fprintf(&out, " DF={%s}", strings.Join(ids, ", "))
+		}
+		out.WriteString("\n")
+
+		for _, phi := range b.Phis {
+			args := make([]string, len(phi.Args))
+			for i, a := range phi.Args {
+				args[i] = fmt.Sprintf("%s [B%d]", a, b.Preds[i].ID)
+			}
+			fmt.Fprintf(&out, "    %s := phi(%s)\n", phi.Dest, strings.Join(args, ", "))
+		}
+		for _, s := range b.Stmts {
+			fmt.Fprintf(&out, "    %s\n", s.String())
+		}
+		if b.Cond != nil {
+			fmt.Fprintf(&out, "    if %s goto B%d else B%d\n", b.Cond.String(), b.Succs[0].ID, b.Succs[1].ID)
+		} else if len(b.Succs) == 1 {
+			fmt.Fprintf(&out, "    goto B%d\n", b.Succs[0].ID)
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,202 @@
+package ssa
+
+import (
+	"fmt"
+	"mini-parser/cfg"
+	"mini-parser/parser"
+	"mini-parser/token"
+	"strings"
+	"testing"
+)
+
+func build(t *testing.T, src string) *Function {
+	t.Helper()
+	p := parser.New(token.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("语法错误: %v", p.Errors())
+	}
+	return Build(cfg.Build(program))
+}
+
+// phis 返回各块中 phi 的文本, 如 "B3: r.3 := phi(r.2, r.1)"
+func phis(f *Function) []string {
+	var out []string
+	for _, b := range f.Blocks {
+		for _, phi := range b.Phis {
+			out = append(out, fmt.Sprintf("B%d: %s := phi(%s)", b.ID, phi.Dest, strings.Join(phi.Args, ", ")))
+		}
+	}
+	return out
+}
+
+func TestPhiPlacement(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		// 程序末尾的 if/else 之后 r 不再被读取, 但它是程序的结果, 汇合处仍要有 phi
+		{"final if", "x := 1; if (x > 0) then r := 1 else r := 2",
+			[]string{"B3: r.3 := phi(r.2, r.1)"}},
+		// 没有 else 时另一条路径带来入口处的初值
+		{"if without else", "if (x > 0) then r := 1",
+			[]string{"B2: r.2 := phi(r.1, r.0)"}},
+		// 循环头为循环中赋值的变量放置 phi, 只在循环前赋值的 n 不需要
+		{"while", "i := 0; n := 3; while (i < n) do i := i + 1",
+			[]string{"B1: i.2 := phi(i.1, i.3)"}},
+		{"straight line", "a := 1; a := a + 1; b := a", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := phis(build(t, tt.src))
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("phi 函数\n%s\n期望\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+// 改名后每个SSA名字只被定义一次, 引用都指向已定义的名字或版本0的初值
+func TestRename(t *testing.T) {
+	f := build(t, `i := 0; s := 0;
+while (i < 5) do begin
+    i := i + 1;
+    if (i % 2 = 0) then s := s + i else s := s - 1
+end;
+r := s`)
+	defined := map[string]bool{}
+	define := func(name string) {
+		if defined[name] {
+			t.Errorf("%s 被定义了不止一次", name)
+		}
+		defined[name] = true
+	}
+	for _, b := range f.Blocks {
+		for _, phi := range b.Phis {
+			define(phi.Dest)
+		}
+		for _, s := range b.Stmts {
+			define(s.(*parser.AssignStatement).Name.Value)
+		}
+	}
+	for _, b := range f.Blocks {
+		var refs []string
+		for _, phi := range b.Phis {
+			refs = append(refs, phi.Args...)
+		}
+		for _, s := range b.Stmts {
+			parser.Inspect(s.(*parser.AssignStatement).Value, func(n parser.Node) bool {
+				if ident, ok := n.(*parser.Identifier); ok {
+					refs = append(refs, ident.Value)
+				}
+				return true
+			})
+		}
+		for _, ref := range refs {
+			if !defined[ref] && !strings.HasSuffix(ref, ".0") {
+				t.Errorf("B%d 引用了未定义的 %s", b.ID, ref)
+			}
+		}
+	}
+	if f.Final["r"] != "r.1" || f.Final["s"] == "" || f.Final["i"] == "" {
+		t.Errorf("出口处的名字不对: %v", f.Final)
+	}
+}
+
+// 退出SSA后的控制流图与原图计算出相同的结果, 结果在 Final 给出的名字中
+func TestDestruct(t *testing.T) {
+	programs := []string{
+		"x := 1; if (x > 0) then r := 1 else r := 2",
+		"x := -1; if (x > 0) then r := 1 else r := 2",
+		"x := 0; r := 5; if (x > 0) then r := 1",
+		`i := 0; s := 0;
+while (i < 5) do begin
+    i := i + 1;
+    if (i % 2 = 0) then s := s + i else s := s - 1
+end`,
+		// 循环中交换两个变量, phi 的复制语句不能互相覆盖
+		"a := 1; b := 2; n := 0; while (n < 3) do begin t := a; a := b; b := t; n := n + 1 end",
+	}
+	for _, src := range programs {
+		p := parser.New(token.New(src))
+		program := p.ParseProgram()
+		g := cfg.Build(program)
+		want := run(t, g)
+
+		f := Build(g)
+		got := run(t, f.Destruct())
+		for v, value := range want {
+			name, ok := f.Final[v]
+			if !ok {
+				t.Errorf("%s: 出口处没有 %s 的名字", src, v)
+				continue
+			}
+			if got[name] != value {
+				t.Errorf("%s: %s 期望为 %d, 退出SSA后 %s 为 %d", src, v, value, name, got[name])
+			}
+		}
+	}
+}
+
+// run 解释执行只含整数的控制流图, 返回出口处的变量值
+func run(t *testing.T, g *cfg.Graph) map[string]int {
+	t.Helper()
+	env := map[string]int{}
+	b := g.Entry
+	for steps := 0; b != g.Exit; steps++ {
+		if steps > 1000 {
+			t.Fatal("执行步数过多")
+		}
+		for _, s := range b.Stmts {
+			assign := s.(*parser.AssignStatement)
+			env[assign.Name.Value] = eval(t, assign.Value, env)
+		}
+		switch {
+		case b.Cond != nil && eval(t, b.Cond, env) != 0:
+			b = b.Succs[0]
+		case b.Cond != nil:
+			b = b.Succs[1]
+		default:
+			b = b.Succs[0]
+		}
+	}
+	return env
+}
+
+// eval 计算整数表达式, 比较的结果为 0 或 1
+func eval(t *testing.T, expr parser.Expression, env map[string]int) int {
+	t.Helper()
+	bit := func(ok bool) int {
+		if ok {
+			return 1
+		}
+		return 0
+	}
+	switch e := expr.(type) {
+	case *parser.IntegerLiteral:
+		return int(e.Value)
+	case *parser.Identifier:
+		return env[e.Value]
+	case *parser.PrefixExpression:
+		return -eval(t, e.Right, env)
+	case *parser.InfixExpression:
+		l, r := eval(t, e.Left, env), eval(t, e.Right, env)
+		switch e.Operator {
+		case "+":
+			return l + r
+		case "-":
+			return l - r
+		case "%":
+			return l % r
+		case "<":
+			return bit(l < r)
+		case ">":
+			return bit(l > r)
+		case "=":
+			return bit(l == r)
+		}
+	}
+	t.Fatalf("测试解释器不支持 %s", expr.String())
+	return 0
+}
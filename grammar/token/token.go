@@ -9,11 +9,19 @@ type Token struct {
 	Column  int
 }
 
+// Comment 单行注释, Text 包含开头的 //
+type Comment struct {
+	Text   string
+	Line   int
+	Column int
+}
+
 const (
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
 	IDENT   = "IDENT"
 	NUMBER  = "NUMBER"
+	REAL    = "REAL"
 
 	// 运算符
 	ASSIGN   = ":="
@@ -22,8 +30,11 @@ const (
 	BANG     = "!"
 	ASTERISK = "*"
 	SLASH    = "/"
+	PERCENT  = "%"
 	LT       = "<"
 	GT       = ">"
+	LE       = "<="
+	GE       = ">="
 	EQ       = "="
 	NEQ      = "!="
 	AND      = "&&"
@@ -31,6 +42,7 @@ const (
 
 	// 分隔符
 	COMMA     = ","
+	COLON     = ":"
 	SEMICOLON = ";"
 	DOT       = "."
 	LPAREN    = "("
@@ -47,6 +59,12 @@ const (
 	DO      = "do"
 	TRUE    = "true"
 	FALSE   = "false"
+
+	PROCEDURE = "procedure"
+	FUNCTION  = "function"
+	VAR       = "var"
+	CONST     = "const"
+	RETURN    = "return"
 )
 
 var keywords = map[string]TokenType{
@@ -60,6 +78,12 @@ var keywords = map[string]TokenType{
 	"do":      DO,
 	"true":    TRUE,
 	"false":   FALSE,
+
+	"procedure": PROCEDURE,
+	"function":  FUNCTION,
+	"var":       VAR,
+	"const":     CONST,
+	"return":    RETURN,
 }
 
 func LookupIdent(ident string) TokenType {
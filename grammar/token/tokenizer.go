@@ -1,6 +1,7 @@
 package token
 
 import (
+	"strings"
 	"unicode"
 	"unicode/utf8"
 )
@@ -12,6 +13,7 @@ type Tokenizer struct {
 	ch           rune
 	line         int
 	column       int
+	comments     []Comment
 }
 
 func New(input string) *Tokenizer {
@@ -21,15 +23,17 @@ func New(input string) *Tokenizer {
 }
 
 func (t *Tokenizer) readChar() {
+	t.position = t.readPosition
 	if t.readPosition >= len(t.input) {
 		t.ch = 0
+		t.readPosition++
 	} else {
-		t.ch = rune(t.input[t.readPosition])
+		// 按UTF-8解码, 避免中文注释打乱列号
+		ch, size := utf8.DecodeRuneInString(t.input[t.readPosition:])
+		t.ch = ch
+		t.readPosition += size
 	}
 
-	t.position = t.readPosition
-	t.readPosition += utf8.RuneLen(t.ch)
-
 	if t.ch == '\n' {
 		t.line++
 		t.column = 0
@@ -49,7 +53,7 @@ func (t *Tokenizer) NextToken() Token {
 			t.readChar()
 			tok = Token{Type: ASSIGN, Literal: ":=", Line: t.line, Column: t.column - 1}
 		} else {
-			tok = newToken(ILLEGAL, t.ch, t.line, t.column)
+			tok = newToken(COLON, t.ch, t.line, t.column)
 		}
 	case ';':
 		tok = newToken(SEMICOLON, t.ch, t.line, t.column)
@@ -86,15 +90,29 @@ func (t *Tokenizer) NextToken() Token {
 		tok = newToken(ASTERISK, t.ch, t.line, t.column)
 	case '/':
 		tok = newToken(SLASH, t.ch, t.line, t.column)
+	case '%':
+		tok = newToken(PERCENT, t.ch, t.line, t.column)
 	case '<':
-		tok = newToken(LT, t.ch, t.line, t.column)
+		if t.peekChar() == '=' {
+			t.readChar()
+			tok = Token{Type: LE, Literal: "<=", Line: t.line, Column: t.column - 1}
+		} else {
+			tok = newToken(LT, t.ch, t.line, t.column)
+		}
 	case '>':
-		tok = newToken(GT, t.ch, t.line, t.column)
+		if t.peekChar() == '=' {
+			t.readChar()
+			tok = Token{Type: GE, Literal: ">=", Line: t.line, Column: t.column - 1}
+		} else {
+			tok = newToken(GT, t.ch, t.line, t.column)
+		}
 	case '=':
 		tok = newToken(EQ, t.ch, t.line, t.column)
 	case 0:
 		tok.Literal = ""
 		tok.Type = EOF
+		tok.Line = t.line
+		tok.Column = t.column
 	default:
 		if unicode.IsLetter(t.ch) {
 			tok.Line = t.line
@@ -105,8 +123,7 @@ func (t *Tokenizer) NextToken() Token {
 		} else if unicode.IsDigit(t.ch) {
 			tok.Line = t.line
 			tok.Column = t.column
-			tok.Literal = t.readNumber()
-			tok.Type = NUMBER
+			tok.Literal, tok.Type = t.readNumber()
 			return tok
 		} else {
 			tok = newToken(ILLEGAL, t.ch, t.line, t.column)
@@ -124,10 +141,14 @@ func (t *Tokenizer) skipWhitespace() {
 			t.readChar()
 		case '/':
 			if t.peekChar() == '/' {
-				// 跳过单行注释
+				// 跳过单行注释, 记录下来供格式化程序使用
+				comment := Comment{Line: t.line, Column: t.column}
+				position := t.position
 				for t.ch != '\n' && t.ch != 0 {
 					t.readChar()
 				}
+				comment.Text = strings.TrimRight(t.input[position:t.position], " \t\r")
+				t.comments = append(t.comments, comment)
 			} else {
 				return
 			}
@@ -137,6 +158,11 @@ func (t *Tokenizer) skipWhitespace() {
 	}
 }
 
+// Comments 返回已经扫描过的注释
+func (t *Tokenizer) Comments() []Comment {
+	return t.comments
+}
+
 func (t *Tokenizer) readIdentifier() string {
 	position := t.position
 	for unicode.IsLetter(t.ch) || unicode.IsDigit(t.ch) || t.ch == '_' {
@@ -145,12 +171,20 @@ func (t *Tokenizer) readIdentifier() string {
 	return t.input[position:t.position]
 }
 
-func (t *Tokenizer) readNumber() string {
+func (t *Tokenizer) readNumber() (string, TokenType) {
 	position := t.position
 	for unicode.IsDigit(t.ch) {
 		t.readChar()
 	}
-	return t.input[position:t.position]
+	// 小数点后紧跟数字才是实数, 否则如"end."中的点号留给DOT
+	if t.ch != '.' || !unicode.IsDigit(t.peekChar()) {
+		return t.input[position:t.position], NUMBER
+	}
+	t.readChar()
+	for unicode.IsDigit(t.ch) {
+		t.readChar()
+	}
+	return t.input[position:t.position], REAL
 }
 
 func (t *Tokenizer) peekChar() rune {
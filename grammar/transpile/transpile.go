@@ -0,0 +1,286 @@
+package transpile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mini-parser/msg"
+	"mini-parser/parser"
+	"mini-parser/types"
+	"strings"
+)
+
+type language int
+
+const (
+	langC language = iota
+	langGo
+)
+
+func (l language) String() string {
+	if l == langGo {
+		return "Go"
+	}
+	return "C"
+}
+
+// ToC 把Mini程序翻译为等价的C99程序, 程序结束时打印所有变量的值
+func ToC(program *parser.Program) (string, error) {
+	return translate(program, langC)
+}
+
+// ToGo 把Mini程序翻译为等价的Go程序, 程序结束时打印所有变量的值
+func ToGo(program *parser.Program) (string, error) {
+	return translate(program, langGo)
+}
+
+type translator struct {
+	out    bytes.Buffer
+	lang   language
+	info   *types.Info
+	vars   []string
+	indent int
+}
+
+func translate(program *parser.Program, lang language) (string, error) {
+	t := &translator{lang: lang, info: types.Infer(program)}
+	if len(t.info.Errors) > 0 {
+		return "", fmt.Errorf("%s", strings.Join(t.info.Errors, "\n"))
+	}
+
+	t.vars = parser.Variables(program)
+
+	t.header(program)
+	t.indent++
+	for _, name := range t.vars {
+		t.declare(name)
+	}
+	for _, s := range program.Statements {
+		if err := t.statement(s); err != nil {
+			return "", err
+		}
+	}
+	t.footer()
+
+	return t.out.String(), nil
+}
+
+func (t *translator) line(format string, args ...interface{}) {
+	unit := "\t"
+	if t.lang == langC {
+		unit = "    "
+	}
+	t.out.WriteString(strings.Repeat(unit, t.indent))
+	fmt.Fprintf(&t.out, format, args...)
+	t.out.WriteString("\n")
+}
+
+func (t *translator) header(program *parser.Program) {
+	name := "mini"
+	if program.Name != nil {
+		name = program.Name.Value
+	}
+
+	if t.lang == langC {
+		t.line("// 由Mini程序 %s 翻译生成", name)
+		t.line("#include <stdbool.h>")
+		t.line("#include <stdio.h>")
+		t.line("")
+		t.line("int main(void) {")
+		return
+	}
+
+	t.line("// 由Mini程序 %s 翻译生成", name)
+	t.line("")
+	// 避免生成的文件被当作所在目录包的一部分, 使用 go run 文件名 运行
+	t.line("//go:build ignore")
+	t.line("")
+	t.line("package main")
+	t.line("")
+	t.line("import \"fmt\"")
+	t.line("")
+	t.line("func main() {")
+}
+
+// ident 生成的程序中变量的名字, 加上前缀以免与C/Go的关键字或 main、printf、fmt 等名字冲突
+func ident(name string) string {
+	return "v_" + name
+}
+
+func (t *translator) declare(name string) {
+	typ := t.info.Vars[name]
+	name = ident(name)
+	if t.lang == langC {
+		switch typ {
+		case types.Real:
+			t.line("double %s = 0;", name)
+		case types.Boolean:
+			t.line("bool %s = false;", name)
+		default:
+			t.line("long long %s = 0;", name)
+		}
+		return
+	}
+
+	switch typ {
+	case types.Real:
+		t.line("var %s float64", name)
+	case types.Boolean:
+		t.line("var %s bool", name)
+	default:
+		t.line("var %s int64", name)
+	}
+}
+
+func (t *translator) footer() {
+	t.line("")
+	for _, name := range t.vars {
+		if t.lang == langGo {
+			t.line("fmt.Println(%q, %s)", name+" =", ident(name))
+			continue
+		}
+		switch t.info.Vars[name] {
+		case types.Real:
+			t.line("printf(\"%s = %%g\\n\", %s);", name, ident(name))
+		case types.Boolean:
+			t.line("printf(\"%s = %%s\\n\", %s ? \"true\" : \"false\");", name, ident(name))
+		default:
+			t.line("printf(\"%s = %%lld\\n\", %s);", name, ident(name))
+		}
+	}
+	if t.lang == langC {
+		t.line("return 0;")
+	}
+	t.indent--
+	t.line("}")
+}
+
+func (t *translator) statement(stmt parser.Statement) error {
+	switch s := stmt.(type) {
+	case *parser.AssignStatement:
+		t.line("// line %d", s.Token.Line)
+		value, err := t.expression(s.Value, t.info.Vars[s.Name.Value])
+		if err != nil {
+			return err
+		}
+		if t.lang == langC {
+			t.line("%s = %s;", ident(s.Name.Value), value)
+		} else {
+			t.line("%s = %s", ident(s.Name.Value), value)
+		}
+
+	case *parser.BlockStatement:
+		for _, inner := range s.Statements {
+			if err := t.statement(inner); err != nil {
+				return err
+			}
+		}
+
+	case *parser.IfExpression:
+		t.line("// line %d", s.Token.Line)
+		cond, err := t.expression(s.Condition, types.Boolean)
+		if err != nil {
+			return err
+		}
+		t.line("if %s {", t.condition(cond))
+		if err := t.body(s.Consequence); err != nil {
+			return err
+		}
+		if s.Alternative != nil {
+			t.line("} else {")
+			if err := t.body(s.Alternative); err != nil {
+				return err
+			}
+		}
+		t.line("}")
+
+	case *parser.WhileExpression:
+		t.line("// line %d", s.Token.Line)
+		cond, err := t.expression(s.Condition, types.Boolean)
+		if err != nil {
+			return err
+		}
+		keyword := "while"
+		if t.lang == langGo {
+			keyword = "for"
+		}
+		t.line("%s %s {", keyword, t.condition(cond))
+		if err := t.body(s.Body); err != nil {
+			return err
+		}
+		t.line("}")
+
+	default:
+		return errors.New(msg.Get(msg.UnsupportedStatement, t.lang, stmt))
+	}
+	return nil
+}
+
+func (t *translator) body(block *parser.BlockStatement) error {
+	t.indent++
+	defer func() { t.indent-- }()
+	return t.statement(block)
+}
+
+// condition C语言的条件必须带括号, 表达式本身已带括号时不再重复
+func (t *translator) condition(cond string) string {
+	if t.lang == langC && !strings.HasPrefix(cond, "(") {
+		return "(" + cond + ")"
+	}
+	return cond
+}
+
+var operators = map[string]string{
+	"=": "==",
+}
+
+// expression 翻译表达式, want为期望的类型, Go中integer到real需要显式转换
+func (t *translator) expression(expr parser.Expression, want types.Type) (string, error) {
+	text, err := t.raw(expr)
+	if err != nil {
+		return "", err
+	}
+	if t.lang == langGo && want == types.Real && t.info.TypeOf(expr) == types.Integer {
+		return "float64(" + text + ")", nil
+	}
+	return text, nil
+}
+
+func (t *translator) raw(expr parser.Expression) (string, error) {
+	switch e := expr.(type) {
+	case *parser.IntegerLiteral:
+		return e.Token.Literal, nil
+	case *parser.RealLiteral:
+		return e.Token.Literal, nil
+	case *parser.Boolean:
+		return e.Token.Literal, nil
+	case *parser.Identifier:
+		return ident(e.Value), nil
+	case *parser.PrefixExpression:
+		right, err := t.expression(e.Right, t.info.TypeOf(e.Right))
+		if err != nil {
+			return "", err
+		}
+		return "(" + e.Operator + right + ")", nil
+	case *parser.InfixExpression:
+		// 数值运算中一侧为real时另一侧也按real计算
+		operand := t.info.TypeOf(e.Left)
+		if t.info.TypeOf(e.Right) == types.Real {
+			operand = types.Real
+		}
+		left, err := t.expression(e.Left, operand)
+		if err != nil {
+			return "", err
+		}
+		right, err := t.expression(e.Right, operand)
+		if err != nil {
+			return "", err
+		}
+		op := e.Operator
+		if mapped, ok := operators[op]; ok {
+			op = mapped
+		}
+		return "(" + left + " " + op + " " + right + ")", nil
+	}
+	return "", errors.New(msg.Get(msg.UnsupportedExpression, t.lang, expr))
+}
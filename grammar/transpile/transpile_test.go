@@ -0,0 +1,156 @@
+package transpile
+
+import (
+	"mini-parser/compiler"
+	"mini-parser/parser"
+	"mini-parser/token"
+	"mini-parser/vm"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// 每个程序都给所有变量赋值且不会出现运行错误, 翻译结果打印的变量与虚拟机相同
+var programs = map[string]string{
+	"integer": `
+i := 0; sum := 0; odd := 0;
+while (i < 10) do begin
+    i := i + 1;
+    sum := sum + i * 2 - 1;
+    if (i % 2 = 1 && !(i > 7)) then odd := odd + 1
+end;
+q := -sum / 7;
+r := sum % 7;
+done := i >= 10 || sum < 0`,
+	"real": `
+a := 1.0; b := 2.5;
+c := a / 2 + b;
+a := a + 1.5;
+d := 7 / 2 * b;
+e := -b < a`,
+	// C 和 Go 的关键字以及生成的程序用到的名字
+	"names": `
+int := 1; func := int + 1; main := func * 2;
+printf := main - 1; fmt := printf % 4; double := 2.5 * fmt;
+bool := double > 1.0; package := !bool`,
+	"declared": `
+program d;
+var x, y: real; i: integer; f: boolean;
+begin
+    x := 1;
+    x := x / 2;
+    i := 7 / 2;
+    y := i;
+    f := x < y
+end.`,
+}
+
+func parse(t *testing.T, src string) *parser.Program {
+	t.Helper()
+	p := parser.New(token.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("语法错误: %v", p.Errors())
+	}
+	return program
+}
+
+// runVM 在虚拟机中运行程序, 返回按行排序的 变量 = 值
+func runVM(t *testing.T, program *parser.Program) []string {
+	t.Helper()
+	comp := compiler.New()
+	if err := comp.Compile(program); err != nil {
+		t.Fatal(err)
+	}
+	bytecode := comp.Bytecode()
+	machine := vm.New(bytecode)
+	if err := machine.Run(); err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for i, value := range machine.Globals() {
+		if value != nil {
+			lines = append(lines, bytecode.Names[i]+" = "+value.Inspect())
+		}
+	}
+	slices.Sort(lines)
+	return lines
+}
+
+// runTarget 编译并运行翻译结果, 返回按行排序的输出
+func runTarget(t *testing.T, text, file string, command ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, file)
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for i, arg := range command {
+		command[i] = strings.ReplaceAll(strings.ReplaceAll(arg, "$src", path), "$dir", dir)
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %s\n%s", err, out, text)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	slices.Sort(lines)
+	return lines
+}
+
+func TestGoMatchesVM(t *testing.T) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("没有找到 go 命令")
+	}
+	for name, src := range programs {
+		t.Run(name, func(t *testing.T) {
+			program := parse(t, src)
+			text, err := ToGo(program)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := runVM(t, program)
+			got := runTarget(t, text, "main.go", goTool, "run", "$src")
+			if !slices.Equal(got, want) {
+				t.Errorf("Go 程序输出\n%s\n虚拟机输出\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+		})
+	}
+}
+
+func TestCMatchesVM(t *testing.T) {
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("没有找到 C 编译器")
+	}
+	for name, src := range programs {
+		t.Run(name, func(t *testing.T) {
+			program := parse(t, src)
+			text, err := ToC(program)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := runVM(t, program)
+			got := runTarget(t, text, "main.c", "sh", "-c", cc+" -std=c99 -o $dir/prog $src && $dir/prog")
+			if !slices.Equal(got, want) {
+				t.Errorf("C 程序输出\n%s\n虚拟机输出\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+		})
+	}
+}
+
+// 没有声明类型的变量先后保存 integer 和 real 时, 虚拟机中的除法随当时的类型变化, 不能按一种类型翻译
+func TestRejectsChangingType(t *testing.T) {
+	program := parse(t, "a := 1; b := 2.5; c := a / 2 + b; a := a + 1.5")
+	for _, translate := range []func(*parser.Program) (string, error){ToC, ToGo} {
+		_, err := translate(program)
+		if err == nil || !strings.Contains(err.Error(), "变量 a") {
+			t.Errorf("期望报告变量 a 的类型改变, 实际为 %v", err)
+		}
+	}
+}
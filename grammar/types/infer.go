@@ -0,0 +1,191 @@
+package types
+
+import (
+	"errors"
+	"mini-parser/msg"
+	"mini-parser/parser"
+)
+
+type Type int
+
+const (
+	Unknown Type = iota
+	Integer
+	Real
+	Boolean
+)
+
+func (t Type) String() string {
+	switch t {
+	case Integer:
+		return "integer"
+	case Real:
+		return "real"
+	case Boolean:
+		return "boolean"
+	}
+	return "unknown"
+}
+
+// Lookup 返回 var 部分中类型名对应的类型
+func Lookup(name string) (Type, bool) {
+	switch name {
+	case "integer":
+		return Integer, true
+	case "real":
+		return Real, true
+	case "boolean":
+		return Boolean, true
+	}
+	return Unknown, false
+}
+
+// CheckIntegral 供只处理整数的后端使用: 声明为 real 的变量无法表示, 在类型名处报告错误
+func CheckIntegral(program *parser.Program, backend string) error {
+	for _, decl := range program.Vars {
+		if decl.Type != nil && decl.Type.Value == "real" {
+			return errors.New(msg.Get(msg.Location, decl.Type.Token.Line, decl.Type.Token.Column, msg.Get(msg.RealUnsupported, backend)))
+		}
+	}
+	return nil
+}
+
+// Info 类型推断的结果
+type Info struct {
+	Vars   map[string]Type
+	Exprs  map[parser.Expression]Type
+	Errors []string
+}
+
+// Infer 根据赋值语句推断每个变量的类型; 变量的类型取所有赋值的上界,
+// integer可以提升为real, 数值和boolean混用时报告错误。从未赋值的变量按integer处理。
+// var 部分标注了类型的变量取声明的类型。
+// 虚拟机中变量的类型随赋值改变, 只有声明为 real 的变量会把 integer 转换为 real,
+// 因此没有声明类型的变量被先后赋予 integer 和 real 值时也报告错误, 按一种类型翻译的程序与虚拟机的结果不一致
+func Infer(program *parser.Program) *Info {
+	info := &Info{Vars: map[string]Type{}, Exprs: map[parser.Expression]Type{}}
+	declared := map[string]bool{}
+	for _, decl := range program.Vars {
+		if decl.Type == nil {
+			continue
+		}
+		if t, ok := Lookup(decl.Type.Value); ok {
+			for _, name := range decl.Names {
+				info.Vars[name.Value] = t
+				declared[name.Value] = true
+			}
+		}
+	}
+
+	var assigns []*parser.AssignStatement
+	parser.Inspect(program, func(n parser.Node) bool {
+		if assign, ok := n.(*parser.AssignStatement); ok {
+			assigns = append(assigns, assign)
+		}
+		return true
+	})
+
+	// 变量类型只会单调提升, 迭代到不再变化为止
+	reported := map[*parser.AssignStatement]bool{}
+	for changed := true; changed; {
+		changed = false
+		for _, assign := range assigns {
+			name := assign.Name.Value
+			t := info.expr(assign.Value)
+			if declared[name] {
+				// 声明的类型不随赋值改变, 类型不符由 semantic.CheckTypes 报告
+				continue
+			}
+			joined, ok := join(info.Vars[name], t)
+			if !ok {
+				if !reported[assign] {
+					reported[assign] = true
+					info.Errors = append(info.Errors, msg.Get(msg.Location, assign.Name.Token.Line, assign.Name.Token.Column,
+						msg.Get(msg.ChangedType, name, info.Vars[name], t)))
+				}
+				continue
+			}
+			if joined != info.Vars[name] {
+				info.Vars[name] = joined
+				changed = true
+			}
+		}
+	}
+
+	for _, name := range parser.Variables(program) {
+		if info.Vars[name] == Unknown {
+			info.Vars[name] = Integer
+		}
+	}
+	// 以最终的变量类型重新计算各表达式的类型
+	for _, assign := range assigns {
+		name := assign.Name.Value
+		if info.expr(assign.Value) == Integer && info.Vars[name] == Real && !declared[name] && !reported[assign] {
+			info.Errors = append(info.Errors, msg.Get(msg.Location, assign.Name.Token.Line, assign.Name.Token.Column,
+				msg.Get(msg.IntegerNotConverted, name)))
+		}
+	}
+	parser.Inspect(program, func(n parser.Node) bool {
+		switch s := n.(type) {
+		case *parser.IfExpression:
+			info.expr(s.Condition)
+		case *parser.WhileExpression:
+			info.expr(s.Condition)
+		}
+		return true
+	})
+
+	return info
+}
+
+// TypeOf 返回表达式推断出的类型
+func (info *Info) TypeOf(expr parser.Expression) Type {
+	return info.Exprs[expr]
+}
+
+func (info *Info) expr(expr parser.Expression) Type {
+	var t Type
+	switch e := expr.(type) {
+	case *parser.IntegerLiteral:
+		t = Integer
+	case *parser.RealLiteral:
+		t = Real
+	case *parser.Boolean:
+		t = Boolean
+	case *parser.Identifier:
+		t = info.Vars[e.Value]
+	case *parser.PrefixExpression:
+		t = info.expr(e.Right)
+		if e.Operator == "!" {
+			t = Boolean
+		}
+	case *parser.InfixExpression:
+		l := info.expr(e.Left)
+		r := info.expr(e.Right)
+		switch e.Operator {
+		case "+", "-", "*", "/":
+			t = Integer
+			if l == Real || r == Real {
+				t = Real
+			}
+		case "%":
+			t = Integer
+		default:
+			t = Boolean
+		}
+	}
+	info.Exprs[expr] = t
+	return t
+}
+
+func join(a, b Type) (Type, bool) {
+	switch {
+	case a == Unknown:
+		return b, true
+	case b == Unknown, a == b:
+		return a, true
+	case a != Boolean && b != Boolean:
+		return Real, true
+	}
+	return a, false
+}
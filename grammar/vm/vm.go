@@ -0,0 +1,308 @@
+package vm
+
+import (
+	"mini-parser/code"
+	"mini-parser/compiler"
+	"mini-parser/msg"
+	"mini-parser/object"
+)
+
+const StackSize = 2048
+
+var (
+	True  = &object.Boolean{Value: true}
+	False = &object.Boolean{Value: false}
+)
+
+// RuntimeError 运行时错误, 位置取自字节码的指令-源位置表
+type RuntimeError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e RuntimeError) Error() string {
+	return msg.Get(msg.RuntimeFailed, msg.Get(msg.Location, e.Line, e.Column, e.Message))
+}
+
+type VM struct {
+	bytecode *compiler.Bytecode
+	globals  []object.Object
+
+	stack []object.Object
+	sp    int // 指向下一个空闲位置, 栈顶为stack[sp-1]
+
+	ip int // 当前指令的偏移
+}
+
+func New(bytecode *compiler.Bytecode) *VM {
+	return &VM{
+		bytecode: bytecode,
+		globals:  make([]object.Object, len(bytecode.Names)),
+		stack:    make([]object.Object, StackSize),
+	}
+}
+
+// NewWithGlobals 沿用已有的变量值运行新的字节码, 字节码中新增的变量初始为未赋值
+func NewWithGlobals(bytecode *compiler.Bytecode, globals []object.Object) *VM {
+	vm := New(bytecode)
+	copy(vm.globals, globals)
+	return vm
+}
+
+// Globals 返回各变量槽位的值, 未赋值的变量为nil
+func (vm *VM) Globals() []object.Object {
+	return vm.globals
+}
+
+// Lookup 按变量名查询变量的值
+func (vm *VM) Lookup(name string) (object.Object, bool) {
+	for i, n := range vm.bytecode.Names {
+		if n == name && vm.globals[i] != nil {
+			return vm.globals[i], true
+		}
+	}
+	return nil, false
+}
+
+func (vm *VM) Run() error {
+	ins := vm.bytecode.Instructions
+
+	for vm.ip = 0; vm.ip < len(ins); vm.ip++ {
+		op := code.Opcode(ins[vm.ip])
+
+		switch op {
+		case code.OpConstant:
+			idx := code.ReadUint16(ins[vm.ip+1:])
+			vm.ip += 2
+			if err := vm.push(vm.bytecode.Constants[idx]); err != nil {
+				return err
+			}
+
+		case code.OpLoad:
+			slot := code.ReadUint16(ins[vm.ip+1:])
+			vm.ip += 2
+			value := vm.globals[slot]
+			if value == nil {
+				return vm.errorf(msg.UnassignedVariable, vm.bytecode.Names[slot])
+			}
+			if err := vm.push(value); err != nil {
+				return err
+			}
+
+		case code.OpStore:
+			slot := code.ReadUint16(ins[vm.ip+1:])
+			vm.ip += 2
+			vm.globals[slot] = vm.pop()
+
+		case code.OpTrue:
+			if err := vm.push(True); err != nil {
+				return err
+			}
+
+		case code.OpFalse:
+			if err := vm.push(False); err != nil {
+				return err
+			}
+
+		case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpMod:
+			if err := vm.executeArithmetic(op); err != nil {
+				return err
+			}
+
+		case code.OpEqual, code.OpNotEqual, code.OpLess, code.OpGreater,
+			code.OpLessEqual, code.OpGreaterEqual:
+			if err := vm.executeComparison(op); err != nil {
+				return err
+			}
+
+		case code.OpMinus:
+			if err := vm.executeMinus(); err != nil {
+				return err
+			}
+
+		case code.OpBang:
+			operand, ok := vm.pop().(*object.Boolean)
+			if !ok {
+				return vm.errorf(msg.BangOperand)
+			}
+			if err := vm.push(nativeBool(!operand.Value)); err != nil {
+				return err
+			}
+
+		case code.OpReal:
+			if i, ok := vm.stack[vm.sp-1].(*object.Integer); ok {
+				vm.stack[vm.sp-1] = &object.Real{Value: float64(i.Value)}
+			}
+
+		case code.OpJump:
+			pos := int(code.ReadUint16(ins[vm.ip+1:]))
+			vm.ip = pos - 1
+
+		case code.OpJumpNotTruthy:
+			pos := int(code.ReadUint16(ins[vm.ip+1:]))
+			condition, ok := vm.pop().(*object.Boolean)
+			if !ok {
+				return vm.errorf(msg.ConditionNotBoolean)
+			}
+			if condition.Value {
+				vm.ip += 2
+			} else {
+				vm.ip = pos - 1
+			}
+
+		default:
+			return vm.errorf(msg.UnknownOpcode, op)
+		}
+	}
+
+	return nil
+}
+
+func (vm *VM) executeArithmetic(op code.Opcode) error {
+	right := vm.pop()
+	left := vm.pop()
+
+	if l, ok := left.(*object.Integer); ok {
+		if r, ok := right.(*object.Integer); ok {
+			return vm.executeIntegerArithmetic(op, l.Value, r.Value)
+		}
+	}
+
+	l, lok := realValue(left)
+	r, rok := realValue(right)
+	if !lok || !rok {
+		return vm.errorf(msg.ArithmeticOperands, left.Type(), right.Type())
+	}
+
+	var result float64
+	switch op {
+	case code.OpAdd:
+		result = l + r
+	case code.OpSub:
+		result = l - r
+	case code.OpMul:
+		result = l * r
+	case code.OpDiv:
+		if r == 0 {
+			return vm.errorf(msg.DivisionByZero)
+		}
+		result = l / r
+	case code.OpMod:
+		return vm.errorf(msg.ModOperands)
+	}
+	return vm.push(&object.Real{Value: result})
+}
+
+func (vm *VM) executeIntegerArithmetic(op code.Opcode, l, r int64) error {
+	var result int64
+	switch op {
+	case code.OpAdd:
+		result = l + r
+	case code.OpSub:
+		result = l - r
+	case code.OpMul:
+		result = l * r
+	case code.OpDiv, code.OpMod:
+		if r == 0 {
+			return vm.errorf(msg.DivisionByZero)
+		}
+		if op == code.OpDiv {
+			result = l / r
+		} else {
+			result = l % r
+		}
+	}
+	return vm.push(&object.Integer{Value: result})
+}
+
+func (vm *VM) executeComparison(op code.Opcode) error {
+	right := vm.pop()
+	left := vm.pop()
+
+	if l, ok := left.(*object.Boolean); ok {
+		r, ok := right.(*object.Boolean)
+		if !ok {
+			return vm.errorf(msg.CannotCompare, left.Type(), right.Type())
+		}
+		switch op {
+		case code.OpEqual:
+			return vm.push(nativeBool(l.Value == r.Value))
+		case code.OpNotEqual:
+			return vm.push(nativeBool(l.Value != r.Value))
+		}
+		return vm.errorf(msg.BooleanComparison)
+	}
+
+	l, lok := realValue(left)
+	r, rok := realValue(right)
+	if !lok || !rok {
+		return vm.errorf(msg.CannotCompare, left.Type(), right.Type())
+	}
+
+	switch op {
+	case code.OpEqual:
+		return vm.push(nativeBool(l == r))
+	case code.OpNotEqual:
+		return vm.push(nativeBool(l != r))
+	case code.OpLess:
+		return vm.push(nativeBool(l < r))
+	case code.OpGreater:
+		return vm.push(nativeBool(l > r))
+	case code.OpLessEqual:
+		return vm.push(nativeBool(l <= r))
+	default:
+		return vm.push(nativeBool(l >= r))
+	}
+}
+
+func (vm *VM) executeMinus() error {
+	switch operand := vm.pop().(type) {
+	case *object.Integer:
+		return vm.push(&object.Integer{Value: -operand.Value})
+	case *object.Real:
+		return vm.push(&object.Real{Value: -operand.Value})
+	default:
+		return vm.errorf(msg.MinusOperand, operand.Type())
+	}
+}
+
+func (vm *VM) push(o object.Object) error {
+	if vm.sp >= StackSize {
+		return vm.errorf(msg.StackOverflow)
+	}
+
+	vm.stack[vm.sp] = o
+	vm.sp++
+
+	return nil
+}
+
+func (vm *VM) pop() object.Object {
+	o := vm.stack[vm.sp-1]
+	vm.sp--
+	return o
+}
+
+// errorf 生成带有当前指令源位置的运行时错误
+func (vm *VM) errorf(id msg.ID, args ...interface{}) error {
+	line, column := vm.bytecode.PositionOf(vm.ip)
+	return RuntimeError{Line: line, Column: column, Message: msg.Get(id, args...)}
+}
+
+func nativeBool(b bool) *object.Boolean {
+	if b {
+		return True
+	}
+	return False
+}
+
+func realValue(o object.Object) (float64, bool) {
+	switch o := o.(type) {
+	case *object.Integer:
+		return float64(o.Value), true
+	case *object.Real:
+		return o.Value, true
+	}
+	return 0, false
+}
@@ -0,0 +1,113 @@
+package vm
+
+import (
+	"errors"
+	"mini-parser/compiler"
+	"mini-parser/msg"
+	"mini-parser/parser"
+	"mini-parser/token"
+	"testing"
+)
+
+func run(t *testing.T, src string) (*VM, error) {
+	t.Helper()
+	p := parser.New(token.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("语法错误: %v", p.Errors())
+	}
+	c := compiler.New()
+	if err := c.Compile(program); err != nil {
+		t.Fatalf("编译错误: %v", err)
+	}
+	machine := New(c.Bytecode())
+	return machine, machine.Run()
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		src  string
+		want map[string]string
+	}{
+		{"x := 7 / 2; y := -7 % 3; z := 2 * (3 + 4) - 1", map[string]string{"x": "3", "y": "-1", "z": "13"}},
+		{"r := 7 / 2.0; s := -r; b := r > 3", map[string]string{"r": "3.5", "s": "-3.5", "b": "true"}},
+		{"b := 1 = 1.0; c := true != false; d := !(2 <= 1)", map[string]string{"b": "true", "c": "true", "d": "true"}},
+		{"if (1 > 2) then x := 1 else x := 2", map[string]string{"x": "2"}},
+		{"i := 0; s := 0; while (i < 5) do begin i := i + 1; s := s + i end", map[string]string{"i": "5", "s": "15"}},
+		// 短路求值: 右边的除以零不会执行
+		{"z := 0; a := false && 1 / z > 0; b := true || 1 / z > 0", map[string]string{"a": "false", "b": "true"}},
+		// 声明为 real 的变量把 integer 值转换为 real
+		{"program p; var r: real; begin r := 2; r := r / 4 end.", map[string]string{"r": "0.5"}},
+	}
+	for _, tt := range tests {
+		machine, err := run(t, tt.src)
+		if err != nil {
+			t.Errorf("%s: %v", tt.src, err)
+			continue
+		}
+		for name, want := range tt.want {
+			value, ok := machine.Lookup(name)
+			if !ok || value.Inspect() != want {
+				t.Errorf("%s: %s = %v, 期望 %s", tt.src, name, value, want)
+			}
+		}
+	}
+}
+
+// 运行错误的位置是出错指令对应的运算符或操作数
+func TestRuntimeErrors(t *testing.T) {
+	tests := []struct {
+		src          string
+		line, column int
+		message      string
+	}{
+		{"x := 0;\ny := 1 + 10 / x", 2, 13, msg.Get(msg.DivisionByZero)},
+		{"x := 0;\ny := 1.5 / x", 2, 10, msg.Get(msg.DivisionByZero)},
+		{"x := 0;\n\ny := 10 % x", 3, 9, msg.Get(msg.DivisionByZero)},
+		{"y := x + 1", 1, 6, msg.Get(msg.UnassignedVariable, "x")},
+		{"x := 1;\nif (x) then y := 1", 2, 1, msg.Get(msg.ConditionNotBoolean)},
+		{"b := true; y := b + 1", 1, 19, msg.Get(msg.ArithmeticOperands, "BOOLEAN", "INTEGER")},
+		{"b := true < false", 1, 11, msg.Get(msg.BooleanComparison)},
+		{"b := !1", 1, 6, msg.Get(msg.BangOperand)},
+		{"b := -true", 1, 6, msg.Get(msg.MinusOperand, "BOOLEAN")},
+		{"r := 1.5 % 2", 1, 10, msg.Get(msg.ModOperands)},
+	}
+	for _, tt := range tests {
+		_, err := run(t, tt.src)
+		var runtimeErr RuntimeError
+		if !errors.As(err, &runtimeErr) {
+			t.Errorf("%q: 期望运行错误, 实际为 %v", tt.src, err)
+			continue
+		}
+		if runtimeErr.Line != tt.line || runtimeErr.Column != tt.column || runtimeErr.Message != tt.message {
+			t.Errorf("%q: 得到 %d:%d %s, 期望 %d:%d %s", tt.src,
+				runtimeErr.Line, runtimeErr.Column, runtimeErr.Message, tt.line, tt.column, tt.message)
+		}
+	}
+}
+
+// 出错前完成的赋值保留, 新的字节码可以沿用这些值继续运行
+func TestGlobals(t *testing.T) {
+	machine, err := run(t, "x := 1; y := x / 0; z := 2")
+	if err == nil {
+		t.Fatal("期望除以零的错误")
+	}
+	globals := machine.Globals()
+	if len(globals) != 3 || globals[0].Inspect() != "1" || globals[1] != nil || globals[2] != nil {
+		t.Fatalf("变量的值 %v", globals)
+	}
+
+	p := parser.New(token.New("w := x + 1"))
+	program := p.ParseProgram()
+	c := compiler.NewWithState([]string{"x", "y", "z"}, nil)
+	if err := c.Compile(program); err != nil {
+		t.Fatal(err)
+	}
+	next := NewWithGlobals(c.Bytecode(), globals)
+	if err := next.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if w, ok := next.Lookup("w"); !ok || w.Inspect() != "2" {
+		t.Errorf("w = %v, 期望 2", w)
+	}
+}
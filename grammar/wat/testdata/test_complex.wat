@@ -0,0 +1,95 @@
+(module
+  (func $main (export "main") (result i64)
+    (local $mode i64)
+    (local $counter i64)
+    (local $total i64)
+    (local $result i64)
+    (local $a i64)
+    (local $b i64)
+    (local $c i64)
+    (local $d i64)
+    (local $e i64)
+    (local $f i64)
+    (local $init i64)
+    (local $step i64)
+    (local $limit i64)
+    local.get $mode
+    i64.const 1
+    i64.eq
+    i64.extend_i32_u
+    i32.wrap_i64
+    if
+      i64.const 0
+      local.set $counter
+      block $exit1
+        loop $loop1
+          local.get $counter
+          i64.const 10
+          i64.lt_s
+          i64.extend_i32_u
+          i32.wrap_i64
+          i32.eqz
+          br_if $exit1
+          local.get $counter
+          i64.const 2
+          i64.rem_s
+          i64.const 0
+          i64.eq
+          i64.extend_i32_u
+          i32.wrap_i64
+          if
+            local.get $total
+            local.get $counter
+            i64.add
+            local.set $total
+          else
+            local.get $total
+            local.get $counter
+            i64.sub
+            local.set $total
+          end
+          local.get $counter
+          i64.const 1
+          i64.add
+          local.set $counter
+          br $loop1
+        end
+      end
+    end
+    local.get $a
+    local.get $b
+    i64.add
+    local.get $c
+    local.get $d
+    i64.sub
+    i64.mul
+    local.get $e
+    local.get $f
+    i64.rem_s
+    i64.div_s
+    local.set $result
+    i64.const 0
+    local.set $init
+    i64.const 2
+    local.set $step
+    i64.const 100
+    local.set $limit
+    block $exit2
+      loop $loop2
+        local.get $init
+        local.get $limit
+        i64.lt_s
+        i64.extend_i32_u
+        i32.wrap_i64
+        i32.eqz
+        br_if $exit2
+        local.get $init
+        local.get $step
+        i64.add
+        local.set $init
+        br $loop2
+      end
+    end
+    i64.const 0
+  )
+)
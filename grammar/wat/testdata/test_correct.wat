@@ -0,0 +1,81 @@
+(module
+  (func $main (export "main") (result i64)
+    (local $x i64)
+    (local $y i64)
+    (local $max i64)
+    (local $i i64)
+    (local $sum i64)
+    (local $flag i64)
+    (local $a i64)
+    (local $b i64)
+    (local $c i64)
+    (local $d i64)
+    (local $temp i64)
+    (local $result i64)
+    i64.const 10
+    local.set $x
+    local.get $x
+    i64.const 5
+    i64.const 2
+    i64.mul
+    i64.add
+    local.set $y
+    local.get $x
+    i64.const 5
+    i64.gt_s
+    i64.extend_i32_u
+    i32.wrap_i64
+    if
+      local.get $x
+      local.set $max
+    end
+    i64.const 0
+    local.set $i
+    block $exit1
+      loop $loop1
+        local.get $i
+        i64.const 10
+        i64.lt_s
+        i64.extend_i32_u
+        i32.wrap_i64
+        i32.eqz
+        br_if $exit1
+        local.get $i
+        i64.const 1
+        i64.add
+        local.set $i
+        local.get $sum
+        local.get $i
+        i64.add
+        local.set $sum
+        br $loop1
+      end
+    end
+    local.get $a
+    local.get $b
+    i64.gt_s
+    i64.extend_i32_u
+    i32.wrap_i64
+    if (result i64)
+      local.get $c
+      local.get $d
+      i64.le_s
+      i64.extend_i32_u
+    else
+      i64.const 0
+    end
+    local.set $flag
+    i64.const 100
+    local.set $temp
+    local.get $temp
+    i64.const 100
+    i64.eq
+    i64.extend_i32_u
+    i32.wrap_i64
+    if
+      i64.const 1
+      local.set $result
+    end
+    i64.const 0
+  )
+)
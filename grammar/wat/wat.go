@@ -0,0 +1,239 @@
+package wat
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mini-parser/msg"
+	"mini-parser/parser"
+	"mini-parser/types"
+	"slices"
+	"strings"
+)
+
+// Generate 把Mini程序翻译为WebAssembly文本格式的模块, 导出的main函数返回变量result的值
+func Generate(program *parser.Program, result string) (string, error) {
+	if err := types.CheckIntegral(program, "WAT"); err != nil {
+		return "", err
+	}
+	g := &generator{locals: parser.Variables(program)}
+	if result != "" && !slices.Contains(g.locals, result) {
+		return "", errors.New(msg.Get(msg.NoSuchVariable, result))
+	}
+
+	g.line("(module")
+	g.indent++
+	g.line("(func $main (export \"main\") (result i64)")
+	g.indent++
+	for _, name := range g.locals {
+		g.line("(local $%s i64)", name)
+	}
+	for _, s := range program.Statements {
+		if err := g.statement(s); err != nil {
+			return "", err
+		}
+	}
+	if result != "" {
+		g.line("local.get $%s", result)
+	} else {
+		g.line("i64.const 0")
+	}
+	g.indent--
+	g.line(")")
+	g.indent--
+	g.line(")")
+
+	return g.out.String(), nil
+}
+
+type generator struct {
+	out    bytes.Buffer
+	locals []string
+	indent int
+	labels int
+}
+
+func (g *generator) line(format string, args ...interface{}) {
+	g.out.WriteString(strings.Repeat("  ", g.indent))
+	fmt.Fprintf(&g.out, format, args...)
+	g.out.WriteString("\n")
+}
+
+func (g *generator) statement(stmt parser.Statement) error {
+	switch s := stmt.(type) {
+	case *parser.AssignStatement:
+		if err := g.expression(s.Value); err != nil {
+			return err
+		}
+		g.line("local.set $%s", s.Name.Value)
+
+	case *parser.BlockStatement:
+		for _, inner := range s.Statements {
+			if err := g.statement(inner); err != nil {
+				return err
+			}
+		}
+
+	case *parser.IfExpression:
+		if err := g.condition(s.Condition); err != nil {
+			return err
+		}
+		g.line("if")
+		if err := g.nested(s.Consequence); err != nil {
+			return err
+		}
+		if s.Alternative != nil {
+			g.line("else")
+			if err := g.nested(s.Alternative); err != nil {
+				return err
+			}
+		}
+		g.line("end")
+
+	case *parser.WhileExpression:
+		g.labels++
+		exit := fmt.Sprintf("$exit%d", g.labels)
+		loop := fmt.Sprintf("$loop%d", g.labels)
+		g.line("block %s", exit)
+		g.indent++
+		g.line("loop %s", loop)
+		g.indent++
+		if err := g.condition(s.Condition); err != nil {
+			return err
+		}
+		g.line("i32.eqz")
+		g.line("br_if %s", exit)
+		if err := g.statement(s.Body); err != nil {
+			return err
+		}
+		g.line("br %s", loop)
+		g.indent--
+		g.line("end")
+		g.indent--
+		g.line("end")
+
+	default:
+		return errors.New(msg.Get(msg.UnsupportedStatement, "WAT", stmt))
+	}
+	return nil
+}
+
+func (g *generator) nested(block *parser.BlockStatement) error {
+	g.indent++
+	defer func() { g.indent-- }()
+	return g.statement(block)
+}
+
+// condition 计算条件并转换为if/br_if需要的i32
+func (g *generator) condition(expr parser.Expression) error {
+	if err := g.expression(expr); err != nil {
+		return err
+	}
+	g.line("i32.wrap_i64")
+	return nil
+}
+
+var instructions = map[string]string{
+	"+":  "i64.add",
+	"-":  "i64.sub",
+	"*":  "i64.mul",
+	"/":  "i64.div_s",
+	"%":  "i64.rem_s",
+	"=":  "i64.eq",
+	"!=": "i64.ne",
+	"<":  "i64.lt_s",
+	">":  "i64.gt_s",
+	"<=": "i64.le_s",
+	">=": "i64.ge_s",
+}
+
+var comparisons = map[string]bool{
+	"=": true, "!=": true, "<": true, ">": true, "<=": true, ">=": true,
+}
+
+// expression 生成计算表达式的指令, 结果为栈顶的i64, 布尔值用0和1表示
+func (g *generator) expression(expr parser.Expression) error {
+	switch e := expr.(type) {
+	case *parser.IntegerLiteral:
+		g.line("i64.const %d", e.Value)
+
+	case *parser.Boolean:
+		if e.Value {
+			g.line("i64.const 1")
+		} else {
+			g.line("i64.const 0")
+		}
+
+	case *parser.Identifier:
+		g.line("local.get $%s", e.Value)
+
+	case *parser.PrefixExpression:
+		if e.Operator == "-" {
+			g.line("i64.const 0")
+		}
+		if err := g.expression(e.Right); err != nil {
+			return err
+		}
+		if e.Operator == "-" {
+			g.line("i64.sub")
+		} else {
+			g.line("i64.eqz")
+			g.line("i64.extend_i32_u")
+		}
+
+	case *parser.InfixExpression:
+		if e.Operator == "&&" || e.Operator == "||" {
+			return g.logical(e)
+		}
+
+		if err := g.expression(e.Left); err != nil {
+			return err
+		}
+		if err := g.expression(e.Right); err != nil {
+			return err
+		}
+		ins, ok := instructions[e.Operator]
+		if !ok {
+			return errors.New(msg.Get(msg.UnsupportedOperator, "WAT", e.Operator))
+		}
+		g.line("%s", ins)
+		if comparisons[e.Operator] {
+			// 比较指令的结果是i32
+			g.line("i64.extend_i32_u")
+		}
+
+	case *parser.RealLiteral:
+		return errors.New(msg.Get(msg.Location, e.Token.Line, e.Token.Column, msg.Get(msg.RealUnsupported, "WAT")))
+
+	default:
+		return errors.New(msg.Get(msg.UnsupportedExpression, "WAT", expr))
+	}
+	return nil
+}
+
+// logical 用带结果的if实现 && 和 || 的短路求值
+func (g *generator) logical(e *parser.InfixExpression) error {
+	if err := g.condition(e.Left); err != nil {
+		return err
+	}
+	g.line("if (result i64)")
+	g.indent++
+	if e.Operator == "&&" {
+		if err := g.expression(e.Right); err != nil {
+			return err
+		}
+	} else {
+		g.line("i64.const 1")
+	}
+	g.indent--
+	g.line("else")
+	g.indent++
+	if e.Operator == "&&" {
+		g.line("i64.const 0")
+	} else if err := g.expression(e.Right); err != nil {
+		return err
+	}
+	g.indent--
+	g.line("end")
+	return nil
+}
@@ -0,0 +1,147 @@
+	.text
+	.globl _start
+_start:
+	pushq %rbp
+	movq %rsp, %rbp
+	subq $112, %rsp
+	movq $0, -8(%rbp)
+	movq $0, -16(%rbp)
+	movq $0, -24(%rbp)
+	movq $0, -32(%rbp)
+	movq $0, -40(%rbp)
+	movq $0, -48(%rbp)
+	movq $0, -56(%rbp)
+	movq $0, -64(%rbp)
+	movq $0, -72(%rbp)
+	movq $0, -80(%rbp)
+	movq $0, -88(%rbp)
+	movq $0, -96(%rbp)
+	movq $0, -104(%rbp)
+	movq -8(%rbp), %rax
+	pushq %rax
+	movq $1, %rax
+	movq %rax, %rcx
+	popq %rax
+	cmpq %rcx, %rax
+	sete %al
+	movzbq %al, %rax
+	cmpq $0, %rax
+	je .Lelse1
+	movq $0, %rax
+	movq %rax, -16(%rbp)
+.Lwhile3:
+	movq -16(%rbp), %rax
+	pushq %rax
+	movq $10, %rax
+	movq %rax, %rcx
+	popq %rax
+	cmpq %rcx, %rax
+	setl %al
+	movzbq %al, %rax
+	cmpq $0, %rax
+	je .Lendwhile4
+	movq -16(%rbp), %rax
+	pushq %rax
+	movq $2, %rax
+	movq %rax, %rcx
+	popq %rax
+	cqto
+	idivq %rcx
+	movq %rdx, %rax
+	pushq %rax
+	movq $0, %rax
+	movq %rax, %rcx
+	popq %rax
+	cmpq %rcx, %rax
+	sete %al
+	movzbq %al, %rax
+	cmpq $0, %rax
+	je .Lelse5
+	movq -24(%rbp), %rax
+	pushq %rax
+	movq -16(%rbp), %rax
+	movq %rax, %rcx
+	popq %rax
+	addq %rcx, %rax
+	movq %rax, -24(%rbp)
+	jmp .Lendif6
+.Lelse5:
+	movq -24(%rbp), %rax
+	pushq %rax
+	movq -16(%rbp), %rax
+	movq %rax, %rcx
+	popq %rax
+	subq %rcx, %rax
+	movq %rax, -24(%rbp)
+.Lendif6:
+	movq -16(%rbp), %rax
+	pushq %rax
+	movq $1, %rax
+	movq %rax, %rcx
+	popq %rax
+	addq %rcx, %rax
+	movq %rax, -16(%rbp)
+	jmp .Lwhile3
+.Lendwhile4:
+	jmp .Lendif2
+.Lelse1:
+.Lendif2:
+	movq -40(%rbp), %rax
+	pushq %rax
+	movq -48(%rbp), %rax
+	movq %rax, %rcx
+	popq %rax
+	addq %rcx, %rax
+	pushq %rax
+	movq -56(%rbp), %rax
+	pushq %rax
+	movq -64(%rbp), %rax
+	movq %rax, %rcx
+	popq %rax
+	subq %rcx, %rax
+	movq %rax, %rcx
+	popq %rax
+	imulq %rcx, %rax
+	pushq %rax
+	movq -72(%rbp), %rax
+	pushq %rax
+	movq -80(%rbp), %rax
+	movq %rax, %rcx
+	popq %rax
+	cqto
+	idivq %rcx
+	movq %rdx, %rax
+	movq %rax, %rcx
+	popq %rax
+	cqto
+	idivq %rcx
+	movq %rax, -32(%rbp)
+	movq $0, %rax
+	movq %rax, -88(%rbp)
+	movq $2, %rax
+	movq %rax, -96(%rbp)
+	movq $100, %rax
+	movq %rax, -104(%rbp)
+.Lwhile7:
+	movq -88(%rbp), %rax
+	pushq %rax
+	movq -104(%rbp), %rax
+	movq %rax, %rcx
+	popq %rax
+	cmpq %rcx, %rax
+	setl %al
+	movzbq %al, %rax
+	cmpq $0, %rax
+	je .Lendwhile8
+	movq -88(%rbp), %rax
+	pushq %rax
+	movq -96(%rbp), %rax
+	movq %rax, %rcx
+	popq %rax
+	addq %rcx, %rax
+	movq %rax, -88(%rbp)
+	jmp .Lwhile7
+.Lendwhile8:
+	movq $0, %rdi
+	movq $60, %rax
+	syscall
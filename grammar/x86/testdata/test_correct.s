@@ -0,0 +1,122 @@
+	.text
+	.globl _start
+_start:
+	pushq %rbp
+	movq %rsp, %rbp
+	subq $96, %rsp
+	movq $0, -8(%rbp)
+	movq $0, -16(%rbp)
+	movq $0, -24(%rbp)
+	movq $0, -32(%rbp)
+	movq $0, -40(%rbp)
+	movq $0, -48(%rbp)
+	movq $0, -56(%rbp)
+	movq $0, -64(%rbp)
+	movq $0, -72(%rbp)
+	movq $0, -80(%rbp)
+	movq $0, -88(%rbp)
+	movq $0, -96(%rbp)
+	movq $10, %rax
+	movq %rax, -8(%rbp)
+	movq -8(%rbp), %rax
+	pushq %rax
+	movq $5, %rax
+	pushq %rax
+	movq $2, %rax
+	movq %rax, %rcx
+	popq %rax
+	imulq %rcx, %rax
+	movq %rax, %rcx
+	popq %rax
+	addq %rcx, %rax
+	movq %rax, -16(%rbp)
+	movq -8(%rbp), %rax
+	pushq %rax
+	movq $5, %rax
+	movq %rax, %rcx
+	popq %rax
+	cmpq %rcx, %rax
+	setg %al
+	movzbq %al, %rax
+	cmpq $0, %rax
+	je .Lelse1
+	movq -8(%rbp), %rax
+	movq %rax, -24(%rbp)
+	jmp .Lendif2
+.Lelse1:
+.Lendif2:
+	movq $0, %rax
+	movq %rax, -32(%rbp)
+.Lwhile3:
+	movq -32(%rbp), %rax
+	pushq %rax
+	movq $10, %rax
+	movq %rax, %rcx
+	popq %rax
+	cmpq %rcx, %rax
+	setl %al
+	movzbq %al, %rax
+	cmpq $0, %rax
+	je .Lendwhile4
+	movq -32(%rbp), %rax
+	pushq %rax
+	movq $1, %rax
+	movq %rax, %rcx
+	popq %rax
+	addq %rcx, %rax
+	movq %rax, -32(%rbp)
+	movq -40(%rbp), %rax
+	pushq %rax
+	movq -32(%rbp), %rax
+	movq %rax, %rcx
+	popq %rax
+	addq %rcx, %rax
+	movq %rax, -40(%rbp)
+	jmp .Lwhile3
+.Lendwhile4:
+	movq -56(%rbp), %rax
+	pushq %rax
+	movq -64(%rbp), %rax
+	movq %rax, %rcx
+	popq %rax
+	cmpq %rcx, %rax
+	setg %al
+	movzbq %al, %rax
+	cmpq $0, %rax
+	je .Lshort5
+	movq -72(%rbp), %rax
+	pushq %rax
+	movq -80(%rbp), %rax
+	movq %rax, %rcx
+	popq %rax
+	cmpq %rcx, %rax
+	setle %al
+	movzbq %al, %rax
+	cmpq $0, %rax
+	je .Lshort5
+	movq $1, %rax
+	jmp .Lendlogic6
+.Lshort5:
+	movq $0, %rax
+.Lendlogic6:
+	movq %rax, -48(%rbp)
+	movq $100, %rax
+	movq %rax, -88(%rbp)
+	movq -88(%rbp), %rax
+	pushq %rax
+	movq $100, %rax
+	movq %rax, %rcx
+	popq %rax
+	cmpq %rcx, %rax
+	sete %al
+	movzbq %al, %rax
+	cmpq $0, %rax
+	je .Lelse7
+	movq $1, %rax
+	movq %rax, -96(%rbp)
+	jmp .Lendif8
+.Lelse7:
+.Lendif8:
+	movq $0, %rdi
+	movq $60, %rax
+	syscall
@@ -0,0 +1,296 @@
+package x86
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mini-parser/msg"
+	"mini-parser/parser"
+	"mini-parser/types"
+)
+
+type Storage int
+
+const (
+	StackSlots Storage = iota // 变量保存在_start的栈帧中
+	Globals                   // 变量保存在.bss段中
+)
+
+type Options struct {
+	Storage Storage
+	// Result 程序退出码取该变量的值, 为空时退出码为0
+	Result string
+}
+
+// Generate 把Mini程序翻译为GNU as可以汇编的x86-64 AT&T语法汇编
+func Generate(program *parser.Program, opts Options) (string, error) {
+	if err := types.CheckIntegral(program, "x86"); err != nil {
+		return "", err
+	}
+	g := &generator{opts: opts, slots: map[string]int{}}
+	for _, name := range parser.Variables(program) {
+		g.slot(name)
+	}
+	if opts.Result != "" {
+		if _, ok := g.slots[opts.Result]; !ok {
+			return "", errors.New(msg.Get(msg.NoSuchVariable, opts.Result))
+		}
+	}
+
+	g.prologue()
+	for _, s := range program.Statements {
+		if err := g.statement(s); err != nil {
+			return "", err
+		}
+	}
+	g.epilogue()
+
+	return g.out.String(), nil
+}
+
+type generator struct {
+	out    bytes.Buffer
+	opts   Options
+	slots  map[string]int
+	names  []string
+	labels int
+}
+
+func (g *generator) slot(name string) int {
+	if s, ok := g.slots[name]; ok {
+		return s
+	}
+	s := len(g.names)
+	g.slots[name] = s
+	g.names = append(g.names, name)
+	return s
+}
+
+// operand 返回变量的内存操作数
+func (g *generator) operand(name string) string {
+	if g.opts.Storage == Globals {
+		return "mini_" + name + "(%rip)"
+	}
+	return fmt.Sprintf("-%d(%%rbp)", 8*(g.slots[name]+1))
+}
+
+func (g *generator) newLabel(kind string) string {
+	g.labels++
+	return fmt.Sprintf(".L%s%d", kind, g.labels)
+}
+
+func (g *generator) emit(format string, args ...interface{}) {
+	g.out.WriteString("\t")
+	fmt.Fprintf(&g.out, format, args...)
+	g.out.WriteString("\n")
+}
+
+func (g *generator) label(name string) {
+	g.out.WriteString(name + ":\n")
+}
+
+func (g *generator) prologue() {
+	if g.opts.Storage == Globals && len(g.names) > 0 {
+		g.emit(".bss")
+		g.emit(".align 8")
+		for _, name := range g.names {
+			g.label("mini_" + name)
+			g.emit(".zero 8")
+		}
+	}
+
+	g.emit(".text")
+	g.emit(".globl _start")
+	g.label("_start")
+	g.emit("pushq %%rbp")
+	g.emit("movq %%rsp, %%rbp")
+
+	if g.opts.Storage == StackSlots && len(g.names) > 0 {
+		// 栈帧按16字节对齐, 所有变量初始化为0
+		size := (8*len(g.names) + 15) / 16 * 16
+		g.emit("subq $%d, %%rsp", size)
+		for _, name := range g.names {
+			g.emit("movq $0, %s", g.operand(name))
+		}
+	}
+}
+
+// epilogue 以exit系统调用结束程序
+func (g *generator) epilogue() {
+	if g.opts.Result != "" {
+		g.emit("movq %s, %%rdi", g.operand(g.opts.Result))
+	} else {
+		g.emit("movq $0, %%rdi")
+	}
+	g.emit("movq $60, %%rax")
+	g.emit("syscall")
+}
+
+func (g *generator) statement(stmt parser.Statement) error {
+	switch s := stmt.(type) {
+	case *parser.AssignStatement:
+		if err := g.expression(s.Value); err != nil {
+			return err
+		}
+		g.emit("movq %%rax, %s", g.operand(s.Name.Value))
+
+	case *parser.BlockStatement:
+		for _, inner := range s.Statements {
+			if err := g.statement(inner); err != nil {
+				return err
+			}
+		}
+
+	case *parser.IfExpression:
+		elseLabel := g.newLabel("else")
+		endLabel := g.newLabel("endif")
+		if err := g.expression(s.Condition); err != nil {
+			return err
+		}
+		g.emit("cmpq $0, %%rax")
+		g.emit("je %s", elseLabel)
+		if err := g.statement(s.Consequence); err != nil {
+			return err
+		}
+		g.emit("jmp %s", endLabel)
+		g.label(elseLabel)
+		if s.Alternative != nil {
+			if err := g.statement(s.Alternative); err != nil {
+				return err
+			}
+		}
+		g.label(endLabel)
+
+	case *parser.WhileExpression:
+		loopLabel := g.newLabel("while")
+		endLabel := g.newLabel("endwhile")
+		g.label(loopLabel)
+		if err := g.expression(s.Condition); err != nil {
+			return err
+		}
+		g.emit("cmpq $0, %%rax")
+		g.emit("je %s", endLabel)
+		if err := g.statement(s.Body); err != nil {
+			return err
+		}
+		g.emit("jmp %s", loopLabel)
+		g.label(endLabel)
+
+	default:
+		return errors.New(msg.Get(msg.UnsupportedStatement, "x86", stmt))
+	}
+	return nil
+}
+
+var setcc = map[string]string{
+	"=":  "sete",
+	"!=": "setne",
+	"<":  "setl",
+	">":  "setg",
+	"<=": "setle",
+	">=": "setge",
+}
+
+// expression 生成计算表达式的代码, 结果保存在%rax中
+func (g *generator) expression(expr parser.Expression) error {
+	switch e := expr.(type) {
+	case *parser.IntegerLiteral:
+		g.emit("movq $%d, %%rax", e.Value)
+
+	case *parser.Boolean:
+		if e.Value {
+			g.emit("movq $1, %%rax")
+		} else {
+			g.emit("movq $0, %%rax")
+		}
+
+	case *parser.Identifier:
+		g.emit("movq %s, %%rax", g.operand(e.Value))
+
+	case *parser.PrefixExpression:
+		if err := g.expression(e.Right); err != nil {
+			return err
+		}
+		switch e.Operator {
+		case "-":
+			g.emit("negq %%rax")
+		case "!":
+			g.emit("xorq $1, %%rax")
+		}
+
+	case *parser.InfixExpression:
+		if e.Operator == "&&" || e.Operator == "||" {
+			return g.logical(e)
+		}
+
+		if err := g.expression(e.Left); err != nil {
+			return err
+		}
+		g.emit("pushq %%rax")
+		if err := g.expression(e.Right); err != nil {
+			return err
+		}
+		g.emit("movq %%rax, %%rcx")
+		g.emit("popq %%rax")
+
+		switch e.Operator {
+		case "+":
+			g.emit("addq %%rcx, %%rax")
+		case "-":
+			g.emit("subq %%rcx, %%rax")
+		case "*":
+			g.emit("imulq %%rcx, %%rax")
+		case "/":
+			g.emit("cqto")
+			g.emit("idivq %%rcx")
+		case "%":
+			g.emit("cqto")
+			g.emit("idivq %%rcx")
+			g.emit("movq %%rdx, %%rax")
+		default:
+			set, ok := setcc[e.Operator]
+			if !ok {
+				return errors.New(msg.Get(msg.UnsupportedOperator, "x86", e.Operator))
+			}
+			g.emit("cmpq %%rcx, %%rax")
+			g.emit("%s %%al", set)
+			g.emit("movzbq %%al, %%rax")
+		}
+
+	case *parser.RealLiteral:
+		return errors.New(msg.Get(msg.Location, e.Token.Line, e.Token.Column, msg.Get(msg.RealUnsupported, "x86")))
+
+	default:
+		return errors.New(msg.Get(msg.UnsupportedExpression, "x86", expr))
+	}
+	return nil
+}
+
+// logical 按短路语义生成 && 和 ||
+func (g *generator) logical(e *parser.InfixExpression) error {
+	shortLabel := g.newLabel("short")
+	endLabel := g.newLabel("endlogic")
+
+	// && 在操作数为假时短路, || 在操作数为真时短路
+	jump, shortValue := "je", 0
+	if e.Operator == "||" {
+		jump, shortValue = "jne", 1
+	}
+
+	if err := g.expression(e.Left); err != nil {
+		return err
+	}
+	g.emit("cmpq $0, %%rax")
+	g.emit("%s %s", jump, shortLabel)
+	if err := g.expression(e.Right); err != nil {
+		return err
+	}
+	g.emit("cmpq $0, %%rax")
+	g.emit("%s %s", jump, shortLabel)
+	g.emit("movq $%d, %%rax", 1-shortValue)
+	g.emit("jmp %s", endLabel)
+	g.label(shortLabel)
+	g.emit("movq $%d, %%rax", shortValue)
+	g.label(endLabel)
+	return nil
+}
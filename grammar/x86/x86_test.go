@@ -0,0 +1,50 @@
+package x86
+
+import (
+	"flag"
+	"mini-parser/parser"
+	"mini-parser/token"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "用当前的输出更新 testdata 中的期望文件")
+
+// 示例程序在上一级目录, 期望的汇编在 testdata/<名称>.s
+var samples = []string{"test_correct", "test_complex"}
+
+func TestGolden(t *testing.T) {
+	for _, name := range samples {
+		t.Run(name, func(t *testing.T) {
+			src, err := os.ReadFile(filepath.Join("..", name+".mini"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			p := parser.New(token.New(string(src)))
+			program := p.ParseProgram()
+			if len(p.Errors()) > 0 {
+				t.Fatalf("语法错误: %v", p.Errors())
+			}
+			got, err := Generate(program, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", name+".s")
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("Generate 的输出与 %s 不同, 确认无误后用 go test -update 更新\n%s", golden, got)
+			}
+		})
+	}
+}
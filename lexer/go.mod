@@ -1,3 +1,7 @@
 module mini-lexer
 
 go 1.24.1
+
+require mini-parser v0.0.0
+
+replace mini-parser => ../grammar
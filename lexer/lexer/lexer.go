@@ -1,8 +1,10 @@
 package lexer
 
 import (
-	"fmt"
+	"errors"
+	"mini-parser/msg"
 	"strconv"
+	"unicode/utf8"
 )
 
 // 辅助函数
@@ -36,6 +38,17 @@ func (l *Lexer) readChar() {
 	l.readPos += 1
 }
 
+// column 返回当前字符所在的列号(从1开始)
+func (l *Lexer) column() int {
+	return utf8.RuneCountInString(l.input[l.lineStart:l.position]) + 1
+}
+
+// newLine 在读到换行符时更新行号和行首位置
+func (l *Lexer) newLine() {
+	l.line++
+	l.lineStart = l.position + 1
+}
+
 // peekChar 预读下一个字符
 func (l *Lexer) peekChar() byte {
 	if l.readPos >= len(l.input) {
@@ -48,7 +61,7 @@ func (l *Lexer) peekChar() byte {
 func (l *Lexer) skipWhitespace() {
 	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
 		if l.ch == '\n' {
-			l.line++
+			l.newLine()
 		}
 		l.readChar()
 	}
@@ -72,6 +85,9 @@ func (l *Lexer) skipComment() {
 			if l.ch == 0 { // 文件结束
 				return
 			}
+			if l.ch == '\n' {
+				l.newLine()
+			}
 			if l.ch == '*' && l.peekChar() == '/' {
 				l.readChar() // 跳过 *
 				l.readChar() // 跳过 /
@@ -92,7 +108,7 @@ func (l *Lexer) readNumber() (string, error) {
 		for isDigit(l.ch) {
 			l.readChar()
 		}
-		return "", fmt.Errorf("illegal number format: leading zeros not allowed")
+		return l.input[position:l.position], errors.New(msg.Get(msg.LeadingZeros))
 	}
 
 	for {
@@ -101,12 +117,12 @@ func (l *Lexer) readNumber() (string, error) {
 		} else if l.ch == '.' {
 			dotCount++
 			if dotCount > 1 {
-				return "", fmt.Errorf("illegal number format: multiple decimal points")
+				return l.input[position:l.position], errors.New(msg.Get(msg.MultipleDecimalPoints))
 			}
 			l.readChar()
 			// 小数点后必须有数字
 			if !isDigit(l.ch) {
-				return "", fmt.Errorf("illegal number format: decimal point must be followed by digits")
+				return l.input[position:l.position], errors.New(msg.Get(msg.DecimalPointNeedsDigits))
 			}
 		} else {
 			break
@@ -115,7 +131,8 @@ func (l *Lexer) readNumber() (string, error) {
 
 	// 检查数字后是否紧跟字母（非法标识符）
 	if isLetter(l.ch) {
-		return "", fmt.Errorf("illegal identifier: cannot start with number")
+		// 错误文本包含紧跟的字母, 调用方会跳过它
+		return l.input[position : l.position+1], errors.New(msg.Get(msg.IdentifierStartsWithDigit))
 	}
 
 	return l.input[position:l.position], nil
@@ -127,7 +144,7 @@ func (l *Lexer) readIdentifier() (string, error) {
 
 	// 第一个字符必须是字母或下划线
 	if !isLetter(l.ch) {
-		return "", fmt.Errorf("illegal identifier: must start with letter or underscore")
+		return "", errors.New(msg.Get(msg.IdentifierStart))
 	}
 
 	for isLetter(l.ch) || isDigit(l.ch) {
@@ -141,7 +158,7 @@ func (l *Lexer) readIdentifier() (string, error) {
 func (l *Lexer) NextToken() Token {
 	l.skipWhitespace()
 
-	tok := Token{Line: l.line}
+	tok := Token{Line: l.line, Column: l.column()}
 
 	switch l.ch {
 	case '=':
@@ -162,23 +179,24 @@ func (l *Lexer) NextToken() Token {
 				return Token{
 					Type:   ERROR,
 					Lexeme: "",
-					Value:  "missing expression after '='",
+					Value:  msg.Get(msg.MissingExpression),
 					Line:   l.line,
+					Column: l.column(),
 				}
 			}
 			return tok
 		}
 		l.readChar()
 	case '+':
-		tok = Token{Type: PLUS, Lexeme: string(l.ch), Line: l.line}
+		tok = Token{Type: PLUS, Lexeme: string(l.ch), Line: l.line, Column: l.column()}
 		l.symbols.AddOperator("+", PLUS) // 动态添加运算符
 		l.readChar()
 	case '-':
-		tok = Token{Type: MINUS, Lexeme: string(l.ch), Line: l.line}
+		tok = Token{Type: MINUS, Lexeme: string(l.ch), Line: l.line, Column: l.column()}
 		l.symbols.AddOperator("-", MINUS) // 动态添加运算符
 		l.readChar()
 	case '*':
-		tok = Token{Type: MULTIPLY, Lexeme: string(l.ch), Line: l.line}
+		tok = Token{Type: MULTIPLY, Lexeme: string(l.ch), Line: l.line, Column: l.column()}
 		l.symbols.AddOperator("*", MULTIPLY) // 动态添加运算符
 		l.readChar()
 	case '/':
@@ -187,27 +205,27 @@ func (l *Lexer) NextToken() Token {
 			l.skipComment()
 			return l.NextToken() // 递归调用获取下一个有效token
 		}
-		tok = Token{Type: DIVIDE, Lexeme: string(l.ch), Line: l.line}
+		tok = Token{Type: DIVIDE, Lexeme: string(l.ch), Line: l.line, Column: l.column()}
 		l.symbols.AddOperator("/", DIVIDE) // 动态添加运算符
 		l.readChar()
 	case '>':
-		tok = Token{Type: GT, Lexeme: string(l.ch), Line: l.line}
+		tok = Token{Type: GT, Lexeme: string(l.ch), Line: l.line, Column: l.column()}
 		l.symbols.AddOperator(">", GT) // 动态添加运算符
 		l.readChar()
 	case '<':
-		tok = Token{Type: LT, Lexeme: string(l.ch), Line: l.line}
+		tok = Token{Type: LT, Lexeme: string(l.ch), Line: l.line, Column: l.column()}
 		l.symbols.AddOperator("<", LT) // 动态添加运算符
 		l.readChar()
 	case '(':
-		tok = Token{Type: LPAREN, Lexeme: string(l.ch), Line: l.line}
+		tok = Token{Type: LPAREN, Lexeme: string(l.ch), Line: l.line, Column: l.column()}
 		l.symbols.AddDelimiter("(", LPAREN) // 动态添加界符
 		l.readChar()
 	case ')':
-		tok = Token{Type: RPAREN, Lexeme: string(l.ch), Line: l.line}
+		tok = Token{Type: RPAREN, Lexeme: string(l.ch), Line: l.line, Column: l.column()}
 		l.symbols.AddDelimiter(")", RPAREN) // 动态添加界符
 		l.readChar()
 	case ';':
-		tok = Token{Type: SEMICOLON, Lexeme: string(l.ch), Line: l.line}
+		tok = Token{Type: SEMICOLON, Lexeme: string(l.ch), Line: l.line, Column: l.column()}
 		l.symbols.AddDelimiter(";", SEMICOLON) // 动态添加界符
 		l.readChar()
 	case '#':
@@ -278,7 +296,7 @@ func (l *Lexer) NextToken() Token {
 			// 非法字符处理
 			tok.Type = ERROR
 			tok.Lexeme = string(l.ch)
-			tok.Value = "illegal character"
+			tok.Value = msg.Get(msg.IllegalCharacter)
 			l.readChar()
 		}
 	}
@@ -3,6 +3,8 @@ package lexer
 import (
 	"bufio"
 	"fmt"
+	"mini-parser/diag"
+	"unicode/utf8"
 )
 
 // PrintTables 输出各类单词表
@@ -40,7 +42,7 @@ func (l *Lexer) PrintTables(writer *bufio.Writer) {
 
 // PrintToken 增强版的词法单元输出函数
 func PrintToken(writer *bufio.Writer, token Token) {
-	fmt.Fprintf(writer, "Line %d: Type: %v, Lexeme: %s", token.Line, token.Type.String(), token.Lexeme)
+	fmt.Fprintf(writer, "Line %d:%d: Type: %v, Lexeme: %s", token.Line, token.Column, token.Type.String(), token.Lexeme)
 
 	switch token.Type {
 	case IDENTIFIER:
@@ -63,3 +65,14 @@ func PrintToken(writer *bufio.Writer, token Token) {
 
 	fmt.Fprintln(writer) // 换行
 }
+
+// Diagnostic 把错误单元转换为诊断信息, 供渲染器输出源码片段
+func (t Token) Diagnostic() diag.Diagnostic {
+	return diag.Diagnostic{
+		Severity: diag.Error,
+		Line:     t.Line,
+		Column:   t.Column,
+		Length:   utf8.RuneCountInString(t.Lexeme),
+		Message:  fmt.Sprint(t.Value),
+	}
+}
@@ -40,6 +40,7 @@ type Token struct {
 	Lexeme string
 	Value  interface{}
 	Line   int
+	Column int
 }
 
 // 在 TokenType 定义后添加
@@ -68,12 +69,13 @@ type SymbolTable struct {
 
 // Lexer 词法分析器结构
 type Lexer struct {
-	input    string
-	position int
-	readPos  int
-	ch       byte
-	line     int
-	symbols  *SymbolTable
+	input     string
+	position  int
+	readPos   int
+	ch        byte
+	line      int
+	lineStart int // 当前行第一个字符的位置
+	symbols   *SymbolTable
 }
 
 // NumberValue 存储数字常量的值和索引
@@ -2,13 +2,23 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"os"
 
 	"mini-lexer/lexer"
+	"mini-parser/diag"
+	"mini-parser/msg"
 )
 
 func main() {
+	lang := flag.String("lang", "", "诊断信息的语言: zh-CN, en (默认取自 LANG 环境变量)")
+	flag.Parse()
+	if err := msg.Select(*lang); err != nil {
+		fmt.Println(err)
+		return
+	}
+
 	// 读取源文件
 	sourceCode, err := os.ReadFile("source.txt")
 	if err != nil {
@@ -29,11 +39,19 @@ func main() {
 
 	writer := bufio.NewWriter(outputFile)
 
+	// 终端上的诊断信息带颜色, 写入文件的保持纯文本
+	console := diag.NewRenderer("source.txt", string(sourceCode), diag.IsTerminal(os.Stdout))
+	plain := diag.NewRenderer("source.txt", string(sourceCode), false)
+
 	// 词法分析过程
 	fmt.Fprintf(writer, "=== Lexical Analysis Results  ===\n")
 	for {
 		token := l.NextToken()
 		lexer.PrintToken(writer, token)
+		if token.Type == lexer.ERROR {
+			fmt.Fprintln(writer, plain.Render(token.Diagnostic()))
+			fmt.Println(console.Render(token.Diagnostic()))
+		}
 
 		if token.Type == lexer.EOF {
 			break